@@ -5,16 +5,28 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"flag"
 	"os"
 
 	"github.com/hyperledger-labs/microfab/internal/app/microfabd"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "microfabd"), log.LstdFlags)
+var logger = logging.New("microfabd")
+
+var couchDBRequired = flag.Bool("couchdb-required", false, "exit with status 0 if the configuration, after applying any profiles, needs CouchDB, or 1 if it does not")
 
 func main() {
+	flag.Parse()
+	if *couchDBRequired {
+		config, err := microfabd.DefaultConfig()
+		if err != nil {
+			logger.Fatalf("Failed to load configuration: %v", err)
+		} else if !config.AnyUsesCouchDB() {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 	microfabd, err := microfabd.New()
 	if err != nil {
 		logger.Fatalf("Failed to create application: %v", err)
@@ -16,12 +16,9 @@ if ! whoami &> /dev/null; then
 fi
 
 
-if [ -n "${MICROFAB_CONFIG:-}" ]; then
-    COUCHDB_ENABLED=$(echo "${MICROFAB_CONFIG}" | jq -r '. | if has("couchdb") then .couchdb else true end')
-    if [ "${COUCHDB_ENABLED}" = "true" ]; then
-        couchdb &
-    fi
-else
+# Only start CouchDB if the configuration needs it, once any profiles have been applied. If the configuration is
+# invalid, CouchDB is not started, and microfabd reports the error below.
+if microfabd --couchdb-required > /dev/null 2>&1; then
     couchdb &
 fi
 exec microfabd
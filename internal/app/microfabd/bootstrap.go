@@ -0,0 +1,141 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package microfabd
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/configtx"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/pkg/errors"
+)
+
+// Bootstrap represents the configuration for bootstrapping the network from an existing configtx.yaml file and
+// crypto-config directory.
+type Bootstrap struct {
+	ConfigTx     string `json:"configtx"`
+	Profile      string `json:"profile"`
+	CryptoConfig string `json:"crypto_config"`
+}
+
+type bootstrapOrganization struct {
+	organization *configtx.Organization
+	kind         string
+}
+
+// applyBootstrap replaces the organizations and channel membership in the configuration with those from the
+// configtx.yaml profile.
+func (c *Config) applyBootstrap() error {
+	configTx, err := configtx.Load(c.Bootstrap.ConfigTx)
+	if err != nil {
+		return err
+	}
+	profile, err := configTx.Profile(c.Bootstrap.Profile)
+	if err != nil {
+		return err
+	}
+	orderingOrganizations := profile.OrderingOrganizations()
+	if len(orderingOrganizations) == 0 {
+		return fmt.Errorf("Profile %s does not contain any ordering organizations", c.Bootstrap.Profile)
+	}
+	endorsingOrganizations := profile.EndorsingOrganizations()
+	if len(endorsingOrganizations) == 0 {
+		return fmt.Errorf("Profile %s does not contain any endorsing organizations", c.Bootstrap.Profile)
+	}
+	c.bootstrapTx = configTx
+	c.bootstrapOrganizations = map[string]*bootstrapOrganization{}
+	c.OrderingOrganization = Organization{Name: orderingOrganizations[0].Name}
+	c.bootstrapOrganizations[orderingOrganizations[0].Name] = &bootstrapOrganization{orderingOrganizations[0], "ordererOrganizations"}
+	stateDatabases := map[string]string{}
+	for _, organization := range c.EndorsingOrganizations {
+		stateDatabases[organization.Name] = organization.StateDatabase
+	}
+	c.EndorsingOrganizations = []Organization{}
+	for _, organization := range endorsingOrganizations {
+		c.EndorsingOrganizations = append(c.EndorsingOrganizations, Organization{
+			Name:          organization.Name,
+			StateDatabase: stateDatabases[organization.Name],
+		})
+		c.bootstrapOrganizations[organization.Name] = &bootstrapOrganization{organization, "peerOrganizations"}
+	}
+	for i := range c.Channels {
+		channel := &c.Channels[i]
+		channelProfile := profile
+		if channel.Profile != "" {
+			channelProfile, err = configTx.Profile(channel.Profile)
+			if err != nil {
+				return err
+			}
+		}
+		channel.EndorsingOrganizations = []string{}
+		for _, organization := range channelProfile.EndorsingOrganizations() {
+			if _, ok := c.bootstrapOrganizations[organization.Name]; !ok {
+				return fmt.Errorf("Channel %s contains organization %s, which is not in profile %s", channel.Name, organization.Name, c.Bootstrap.Profile)
+			}
+			channel.EndorsingOrganizations = append(channel.EndorsingOrganizations, organization.Name)
+		}
+		if channel.CapabilityLevel == "" {
+			channel.CapabilityLevel = channelProfile.CapabilityLevel()
+		}
+		channel.Policies, err = channelProfile.ApplicationPolicies()
+		if err != nil {
+			return errors.WithMessagef(err, "failed to build policies for channel %s", channel.Name)
+		}
+	}
+	return nil
+}
+
+// loadBootstrapMaterial loads the crypto material for the specified organization from the crypto-config directory,
+// or returns nil if the network is not being bootstrapped.
+func (m *Microfab) loadBootstrapMaterial(name string) (*configtx.Material, error) {
+	if m.config.bootstrapTx == nil {
+		return nil, nil
+	}
+	bootstrap := m.config.bootstrapOrganizations[name]
+	return m.config.bootstrapTx.LoadMaterial(bootstrap.organization, m.config.Bootstrap.CryptoConfig, bootstrap.kind)
+}
+
+// validForHostnames returns true if the TLS certificate of the identity is valid for all of the specified hostnames.
+func validForHostnames(tls *identity.Identity, hostnames []string) bool {
+	for _, hostname := range hostnames {
+		if tls.Certificate().Certificate().VerifyHostname(hostname) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// applyBootstrapOrganization applies the MSP ID, policies, and admin, node and node TLS identities from the
+// configtx.yaml file and crypto-config directory to the specified organization.
+func (m *Microfab) applyBootstrapOrganization(organization *organization.Organization) error {
+	material, err := m.loadBootstrapMaterial(organization.Name())
+	if err != nil {
+		return err
+	} else if material == nil {
+		return nil
+	}
+	bootstrap := m.config.bootstrapOrganizations[organization.Name()]
+	if bootstrap.organization.ID != "" {
+		organization.SetMSPID(bootstrap.organization.ID)
+	}
+	policies, err := configtx.BuildPolicies(bootstrap.organization.Policies)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to build policies for organization %s", organization.Name())
+	}
+	organization.SetPolicies(policies)
+	if material.Admin != nil {
+		organization.SetAdmin(material.Admin)
+	}
+	m.Lock()
+	if material.Node != nil {
+		m.nodeIdentities[organization.Name()] = material.Node
+	}
+	if material.NodeTLS != nil {
+		m.bootstrapNodeTLS[organization.Name()] = material.NodeTLS
+	}
+	m.Unlock()
+	return nil
+}
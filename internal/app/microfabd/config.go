@@ -5,22 +5,32 @@
 package microfabd
 
 import (
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path"
+	"strings"
 	"time"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/configtx"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/pkg/errors"
 )
 
 // Organization represents an organization in the configuration.
 type Organization struct {
-	Name string `json:"name"`
+	Name          string `json:"name"`
+	StateDatabase string `json:"state_database,omitempty"`
 }
 
 // Channel represents a channel in the configuration.
 type Channel struct {
-	Name                   string   `json:"name"`
-	EndorsingOrganizations []string `json:"endorsing_organizations"`
-	CapabilityLevel        string   `json:"capability_level"`
+	Name                   string                    `json:"name"`
+	EndorsingOrganizations []string                  `json:"endorsing_organizations"`
+	CapabilityLevel        string                    `json:"capability_level"`
+	Profile                string                    `json:"profile,omitempty"`
+	Policies               map[string]*common.Policy `json:"-"`
 }
 
 // TLS represents the TLS configuration.
@@ -31,20 +41,104 @@ type TLS struct {
 	CA          *string `json:"ca"`
 }
 
+// Tracing represents the tracing configuration.
+type Tracing struct {
+	Enabled  bool   `json:"enabled"`
+	Exporter string `json:"exporter"`
+	File     string `json:"file"`
+	Endpoint string `json:"endpoint"`
+}
+
+// Logging represents the logging configuration.
+type Logging struct {
+	Format     string            `json:"format"`
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// Overrides represents the configuration overrides for the Fabric components.
+type Overrides struct {
+	Peer    map[string]interface{} `json:"peer"`
+	Orderer map[string]interface{} `json:"orderer"`
+	CA      map[string]interface{} `json:"ca"`
+}
+
+// Export represents the configuration for exporting the network to other formats.
+type Export struct {
+	DockerCompose string `json:"docker_compose"`
+	CryptoConfig  string `json:"crypto_config"`
+	FabricVersion string `json:"fabric_version"`
+}
+
+// ExternalNode represents a peer or orderer that runs outside of Microfab, for example under a debugger.
+type ExternalNode struct {
+	Type           string `json:"type"`
+	Organization   string `json:"organization"`
+	Hostname       string `json:"hostname"`
+	APIPort        int32  `json:"api_port"`
+	ChaincodePort  int32  `json:"chaincode_port"`
+	OperationsPort int32  `json:"operations_port"`
+	TLSCA          string `json:"tls_ca"`
+}
+
+// OrderingService represents an existing ordering service that is used instead of starting an orderer.
+type OrderingService struct {
+	APIURL        string `json:"api_url"`
+	MSPID         string `json:"msp_id"`
+	TLSCA         string `json:"tls_ca"`
+	AdminMSP      string `json:"admin_msp"`
+	SystemChannel string `json:"system_channel"`
+	Consortium    string `json:"consortium"`
+}
+
+// ConsoleAuth represents the authentication configuration for the console.
+type ConsoleAuth struct {
+	Tokens             []ConsoleToken `json:"tokens"`
+	Users              []ConsoleUser  `json:"users"`
+	ClientCertificates bool           `json:"client_certificates"`
+}
+
+// ConsoleToken represents a bearer token that can access the console, and the organizations that it can access.
+type ConsoleToken struct {
+	Token         string   `json:"token"`
+	Organizations []string `json:"organizations"`
+}
+
+// ConsoleUser represents a user that can access the console with basic authentication, and the organizations that
+// the user can access.
+type ConsoleUser struct {
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	Organizations []string `json:"organizations"`
+}
+
 // Config represents the configuration.
 type Config struct {
-	Domain                 string         `json:"domain"`
-	Port                   int            `json:"port"`
-	Directory              string         `json:"directory"`
-	OrderingOrganization   Organization   `json:"ordering_organization"`
-	EndorsingOrganizations []Organization `json:"endorsing_organizations"`
-	Channels               []Channel      `json:"channels"`
-	CapabilityLevel        string         `json:"capability_level"`
-	CouchDB                bool           `json:"couchdb"`
-	CertificateAuthorities bool           `json:"certificate_authorities"`
-	TimeoutString          string         `json:"timeout"`
-	TLS                    TLS            `json:"tls"`
-	Timeout                time.Duration  `json:"-"`
+	Profile                string          `json:"profile"`
+	Domain                 string          `json:"domain"`
+	Port                   int             `json:"port"`
+	Directory              string          `json:"directory"`
+	OrderingOrganization   Organization    `json:"ordering_organization"`
+	EndorsingOrganizations []Organization  `json:"endorsing_organizations"`
+	Channels               []Channel       `json:"channels"`
+	CapabilityLevel        string          `json:"capability_level"`
+	CouchDB                bool            `json:"couchdb"`
+	CertificateAuthorities bool            `json:"certificate_authorities"`
+	TimeoutString          string          `json:"timeout"`
+	TLS                    TLS             `json:"tls"`
+	Tracing                Tracing         `json:"tracing"`
+	Logging                Logging         `json:"logging"`
+	Overrides              Overrides       `json:"overrides"`
+	Mock                   bool            `json:"mock"`
+	Export                 Export          `json:"export"`
+	Bootstrap              Bootstrap       `json:"bootstrap"`
+	Seed                   string          `json:"seed"`
+	ExternalNodes          []ExternalNode  `json:"external_nodes"`
+	OrderingService        OrderingService `json:"ordering_service"`
+	ConsoleAuth            ConsoleAuth     `json:"console_auth"`
+	Timeout                time.Duration   `json:"-"`
+	bootstrapTx            *configtx.ConfigTx
+	bootstrapOrganizations map[string]*bootstrapOrganization
 }
 
 // DefaultConfig returns the default configuration.
@@ -84,16 +178,68 @@ func DefaultConfig() (*Config, error) {
 		TLS: TLS{
 			Enabled: false,
 		},
+		Tracing: Tracing{
+			Enabled:  false,
+			Exporter: "file",
+			File:     path.Join(home, "traces.json"),
+			Endpoint: "localhost:4318",
+		},
+		Logging: Logging{
+			Format:     "text",
+			Level:      "info",
+			Components: map[string]string{},
+		},
+		Export: Export{
+			FabricVersion: "2.5",
+		},
+		OrderingService: OrderingService{
+			SystemChannel: "system-channel",
+			Consortium:    "SampleConsortium",
+		},
+	}
+	env := os.Getenv("MICROFAB_CONFIG")
+	profiles, err := profileNames([]byte(env))
+	if err != nil {
+		return nil, err
 	}
-	if env, ok := os.LookupEnv("MICROFAB_CONFIG"); ok {
+	if len(profiles) > 0 {
+		err := config.applyProfiles(profiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if env != "" {
 		err := json.Unmarshal([]byte(env), config)
 		if err != nil {
 			return nil, err
 		}
 	}
+	config.Profile = strings.Join(profiles, ",")
+	if config.Bootstrap.ConfigTx != "" {
+		err := config.applyBootstrap()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to bootstrap from configtx.yaml")
+		}
+	}
 	if config.Port >= startPort && config.Port < endPort {
 		logger.Fatalf("Cannot specify port %d, must be outside port range %d-%d", config.Port, 2000, 3000)
 	}
+	for _, organization := range config.EndorsingOrganizations {
+		switch strings.ToLower(organization.StateDatabase) {
+		case "", "leveldb", "couchdb":
+		default:
+			return nil, fmt.Errorf("Invalid state database %s for organization %s, must be LevelDB or CouchDB", organization.StateDatabase, organization.Name)
+		}
+	}
+	if err := config.validateExternalNodes(); err != nil {
+		return nil, err
+	}
+	if err := config.validateOrderingService(); err != nil {
+		return nil, err
+	}
+	if err := config.validateConsoleAuth(); err != nil {
+		return nil, err
+	}
 	timeout, err := time.ParseDuration(config.TimeoutString)
 	if err != nil {
 		return nil, err
@@ -101,3 +247,169 @@ func DefaultConfig() (*Config, error) {
 	config.Timeout = timeout
 	return config, nil
 }
+
+func (c *Config) validateExternalNodes() error {
+	if len(c.ExternalNodes) > 0 && c.Mock {
+		return fmt.Errorf("Cannot specify external nodes in mock mode")
+	}
+	seen := map[string]bool{}
+	for i := range c.ExternalNodes {
+		node := &c.ExternalNodes[i]
+		node.Type = strings.ToLower(node.Type)
+		switch node.Type {
+		case "peer":
+			found := false
+			for _, organization := range c.EndorsingOrganizations {
+				if organization.Name == node.Organization {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("External peer organization %s is not an endorsing organization", node.Organization)
+			}
+			if node.ChaincodePort == 0 {
+				return fmt.Errorf("External peer for organization %s must specify a chaincode port", node.Organization)
+			}
+		case "orderer":
+			if node.Organization == "" {
+				node.Organization = c.OrderingOrganization.Name
+			} else if node.Organization != c.OrderingOrganization.Name {
+				return fmt.Errorf("External orderer organization %s is not the ordering organization", node.Organization)
+			}
+		default:
+			return fmt.Errorf("Invalid external node type %s, must be peer or orderer", node.Type)
+		}
+		key := node.Type + "/" + node.Organization
+		if seen[key] {
+			return fmt.Errorf("Cannot specify more than one external %s for organization %s", node.Type, node.Organization)
+		}
+		seen[key] = true
+		if node.APIPort == 0 || node.OperationsPort == 0 {
+			return fmt.Errorf("External %s for organization %s must specify an API port and an operations port", node.Type, node.Organization)
+		}
+		for _, port := range []int32{node.APIPort, node.ChaincodePort, node.OperationsPort} {
+			if port >= startPort && port < endPort {
+				return fmt.Errorf("Cannot specify port %d for external %s, must be outside port range %d-%d", port, node.Type, startPort, endPort)
+			}
+		}
+		if node.Hostname == "" {
+			node.Hostname = "localhost"
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateOrderingService() error {
+	service := c.OrderingService
+	if !c.UsesOrderingService() {
+		return nil
+	} else if c.Mock {
+		return fmt.Errorf("Cannot use an existing ordering service in mock mode")
+	} else if c.ExternalNode("orderer", c.OrderingOrganization.Name) != nil {
+		return fmt.Errorf("Cannot use an existing ordering service with an external orderer")
+	} else if service.MSPID == "" || service.AdminMSP == "" {
+		return fmt.Errorf("Existing ordering service must specify an MSP ID and an admin MSP directory")
+	}
+	usesTLS := strings.HasPrefix(service.APIURL, "grpcs://")
+	if usesTLS && service.TLSCA == "" {
+		return fmt.Errorf("Existing ordering service must specify a TLS CA when using TLS")
+	} else if usesTLS != c.TLS.Enabled {
+		// The peers only use TLS to connect to the ordering service if TLS is enabled for the peers.
+		return fmt.Errorf("Existing ordering service %s must use TLS if and only if TLS is enabled", service.APIURL)
+	}
+	return nil
+}
+
+func (c *Config) validateConsoleAuth() error {
+	auth := c.ConsoleAuth
+	if auth.ClientCertificates && !c.TLS.Enabled {
+		return fmt.Errorf("Cannot use client certificates for the console unless TLS is enabled")
+	}
+	organizations := map[string]bool{strings.ToLower(c.OrderingOrganization.Name): true}
+	for _, organization := range c.EndorsingOrganizations {
+		organizations[strings.ToLower(organization.Name)] = true
+	}
+	validateOrganizations := func(names []string) error {
+		for _, name := range names {
+			if !organizations[strings.ToLower(name)] {
+				return fmt.Errorf("Console credentials refer to unknown organization %s", name)
+			}
+		}
+		return nil
+	}
+	for _, token := range auth.Tokens {
+		if token.Token == "" {
+			return fmt.Errorf("Console tokens must not be empty")
+		} else if err := validateOrganizations(token.Organizations); err != nil {
+			return err
+		}
+	}
+	for _, user := range auth.Users {
+		if user.Username == "" || user.Password == "" {
+			return fmt.Errorf("Console users must specify a username and a password")
+		} else if err := validateOrganizations(user.Organizations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UsesOrderingService returns true if Microfab uses an existing ordering service instead of starting an orderer.
+func (c *Config) UsesOrderingService() bool {
+	return c.OrderingService.APIURL != ""
+}
+
+// ExternalNode returns the external node of the specified type for the specified organization, or nil if
+// Microfab runs that node itself.
+func (c *Config) ExternalNode(nodeType, organizationName string) *ExternalNode {
+	for i := range c.ExternalNodes {
+		if c.ExternalNodes[i].Type == nodeType && c.ExternalNodes[i].Organization == organizationName {
+			return &c.ExternalNodes[i]
+		}
+	}
+	return nil
+}
+
+// UsesCouchDB returns true if the specified endorsing organization uses CouchDB as its state database.
+// The in-memory ledger is always used in mock mode.
+func (c *Config) UsesCouchDB(organizationName string) bool {
+	if c.Mock {
+		return false
+	}
+	for _, organization := range c.EndorsingOrganizations {
+		if organization.Name != organizationName {
+			continue
+		}
+		switch strings.ToLower(organization.StateDatabase) {
+		case "couchdb":
+			return true
+		case "leveldb":
+			return false
+		}
+	}
+	return c.CouchDB
+}
+
+// AnyUsesCouchDB returns true if any endorsing organization uses CouchDB as its state database.
+func (c *Config) AnyUsesCouchDB() bool {
+	for _, organization := range c.EndorsingOrganizations {
+		if c.UsesCouchDB(organization.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash returns a hash of the configuration, which is stored in the state so that the network is recreated when the
+// configuration changes. The tracing and logging configuration is excluded, as it does not affect the network.
+func (c *Config) Hash() ([]byte, error) {
+	temp := *c
+	temp.Tracing = Tracing{}
+	temp.Logging = Logging{}
+	data, err := json.Marshal(&temp)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
@@ -0,0 +1,63 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package microfabd_test
+
+import (
+	"os"
+
+	"github.com/hyperledger-labs/microfab/internal/app/microfabd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the microfabd configuration", func() {
+
+	var config *microfabd.Config
+	var originalHash []byte
+
+	BeforeEach(func() {
+		os.Unsetenv("MICROFAB_CONFIG")
+		var err error
+		config, err = microfabd.DefaultConfig()
+		Expect(err).NotTo(HaveOccurred())
+		originalHash, err = config.Hash()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("microfabd.Config.Hash()", func() {
+
+		When("the tracing configuration changes", func() {
+			It("returns the same hash", func() {
+				config.Tracing.Enabled = true
+				config.Tracing.Exporter = "stdout"
+				hash, err := config.Hash()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hash).To(Equal(originalHash))
+			})
+		})
+
+		When("the logging configuration changes", func() {
+			It("returns the same hash", func() {
+				config.Logging.Format = "json"
+				config.Logging.Level = "debug"
+				config.Logging.Components = map[string]string{"proxy": "debug"}
+				hash, err := config.Hash()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hash).To(Equal(originalHash))
+			})
+		})
+
+		When("the network configuration changes", func() {
+			It("returns a different hash", func() {
+				config.EndorsingOrganizations = append(config.EndorsingOrganizations, microfabd.Organization{Name: "Org2"})
+				hash, err := config.Hash()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(hash).NotTo(Equal(originalHash))
+			})
+		})
+
+	})
+
+})
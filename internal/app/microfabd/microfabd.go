@@ -6,12 +6,10 @@ package microfabd
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"math/rand"
 	"os"
 	"os/signal"
@@ -25,23 +23,30 @@ import (
 	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
 	"github.com/hyperledger-labs/microfab/internal/pkg/ca"
 	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/compose"
+	"github.com/hyperledger-labs/microfab/internal/pkg/configtx"
 	"github.com/hyperledger-labs/microfab/internal/pkg/console"
 	"github.com/hyperledger-labs/microfab/internal/pkg/couchdb"
+	"github.com/hyperledger-labs/microfab/internal/pkg/cryptoconfig"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity/privatekey"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
+	"github.com/hyperledger-labs/microfab/internal/pkg/mock"
 	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
 	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
 	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
 	"github.com/hyperledger-labs/microfab/internal/pkg/proxy"
+	"github.com/hyperledger-labs/microfab/internal/pkg/tracing"
 	"github.com/hyperledger-labs/microfab/internal/pkg/util"
 	"github.com/hyperledger-labs/microfab/pkg/client"
 	"github.com/hyperledger/fabric-protos-go/common"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/errgroup"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "microfabd"), log.LstdFlags)
+var logger = logging.New("microfabd")
 
 const startPort = 2000
 const endPort = 3000
@@ -63,6 +68,9 @@ type Microfab struct {
 	couchDB                *couchdb.CouchDB
 	couchDBProxies         []*couchdb.Proxy
 	peers                  []*peer.Peer
+	ledger                 *mock.Ledger
+	mockOrderer            *mock.Orderer
+	mockPeers              []*mock.Peer
 	peerConnections        []*peer.Connection
 	cas                    []*ca.CA
 	genesisBlocks          map[string]*common.Block
@@ -71,13 +79,19 @@ type Microfab struct {
 	currentPort            int
 	currentGossipPort      int
 	tls                    *identity.Identity
+	nodeIdentities         map[string]*identity.Identity
+	nodeTLS                map[string]*identity.Identity
+	bootstrapNodeTLS       map[string]*identity.Identity
+	tracing                *tracing.Provider
 }
 
 // State represents the state that should be persisted between instances.
 type State struct {
-	Hash []byte                      `json:"hash"`
-	CAS  map[string]*client.Identity `json:"cas"`
-	TLS  *client.Identity            `json:"tls"`
+	Hash    []byte                      `json:"hash"`
+	CAS     map[string]*client.Identity `json:"cas"`
+	TLSCAS  map[string]*client.Identity `json:"tls_cas"`
+	NodeTLS map[string]*client.Identity `json:"node_tls"`
+	TLS     *client.Identity            `json:"tls"`
 }
 
 // New creates an instance of the Microfab application.
@@ -86,6 +100,13 @@ func New() (*Microfab, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = logging.Configure(config.Logging.Format, config.Logging.Level, config.Logging.Components)
+	if err != nil {
+		return nil, err
+	}
+	if config.Seed != "" {
+		identity.UseSeed(config.Seed)
+	}
 	return &Microfab{
 		config:            config,
 		sigs:              make(chan os.Signal, 1),
@@ -93,6 +114,9 @@ func New() (*Microfab, error) {
 		started:           false,
 		currentPort:       startPort,
 		currentGossipPort: gossipPortStart,
+		nodeIdentities:    map[string]*identity.Identity{},
+		nodeTLS:           map[string]*identity.Identity{},
+		bootstrapNodeTLS:  map[string]*identity.Identity{},
 	}, nil
 }
 
@@ -109,18 +133,26 @@ func (m *Microfab) Start() error {
 		}
 	}()
 
+	// If tracing is enabled, start exporting spans.
+	if m.config.Tracing.Enabled {
+		if err := m.createTracing(); err != nil {
+			return err
+		}
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "start")
+	defer span.End()
+
 	// Calculate the config hash.
-	config, err := json.Marshal(m.config)
+	hash, err := m.config.Hash()
 	if err != nil {
 		return err
 	}
-	hash := sha256.Sum256(config)
 
 	// See if the state exists.
 	if m.stateExists() {
 		if temp, err := m.loadState(); err != nil {
 			logger.Printf("Could not load state: %v\n", err)
-		} else if bytes.Equal(hash[:], temp.Hash) {
+		} else if bytes.Equal(hash, temp.Hash) {
 			logger.Println("Loaded state")
 			m.state = temp
 		} else {
@@ -136,24 +168,36 @@ func (m *Microfab) Start() error {
 		}
 	}
 
+	// In mock mode, all peers and the orderer share a single in-memory ledger.
+	if m.config.Mock {
+		logger.Print("Running in mock mode, Fabric components will not be started")
+		m.ledger = mock.NewLedger()
+	}
+
 	// If TLS is enabled, generate the TLS material.
 	if m.config.TLS.Enabled {
-		if err := m.createTLS(); err != nil {
+		err = tracing.Span(ctx, "create tls", func(context.Context) error {
+			return m.createTLS()
+		})
+		if err != nil {
 			return err
 		}
 	}
 
 	// Create all of the organizations.
-	ctx := context.Background()
 	eg, _ := errgroup.WithContext(ctx)
 	eg.Go(func() error {
-		return m.createOrderingOrganization(m.config.OrderingOrganization)
+		return tracing.Span(ctx, "create ordering organization", func(context.Context) error {
+			return m.createOrderingOrganization(m.config.OrderingOrganization)
+		}, attribute.String("organization", m.config.OrderingOrganization.Name))
 	})
 
 	for i := range m.config.EndorsingOrganizations {
 		organization := m.config.EndorsingOrganizations[i]
 		eg.Go(func() error {
-			return m.createEndorsingOrganization(organization)
+			return tracing.Span(ctx, "create endorsing organization", func(context.Context) error {
+				return m.createEndorsingOrganization(organization)
+			}, attribute.String("organization", organization.Name))
 		})
 	}
 	err = eg.Wait()
@@ -168,20 +212,32 @@ func (m *Microfab) Start() error {
 	m.organizations = append(m.organizations, m.ordererOrganization)
 	m.organizations = append(m.organizations, m.endorsingOrganizations...)
 
-	// Wait for CouchDB to start.
-	if m.config.CouchDB {
-		err = m.waitForCouchDB()
+	// Wait for CouchDB to start, if any organization needs it.
+	if m.config.AnyUsesCouchDB() {
+		err = tracing.Span(ctx, "wait for couchdb", func(context.Context) error {
+			return m.waitForCouchDB()
+		})
 		if err != nil {
 			return err
 		}
 	}
 
 	// Create and start all of the components (orderer, peers, CAs).
-	eg.Go(func() error {
-		apiPort := m.allocatePort()
-		operationsPort := m.allocatePort()
-		return m.createAndStartOrderer(m.ordererOrganization, apiPort, operationsPort)
-	})
+	if m.config.UsesOrderingService() {
+		eg.Go(func() error {
+			return tracing.Span(ctx, "connect ordering service", func(context.Context) error {
+				return m.connectOrderingService(m.ordererOrganization)
+			}, attribute.String("organization", m.ordererOrganization.Name()))
+		})
+	} else {
+		eg.Go(func() error {
+			apiPort := m.allocatePort()
+			operationsPort := m.allocatePort()
+			return tracing.Span(ctx, "start orderer", func(context.Context) error {
+				return m.createAndStartOrderer(m.ordererOrganization, apiPort, operationsPort)
+			}, attribute.String("organization", m.ordererOrganization.Name()))
+		})
+	}
 	for i := range m.endorsingOrganizations {
 		organization := m.endorsingOrganizations[i]
 		eg.Go(func() error {
@@ -189,18 +245,22 @@ func (m *Microfab) Start() error {
 			peerChaincodePort := m.allocatePort()
 			peerOperationsPort := m.allocatePort()
 			peerGossipPort := m.allocateGossipPort()
-			if m.config.CouchDB {
-				couchDBProxyPort := m.allocatePort()
-				go m.createAndStartCouchDBProxy(organization, couchDBProxyPort)
-				return m.createAndStartPeer(organization, peerAPIPort, peerChaincodePort, peerOperationsPort, m.config.CouchDB, couchDBProxyPort, peerGossipPort)
-			}
-			return m.createAndStartPeer(organization, peerAPIPort, peerChaincodePort, peerOperationsPort, false, 0, peerGossipPort)
+			return tracing.Span(ctx, "start peer", func(context.Context) error {
+				if m.config.UsesCouchDB(organization.Name()) {
+					couchDBProxyPort := m.allocatePort()
+					go m.createAndStartCouchDBProxy(organization, couchDBProxyPort)
+					return m.createAndStartPeer(organization, peerAPIPort, peerChaincodePort, peerOperationsPort, true, couchDBProxyPort, peerGossipPort)
+				}
+				return m.createAndStartPeer(organization, peerAPIPort, peerChaincodePort, peerOperationsPort, false, 0, peerGossipPort)
+			}, attribute.String("organization", organization.Name()))
 		})
-		if m.config.CertificateAuthorities {
+		if m.config.CertificateAuthorities && !m.config.Mock {
 			eg.Go(func() error {
 				caAPIPort := m.allocatePort()
 				caOperationsPort := m.allocatePort()
-				return m.createAndStartCA(organization, caAPIPort, caOperationsPort)
+				return tracing.Span(ctx, "start ca", func(context.Context) error {
+					return m.createAndStartCA(organization, caAPIPort, caOperationsPort)
+				}, attribute.String("organization", organization.Name()))
 			})
 		}
 	}
@@ -216,12 +276,18 @@ func (m *Microfab) Start() error {
 
 	// Create and start the console.
 	consolePort := m.allocatePort()
-	if err := m.createAndStartConsole(consolePort); err != nil {
+	err = tracing.Span(ctx, "start console", func(context.Context) error {
+		return m.createAndStartConsole(consolePort)
+	})
+	if err != nil {
 		return err
 	}
 
 	// Create and start the proxy.
-	if err := m.createAndStartProxy(); err != nil {
+	err = tracing.Span(ctx, "start proxy", func(context.Context) error {
+		return m.createAndStartProxy()
+	})
+	if err != nil {
 		return err
 	}
 
@@ -240,14 +306,20 @@ func (m *Microfab) Start() error {
 	}()
 
 	// wait for the orderer to wakeup
-	time.Sleep(8 * time.Second)
+	if !m.config.Mock && !m.config.UsesOrderingService() {
+		_, sleepSpan := tracing.Tracer().Start(ctx, "wait for orderer")
+		time.Sleep(8 * time.Second)
+		sleepSpan.End()
+	}
 
-	// Create and join all of the channels.
-	if m.state == nil {
+	// Create and join all of the channels. The mock ledger is held in memory, so channels are always created in mock mode.
+	if m.state == nil || m.config.Mock {
 		for i := range m.config.Channels {
 			channel := m.config.Channels[i]
 			eg.Go(func() error {
-				return m.createAndJoinChannel(channel)
+				return tracing.Span(ctx, "create and join channel", func(ctx context.Context) error {
+					return m.createAndJoinChannel(ctx, channel)
+				}, attribute.String("channel", channel.Name))
 			})
 		}
 		err = eg.Wait()
@@ -255,6 +327,7 @@ func (m *Microfab) Start() error {
 			return err
 		}
 	}
+	m.console.MarkReady()
 
 	// Write the state for next time.
 	err = m.saveState()
@@ -262,10 +335,29 @@ func (m *Microfab) Start() error {
 		return err
 	}
 
+	// Export the network, if requested.
+	if m.config.Export.DockerCompose != "" {
+		err = tracing.Span(ctx, "export docker compose", func(context.Context) error {
+			return m.exportDockerCompose(m.config.Export.DockerCompose)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if m.config.Export.CryptoConfig != "" {
+		err = tracing.Span(ctx, "export crypto config", func(context.Context) error {
+			return m.exportCryptoConfig(m.config.Export.CryptoConfig)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Say how long start up took, then wait for signals.
 	readyTime := time.Now()
 	startupDuration := readyTime.Sub(startTime)
 	logger.Printf("Microfab started in %vms", startupDuration.Milliseconds())
+	span.SetAttributes(attribute.Int64("duration_ms", startupDuration.Milliseconds()))
 	signal.Notify(m.sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-m.sigs
@@ -392,18 +484,24 @@ func (m *Microfab) saveState() error {
 		return err
 	}
 	defer file.Close()
-	config, err := json.Marshal(m.config)
+	hash, err := m.config.Hash()
 	if err != nil {
 		return err
 	}
-	hash := sha256.Sum256(config)
 	state := &State{
-		Hash: hash[:],
-		CAS:  map[string]*client.Identity{},
+		Hash:    hash,
+		CAS:     map[string]*client.Identity{},
+		TLSCAS:  map[string]*client.Identity{},
+		NodeTLS: map[string]*client.Identity{},
 	}
 	state.CAS[m.ordererOrganization.Name()] = m.ordererOrganization.CA().ToClient()
+	state.TLSCAS[m.ordererOrganization.Name()] = m.ordererOrganization.TLSCA().ToClient()
 	for _, endorsingOrganization := range m.endorsingOrganizations {
 		state.CAS[endorsingOrganization.Name()] = endorsingOrganization.CA().ToClient()
+		state.TLSCAS[endorsingOrganization.Name()] = endorsingOrganization.TLSCA().ToClient()
+	}
+	for name, tls := range m.nodeTLS {
+		state.NodeTLS[name] = tls.ToClient()
 	}
 	if m.tls != nil {
 		state.TLS = m.tls.ToClient()
@@ -483,6 +581,79 @@ func (m *Microfab) createTLS() error {
 	return m.generateTLS()
 }
 
+// createTLSCA returns the TLS CA for an organization, which issues the TLS certificates for its nodes. The TLS CA
+// from the crypto-config directory is used when bootstrapping the network, if one is present.
+func (m *Microfab) createTLSCA(organizationName string, bootstrap *configtx.Material) (*identity.Identity, error) {
+	if bootstrap != nil && bootstrap.TLSCA != nil {
+		return bootstrap.TLSCA, nil
+	}
+	if m.state != nil {
+		if temp, ok := m.state.TLSCAS[organizationName]; ok {
+			return identity.FromClient(temp)
+		}
+	}
+	return identity.New(fmt.Sprintf("%s TLS CA", organizationName), identity.WithIsCA(true))
+}
+
+// createNodeTLS returns the TLS identity for a node, issued by the TLS CA of its organization for the specified
+// hostnames. The identity is kept in the state, as the orderer TLS certificate is part of the consenter set. The
+// bootstrap identity from the crypto-config directory is used instead if it is valid for all of the hostnames.
+func (m *Microfab) createNodeTLS(organization *organization.Organization, name string, bootstrap *identity.Identity, hostnames ...string) (*identity.Identity, error) {
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, hostname := range append(hostnames, "localhost", "127.0.0.1") {
+		if !seen[hostname] {
+			unique = append(unique, hostname)
+			seen[hostname] = true
+		}
+	}
+	var tls *identity.Identity
+	var err error
+	if m.state != nil {
+		if temp, ok := m.state.NodeTLS[name]; ok {
+			tls, err = identity.FromClient(temp)
+		}
+	}
+	if tls == nil && err == nil && bootstrap != nil {
+		if validForHostnames(bootstrap, unique) {
+			tls = bootstrap
+		} else {
+			logger.Printf("TLS certificate for %s from crypto-config directory is not valid for all hostnames, issuing a new one", name)
+		}
+	}
+	if tls == nil && err == nil {
+		tls, err = identity.New(name, identity.WithHostnames(unique...), identity.UsingSigner(organization.TLSCA()))
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.Lock()
+	m.nodeTLS[name] = tls
+	m.Unlock()
+	return tls, nil
+}
+
+func (m *Microfab) createTracing() error {
+	config := m.config.Tracing
+	var provider *tracing.Provider
+	var err error
+	switch config.Exporter {
+	case "file":
+		logger.Printf("Exporting traces to file %s", config.File)
+		provider, err = tracing.NewFileProvider(config.File)
+	case "otlp":
+		logger.Printf("Exporting traces to OTLP collector %s", config.Endpoint)
+		provider, err = tracing.NewOTLPProvider(config.Endpoint)
+	default:
+		return fmt.Errorf("Invalid tracing exporter %s, must be file or otlp", config.Exporter)
+	}
+	if err != nil {
+		return err
+	}
+	m.tracing = provider
+	return nil
+}
+
 func (m *Microfab) createOrderingOrganization(config Organization) error {
 	logger.Printf("Creating ordering organization %s ...", config.Name)
 	var ca *identity.Identity
@@ -497,8 +668,14 @@ func (m *Microfab) createOrderingOrganization(config Organization) error {
 		}
 	}
 
-	// create tls CA id
-	tlsCA, err := identity.New(fmt.Sprintf("*.%s", m.config.Domain), identity.WithIsCA(true))
+	bootstrap, err := m.loadBootstrapMaterial(config.Name)
+	if err != nil {
+		return err
+	} else if bootstrap != nil {
+		ca = bootstrap.CA
+	}
+
+	tlsCA, err := m.createTLSCA(config.Name, bootstrap)
 	if err != nil {
 		return err
 	}
@@ -507,6 +684,22 @@ func (m *Microfab) createOrderingOrganization(config Organization) error {
 	if err != nil {
 		return err
 	}
+	err = m.applyBootstrapOrganization(organization)
+	if err != nil {
+		return err
+	}
+	err = m.applyExternalNodeTLSCA(organization)
+	if err != nil {
+		return err
+	}
+	if m.config.UsesOrderingService() {
+		organization.SetMSPID(m.config.OrderingService.MSPID)
+		admin, err := util.LoadMSPDirectory(m.config.OrderingService.AdminMSP)
+		if err != nil {
+			return fmt.Errorf("Failed to load the admin for the ordering service: %v", err)
+		}
+		organization.SetAdmin(admin)
+	}
 	organizationName := organization.Name()
 	lowerOrganizationName := strings.ToLower(organizationName)
 	adminDirectory := path.Join(m.config.Directory, fmt.Sprintf("admin-%s", lowerOrganizationName))
@@ -535,8 +728,14 @@ func (m *Microfab) createEndorsingOrganization(config Organization) error {
 		}
 	}
 
-	// create tls CA id
-	tlsCA, err := identity.New(fmt.Sprintf("*.%s", m.config.Domain), identity.WithIsCA(true))
+	bootstrap, err := m.loadBootstrapMaterial(config.Name)
+	if err != nil {
+		return err
+	} else if bootstrap != nil {
+		ca = bootstrap.CA
+	}
+
+	tlsCA, err := m.createTLSCA(config.Name, bootstrap)
 	if err != nil {
 		return err
 	}
@@ -545,6 +744,14 @@ func (m *Microfab) createEndorsingOrganization(config Organization) error {
 	if err != nil {
 		return err
 	}
+	err = m.applyBootstrapOrganization(organization)
+	if err != nil {
+		return err
+	}
+	err = m.applyExternalNodeTLSCA(organization)
+	if err != nil {
+		return err
+	}
 	organizationName := organization.Name()
 	lowerOrganizationName := strings.ToLower(organizationName)
 	adminDirectory := path.Join(m.config.Directory, fmt.Sprintf("admin-%s", lowerOrganizationName))
@@ -559,6 +766,24 @@ func (m *Microfab) createEndorsingOrganization(config Organization) error {
 	return nil
 }
 
+func (m *Microfab) applyExternalNodeTLSCA(organization *organization.Organization) error {
+	for _, node := range m.config.ExternalNodes {
+		if node.Organization != organization.Name() || node.TLSCA == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(node.TLSCA)
+		if err != nil {
+			return fmt.Errorf("Invalid TLS CA for external %s, must be a PEM file: %v", node.Type, err)
+		}
+		cert, err := certificate.FromBytes(data)
+		if err != nil {
+			return fmt.Errorf("Invalid TLS CA for external %s, must be a PEM file: %v", node.Type, err)
+		}
+		organization.AddTLSRootCert(cert)
+	}
+	return nil
+}
+
 func (m *Microfab) createAndStartOrderer(organization *organization.Organization, apiPort, operationsPort int) error {
 	logger.Printf("Creating and starting orderer for ordering organization %s ...", organization.Name())
 	directory := path.Join(m.config.Directory, "orderer")
@@ -566,6 +791,10 @@ func (m *Microfab) createAndStartOrderer(organization *organization.Organization
 	if m.tls != nil {
 		schemeSuffix = "s"
 	}
+	external := m.config.ExternalNode("orderer", organization.Name())
+	if external != nil {
+		apiPort, operationsPort = int(external.APIPort), int(external.OperationsPort)
+	}
 	orderer, err := orderer.New(
 		organization,
 		directory,
@@ -578,13 +807,32 @@ func (m *Microfab) createAndStartOrderer(organization *organization.Organization
 	if err != nil {
 		return err
 	}
+	if external != nil {
+		orderer.SetExternal(external.Hostname)
+	}
 	if m.tls != nil {
-		orderer.EnableTLS(m.tls)
+		tls, err := m.createNodeTLS(organization, fmt.Sprintf("%s Orderer TLS", organization.Name()), m.bootstrapNodeTLS[organization.Name()], orderer.APIHostname(false), orderer.OperationsHostname(false), orderer.APIHostname(true))
+		if err != nil {
+			return err
+		}
+		orderer.EnableTLS(tls)
 	}
+	if id, ok := m.nodeIdentities[organization.Name()]; ok {
+		orderer.SetIdentity(id)
+	}
+	orderer.SetOverrides(m.config.Overrides.Orderer)
 	m.Lock()
 	m.orderer = orderer
 	m.Unlock()
-	err = orderer.Start(m.endorsingOrganizations, m.config.Timeout)
+	if m.config.Mock {
+		mockOrderer := mock.NewOrderer(orderer, m.endorsingOrganizations, m.ledger)
+		m.Lock()
+		m.mockOrderer = mockOrderer
+		m.Unlock()
+		err = mockOrderer.Start()
+	} else {
+		err = orderer.Start(m.endorsingOrganizations, m.config.Timeout)
+	}
 	if err != nil {
 		return err
 	}
@@ -594,6 +842,46 @@ func (m *Microfab) createAndStartOrderer(organization *organization.Organization
 	return nil
 }
 
+// connectOrderingService uses an existing ordering service instead of starting an orderer. The endorsing
+// organizations are added to the consortium in its system channel, so that they can create channels.
+func (m *Microfab) connectOrderingService(organization *organization.Organization) error {
+	config := m.config.OrderingService
+	logger.Printf("Connecting to existing ordering service %s ...", config.APIURL)
+	remoteOrderer, err := orderer.NewRemote(organization, config.APIURL)
+	if err != nil {
+		return err
+	}
+	if config.TLSCA != "" {
+		data, err := ioutil.ReadFile(config.TLSCA)
+		if err != nil {
+			return fmt.Errorf("Invalid TLS CA for the ordering service, must be a PEM file: %v", err)
+		}
+		cert, err := certificate.FromBytes(data)
+		if err != nil {
+			return fmt.Errorf("Invalid TLS CA for the ordering service, must be a PEM file: %v", err)
+		}
+		tls, err := identity.FromParts(cert.Certificate().Subject.CommonName, cert, nil, cert)
+		if err != nil {
+			return err
+		}
+		remoteOrderer.EnableTLS(tls)
+	}
+	m.Lock()
+	m.orderer = remoteOrderer
+	m.Unlock()
+	ordererConnection, err := orderer.Connect(remoteOrderer, organization.MSPID(), organization.Admin())
+	if err != nil {
+		return err
+	}
+	defer ordererConnection.Close()
+	err = channel.AddConsortiumOrganizations(ordererConnection, config.SystemChannel, config.Consortium, m.tls, m.endorsingOrganizations...)
+	if err != nil {
+		return fmt.Errorf("Failed to add organizations to consortium %s: %v", config.Consortium, err)
+	}
+	logger.Printf("Connected to existing ordering service %s", config.APIURL)
+	return nil
+}
+
 func (m *Microfab) waitForCouchDB() error {
 	logger.Printf("Waiting for CouchDB to start ...")
 	scheme := "http"
@@ -644,6 +932,10 @@ func (m *Microfab) createAndStartPeer(organization *organization.Organization, a
 	if m.tls != nil {
 		schemeSuffix = "s"
 	}
+	external := m.config.ExternalNode("peer", organizationName)
+	if external != nil {
+		apiPort, chaincodePort, operationsPort = int(external.APIPort), int(external.ChaincodePort), int(external.OperationsPort)
+	}
 
 	peer, err := peer.New(
 		organization,
@@ -663,13 +955,32 @@ func (m *Microfab) createAndStartPeer(organization *organization.Organization, a
 	if err != nil {
 		return err
 	}
+	if external != nil {
+		peer.SetExternal(external.Hostname)
+	}
 	if m.tls != nil {
-		peer.EnableTLS(m.tls)
+		tls, err := m.createNodeTLS(organization, fmt.Sprintf("%s Peer TLS", organizationName), m.bootstrapNodeTLS[organization.Name()], peer.APIHostname(false), peer.ChaincodeHostname(false), peer.OperationsHostname(false), peer.GossipHostname(false), peer.APIHostname(true))
+		if err != nil {
+			return err
+		}
+		peer.EnableTLS(tls)
+	}
+	if id, ok := m.nodeIdentities[organization.Name()]; ok {
+		peer.SetIdentity(id)
 	}
+	peer.SetOverrides(m.config.Overrides.Peer)
 	m.Lock()
 	m.peers = append(m.peers, peer)
 	m.Unlock()
-	err = peer.Start(m.config.Timeout)
+	if m.config.Mock {
+		mockPeer := mock.NewPeer(peer, m.ledger)
+		m.Lock()
+		m.mockPeers = append(m.mockPeers, mockPeer)
+		m.Unlock()
+		err = mockPeer.Start()
+	} else {
+		err = peer.Start(m.config.Timeout)
+	}
 	if err != nil {
 		return err
 	}
@@ -703,8 +1014,13 @@ func (m *Microfab) createAndStartCA(organization *organization.Organization, api
 		return err
 	}
 	if m.tls != nil {
-		c.EnableTLS(m.tls)
+		tls, err := m.createNodeTLS(organization, fmt.Sprintf("%s CA TLS", organizationName), nil, c.APIHostname(false), c.OperationsHostname(false))
+		if err != nil {
+			return err
+		}
+		c.EnableTLS(tls)
 	}
+	c.SetOverrides(m.config.Overrides.CA)
 	m.Lock()
 	m.cas = append(m.cas, c)
 	m.Unlock()
@@ -734,28 +1050,32 @@ func (m *Microfab) createChannel(config Channel) (*common.Block, error) {
 	}
 	opts := []channel.Option{
 		channel.WithCapabilityLevel(capabilityLevel),
+		channel.WithPolicies(config.Policies),
 	}
-	endorsingOrganizations := []*organization.Organization{}
-	for _, endorsingOrganization := range m.endorsingOrganizations {
-		for _, organizationName := range config.EndorsingOrganizations {
-			if endorsingOrganization.Name() == organizationName {
-				endorsingOrganizations = append(endorsingOrganizations, endorsingOrganization)
-				break
-			}
-		}
-	}
+	endorsingOrganizations := m.channelOrganizations(config)
 	if len(endorsingOrganizations) == 0 {
 		logger.Fatalf("Attempted to create channel %s with no endorsing organizations", config.Name)
 	}
 	for _, endorsingOrganization := range endorsingOrganizations {
 		opts = append(opts, channel.AddMSPID(endorsingOrganization.MSPID()))
 	}
+	if m.config.UsesOrderingService() {
+		opts = append(opts, channel.WithConsortium(m.config.OrderingService.Consortium))
+	}
 	channelCreator := endorsingOrganizations[rand.Intn(len(endorsingOrganizations))]
 	ordererConnection, err := orderer.Connect(m.orderer, channelCreator.MSPID(), channelCreator.Admin())
 	if err != nil {
 		return nil, err
 	}
 	defer ordererConnection.Close()
+	if m.orderer.Remote() {
+		// The channel may have been created by a previous instance of Microfab, so just join it. The organizations in
+		// it are the same, as adding them to the consortium fails if their MSPs have changed.
+		if genesisBlock, err := blocks.GetGenesisBlock(ordererConnection, config.Name); err == nil {
+			logger.Printf("Channel %s already exists on the ordering service", config.Name)
+			return genesisBlock, nil
+		}
+	}
 	err = channel.CreateChannel(ordererConnection, config.Name, opts...)
 	if err != nil {
 		return nil, err
@@ -779,7 +1099,8 @@ func (m *Microfab) createChannel(config Channel) (*common.Block, error) {
 			}
 		}
 		if found {
-			opts = append(opts, channel.AddAnchorPeer(peer.MSPID(), peer.APIHostname(false), peer.APIPort(true)))
+			// External peers may not be reachable through the Microfab domain, so use their own hostname.
+			opts = append(opts, channel.AddAnchorPeer(peer.MSPID(), peer.APIHostname(peer.External()), peer.APIPort(true)))
 		}
 	}
 	err = channel.UpdateChannel(ordererConnection, config.Name, opts...)
@@ -790,13 +1111,31 @@ func (m *Microfab) createChannel(config Channel) (*common.Block, error) {
 	return genesisBlock, nil
 }
 
-func (m *Microfab) createAndJoinChannel(config Channel) error {
+// channelOrganizations returns the endorsing organizations that are members of the specified channel.
+func (m *Microfab) channelOrganizations(config Channel) []*organization.Organization {
+	result := []*organization.Organization{}
+	for _, endorsingOrganization := range m.endorsingOrganizations {
+		for _, organizationName := range config.EndorsingOrganizations {
+			if endorsingOrganization.Name() == organizationName {
+				result = append(result, endorsingOrganization)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func (m *Microfab) createAndJoinChannel(ctx context.Context, config Channel) error {
 	logger.Printf("Creating and joining channel %s ...", config.Name)
-	genesisBlock, err := m.createChannel(config)
+	var genesisBlock *common.Block
+	err := tracing.Span(ctx, "create channel", func(context.Context) error {
+		var err error
+		genesisBlock, err = m.createChannel(config)
+		return err
+	}, attribute.String("channel", config.Name))
 	if err != nil {
 		return err
 	}
-	ctx := context.Background()
 	eg, _ := errgroup.WithContext(ctx)
 	for i := range m.peers {
 		peer := m.peers[i]
@@ -810,13 +1149,15 @@ func (m *Microfab) createAndJoinChannel(config Channel) error {
 		}
 		if found {
 			eg.Go(func() error {
-				logger.Printf("Joining channel %s on peer for endorsing organization %s ...", config.Name, peer.Organization().Name())
-				err := connection.JoinChannel(genesisBlock)
-				if err != nil {
-					return err
-				}
-				logger.Printf("Joined channel %s on peer for endorsing organization %s", config.Name, peer.Organization().Name())
-				return nil
+				return tracing.Span(ctx, "join channel", func(context.Context) error {
+					logger.Printf("Joining channel %s on peer for endorsing organization %s ...", config.Name, peer.Organization().Name())
+					err := connection.JoinChannel(genesisBlock)
+					if err != nil {
+						return err
+					}
+					logger.Printf("Joined channel %s on peer for endorsing organization %s", config.Name, peer.Organization().Name())
+					return nil
+				}, attribute.String("channel", config.Name), attribute.String("organization", peer.Organization().Name()))
 			})
 		}
 	}
@@ -828,6 +1169,58 @@ func (m *Microfab) createAndJoinChannel(config Channel) error {
 	return nil
 }
 
+func (m *Microfab) exportDockerCompose(directory string) error {
+	logger.Printf("Exporting docker-compose network to %s ...", directory)
+	channels := []compose.Channel{}
+	for _, config := range m.config.Channels {
+		capabilityLevel := config.CapabilityLevel
+		if capabilityLevel == "" {
+			capabilityLevel = m.config.CapabilityLevel
+		}
+		channels = append(channels, compose.Channel{
+			Name:            config.Name,
+			CapabilityLevel: capabilityLevel,
+			Organizations:   m.channelOrganizations(config),
+			Policies:        config.Policies,
+		})
+	}
+	if m.orderer.Remote() {
+		return fmt.Errorf("Cannot export a docker-compose network that uses an existing ordering service")
+	}
+	err := compose.Export(directory, &compose.Network{
+		Orderer:       m.orderer,
+		Organizations: m.endorsingOrganizations,
+		Peers:         m.peers,
+		Channels:      channels,
+		FabricVersion: m.config.Export.FabricVersion,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Printf("Exported docker-compose network to %s", directory)
+	return nil
+}
+
+func (m *Microfab) exportCryptoConfig(directory string) error {
+	logger.Printf("Exporting crypto material to %s ...", directory)
+	exportOrderer := m.orderer
+	if exportOrderer.Remote() {
+		exportOrderer = nil
+	}
+	err := cryptoconfig.Export(directory, &cryptoconfig.Network{
+		Domain:        m.config.Domain,
+		Orderer:       exportOrderer,
+		Organizations: m.endorsingOrganizations,
+		Peers:         m.peers,
+		TLS:           m.tls,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Printf("Exported crypto material to %s", directory)
+	return nil
+}
+
 func (m *Microfab) createAndStartConsole(port int) error {
 	logger.Print("Creating and starting console ...")
 	schemeSuffix := ""
@@ -845,6 +1238,15 @@ func (m *Microfab) createAndStartConsole(port int) error {
 	if err != nil {
 		return err
 	}
+	for _, token := range m.config.ConsoleAuth.Tokens {
+		c.AddToken(token.Token, token.Organizations...)
+	}
+	for _, user := range m.config.ConsoleAuth.Users {
+		c.AddUser(user.Username, user.Password, user.Organizations...)
+	}
+	if m.config.ConsoleAuth.ClientCertificates {
+		c.EnableClientCertificates(m.tls)
+	}
 	c.RegisterOrderer(m.orderer)
 	c.RegisterOrganization(m.ordererOrganization)
 	for _, organization := range m.endorsingOrganizations {
@@ -856,6 +1258,12 @@ func (m *Microfab) createAndStartConsole(port int) error {
 	for _, ca := range m.cas {
 		c.RegisterCA(ca)
 	}
+	if m.couchDB != nil {
+		c.RegisterCouchDB(m.couchDB)
+	}
+	for _, config := range m.config.Channels {
+		c.RegisterChannel(config.Name, m.channelOrganizations(config))
+	}
 	m.console = c
 	go c.Start()
 	logger.Print("Created and started console")
@@ -875,7 +1283,9 @@ func (m *Microfab) createAndStartProxy() error {
 		return err
 	}
 	p.RegisterConsole(m.console)
-	p.RegisterOrderer(m.orderer)
+	if !m.orderer.Remote() {
+		p.RegisterOrderer(m.orderer)
+	}
 	for _, ca := range m.cas {
 		p.RegisterCA(ca)
 	}
@@ -922,6 +1332,13 @@ func (m *Microfab) stop() error {
 		}
 	}
 	m.peers = []*peer.Peer{}
+	for _, mockPeer := range m.mockPeers {
+		err := mockPeer.Stop()
+		if err != nil {
+			return err
+		}
+	}
+	m.mockPeers = []*mock.Peer{}
 	for _, couchDBProxy := range m.couchDBProxies {
 		err := couchDBProxy.Stop()
 		if err != nil {
@@ -936,5 +1353,19 @@ func (m *Microfab) stop() error {
 		}
 		m.orderer = nil
 	}
+	if m.mockOrderer != nil {
+		err := m.mockOrderer.Stop()
+		if err != nil {
+			return err
+		}
+		m.mockOrderer = nil
+	}
+	if m.tracing != nil {
+		err := m.tracing.Shutdown()
+		if err != nil {
+			return err
+		}
+		m.tracing = nil
+	}
 	return nil
 }
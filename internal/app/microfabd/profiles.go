@@ -0,0 +1,153 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package microfabd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// builtinProfiles are the named configuration profiles that are always available. Each profile is a partial
+// configuration that is applied on top of the default configuration.
+var builtinProfiles = map[string]string{
+	"single-org": `{
+		"endorsing_organizations": [{"name": "Org1"}],
+		"channels": [{"name": "channel1", "endorsing_organizations": ["Org1"]}]
+	}`,
+	"two-org": `{
+		"endorsing_organizations": [{"name": "Org1"}, {"name": "Org2"}],
+		"channels": [{"name": "channel1", "endorsing_organizations": ["Org1", "Org2"]}]
+	}`,
+	"two-org-tls": `{
+		"profile": "two-org",
+		"tls": {"enabled": true}
+	}`,
+	"ci": `{
+		"couchdb": false,
+		"certificate_authorities": false
+	}`,
+	"mock": `{
+		"mock": true
+	}`,
+}
+
+// profileNames returns the names of the profiles to apply, in order. The profile key in the configuration takes
+// precedence over the MICROFAB_PROFILE environment variable.
+func profileNames(config []byte) ([]string, error) {
+	value := os.Getenv("MICROFAB_PROFILE")
+	if len(config) > 0 {
+		names, err := profileKey(config)
+		if err != nil {
+			return nil, err
+		} else if len(names) > 0 {
+			return names, nil
+		}
+	}
+	return splitProfileNames(value), nil
+}
+
+// profileKey returns the names in the profile key of a configuration or profile, if any.
+func profileKey(data []byte) ([]string, error) {
+	selector := &struct {
+		Profile string `json:"profile"`
+	}{}
+	if err := json.Unmarshal(data, selector); err != nil {
+		return nil, err
+	}
+	return splitProfileNames(selector.Profile), nil
+}
+
+func splitProfileNames(value string) []string {
+	result := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// loadProfiles returns the built-in profiles, and any profiles in the file specified by the MICROFAB_PROFILES
+// environment variable. The file contains a JSON object that maps profile names to partial configurations, and
+// profiles in the file replace built-in profiles with the same name.
+func loadProfiles() (map[string]json.RawMessage, error) {
+	profiles := map[string]json.RawMessage{}
+	for name, profile := range builtinProfiles {
+		profiles[name] = json.RawMessage(profile)
+	}
+	file, ok := os.LookupEnv("MICROFAB_PROFILES")
+	if !ok || file == "" {
+		return profiles, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	fileProfiles := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fileProfiles); err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse profiles file %s", file)
+	}
+	for name, profile := range fileProfiles {
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// applyProfiles applies the named profiles to the configuration, in order. A profile can itself specify a profile
+// key, in which case those profiles are applied first, so that profiles can be layered on top of each other.
+func (c *Config) applyProfiles(names []string) error {
+	profiles, err := loadProfiles()
+	if err != nil {
+		return err
+	}
+	applied := map[string]bool{}
+	var apply func(name string, stack []string) error
+	apply = func(name string, stack []string) error {
+		for _, parent := range stack {
+			if parent == name {
+				return fmt.Errorf("Profile %s extends itself: %s", name, strings.Join(append(stack, name), " -> "))
+			}
+		}
+		if applied[name] {
+			return nil
+		}
+		profile, ok := profiles[name]
+		if !ok {
+			available := []string{}
+			for name := range profiles {
+				available = append(available, name)
+			}
+			sort.Strings(available)
+			return fmt.Errorf("Profile %s not found, available profiles are %s", name, strings.Join(available, ", "))
+		}
+		parents, err := profileKey(profile)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to parse profile %s", name)
+		}
+		for _, parent := range parents {
+			if err := apply(parent, append(stack, name)); err != nil {
+				return err
+			}
+		}
+		logger.Printf("Applying configuration profile %s", name)
+		if err := json.Unmarshal(profile, c); err != nil {
+			return errors.WithMessagef(err, "failed to apply profile %s", name)
+		}
+		applied[name] = true
+		return nil
+	}
+	for _, name := range names {
+		if err := apply(name, []string{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
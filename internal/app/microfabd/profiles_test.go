@@ -0,0 +1,163 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package microfabd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/hyperledger-labs/microfab/internal/app/microfabd"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the microfabd package", func() {
+
+	var testDirectory string
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-microfabd")
+		Expect(err).NotTo(HaveOccurred())
+		for _, name := range []string{"MICROFAB_CONFIG", "MICROFAB_PROFILE", "MICROFAB_PROFILES"} {
+			os.Unsetenv(name)
+		}
+		os.Setenv("MICROFAB_HOME", testDirectory)
+	})
+
+	AfterEach(func() {
+		for _, name := range []string{"MICROFAB_CONFIG", "MICROFAB_PROFILE", "MICROFAB_PROFILES", "MICROFAB_HOME"} {
+			os.Unsetenv(name)
+		}
+		os.RemoveAll(testDirectory)
+	})
+
+	writeProfiles := func(profiles string) {
+		file := path.Join(testDirectory, "profiles.json")
+		Expect(ioutil.WriteFile(file, []byte(profiles), 0644)).To(Succeed())
+		os.Setenv("MICROFAB_PROFILES", file)
+	}
+
+	organizationNames := func(config *microfabd.Config) []string {
+		result := []string{}
+		for _, organization := range config.EndorsingOrganizations {
+			result = append(result, organization.Name)
+		}
+		return result
+	}
+
+	Context("microfabd.DefaultConfig()", func() {
+
+		When("no profiles are specified", func() {
+			It("returns the default configuration, which uses CouchDB", func() {
+				config, err := microfabd.DefaultConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Profile).To(BeEmpty())
+				Expect(organizationNames(config)).To(Equal([]string{"Org1"}))
+				Expect(config.AnyUsesCouchDB()).To(BeTrue())
+			})
+		})
+
+		When("a profile that builds on another profile is specified", func() {
+			It("applies both profiles, in order", func() {
+				os.Setenv("MICROFAB_CONFIG", `{"profile":"two-org-tls"}`)
+				config, err := microfabd.DefaultConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Profile).To(Equal("two-org-tls"))
+				Expect(organizationNames(config)).To(Equal([]string{"Org1", "Org2"}))
+				Expect(config.TLS.Enabled).To(BeTrue())
+			})
+		})
+
+		When("profiles are specified by the MICROFAB_PROFILE environment variable", func() {
+			It("applies the profiles, and the rest of the configuration on top of them", func() {
+				os.Setenv("MICROFAB_PROFILE", "two-org, ci")
+				os.Setenv("MICROFAB_CONFIG", `{"certificate_authorities":true}`)
+				config, err := microfabd.DefaultConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Profile).To(Equal("two-org,ci"))
+				Expect(organizationNames(config)).To(Equal([]string{"Org1", "Org2"}))
+				Expect(config.AnyUsesCouchDB()).To(BeFalse())
+				Expect(config.CertificateAuthorities).To(BeTrue())
+			})
+		})
+
+		When("the configuration and the MICROFAB_PROFILE environment variable both specify profiles", func() {
+			It("only applies the profiles in the configuration", func() {
+				os.Setenv("MICROFAB_PROFILE", "ci")
+				os.Setenv("MICROFAB_CONFIG", `{"profile":"two-org"}`)
+				config, err := microfabd.DefaultConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Profile).To(Equal("two-org"))
+				Expect(config.AnyUsesCouchDB()).To(BeTrue())
+			})
+		})
+
+		When("profiles are defined in a profiles file", func() {
+			It("applies the profiles, which can build on and replace built-in profiles", func() {
+				writeProfiles(`{
+					"three-org": {
+						"profile": "two-org",
+						"endorsing_organizations": [{"name": "Org1"}, {"name": "Org2"}, {"name": "Org3"}]
+					},
+					"ci": {"couchdb": false, "timeout": "1m"}
+				}`)
+				os.Setenv("MICROFAB_PROFILE", "three-org,ci")
+				config, err := microfabd.DefaultConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(organizationNames(config)).To(Equal([]string{"Org1", "Org2", "Org3"}))
+				Expect(config.Channels[0].EndorsingOrganizations).To(Equal([]string{"Org1", "Org2"}))
+				Expect(config.TimeoutString).To(Equal("1m"))
+				Expect(config.CertificateAuthorities).To(BeTrue())
+			})
+		})
+
+		When("profiles build on each other in a cycle", func() {
+			It("returns an error", func() {
+				writeProfiles(`{
+					"first": {"profile": "second"},
+					"second": {"profile": "first"}
+				}`)
+				os.Setenv("MICROFAB_PROFILE", "first")
+				_, err := microfabd.DefaultConfig()
+				Expect(err).To(MatchError("Profile first extends itself: first -> second -> first"))
+			})
+		})
+
+		When("a profile is applied more than once", func() {
+			It("only applies the profile once", func() {
+				writeProfiles(`{
+					"left": {"profile": "base"},
+					"right": {"profile": "base", "timeout": "1m"},
+					"base": {"timeout": "5m"}
+				}`)
+				os.Setenv("MICROFAB_PROFILE", "right,left")
+				config, err := microfabd.DefaultConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.TimeoutString).To(Equal("1m"))
+			})
+		})
+
+		When("an unknown profile is specified", func() {
+			It("returns an error listing the available profiles", func() {
+				os.Setenv("MICROFAB_PROFILE", "unknown")
+				_, err := microfabd.DefaultConfig()
+				Expect(err).To(MatchError(HavePrefix("Profile unknown not found, available profiles are ci, mock, single-org")))
+			})
+		})
+
+		When("the profiles file is not valid", func() {
+			It("returns an error", func() {
+				writeProfiles(`[]`)
+				os.Setenv("MICROFAB_PROFILE", "ci")
+				_, err := microfabd.DefaultConfig()
+				Expect(err).To(MatchError(ContainSubstring("failed to parse profiles file")))
+			})
+		})
+
+	})
+
+})
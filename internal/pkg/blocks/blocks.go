@@ -5,7 +5,9 @@
 package blocks
 
 import (
+	"context"
 	"errors"
+	"math"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
 	"github.com/hyperledger-labs/microfab/internal/pkg/protoutil"
@@ -25,6 +27,12 @@ type Deliverer interface {
 	Deliver(envelope *common.Envelope, callback DeliverCallback) error
 }
 
+// StreamingDeliverer can be implemented by types that can deliver blocks until a context is done.
+type StreamingDeliverer interface {
+	Deliverer
+	DeliverWithContext(ctx context.Context, envelope *common.Envelope, callback DeliverCallback) error
+}
+
 // GetConfigBlock gets the latest config block from the specified channel.
 func GetConfigBlock(deliverer Deliverer, channel string) (*common.Block, error) {
 	newestBlock, err := GetNewestBlock(deliverer, channel)
@@ -79,6 +87,30 @@ func GetSpecificBlock(deliverer Deliverer, channel string, number uint64) (*comm
 	return getBlock(deliverer, channel, seekInfo)
 }
 
+// StreamBlocks calls the callback for every block in the specified channel, starting with the specified block
+// number and waiting for new blocks as they are committed, until the context is done or the callback returns an error.
+func StreamBlocks(ctx context.Context, deliverer StreamingDeliverer, channel string, start uint64, callback DeliverCallback) error {
+	seekInfo := &orderer.SeekInfo{
+		Start: &orderer.SeekPosition{
+			Type: &orderer.SeekPosition_Specified{
+				Specified: &orderer.SeekSpecified{
+					Number: start,
+				},
+			},
+		},
+		Stop: &orderer.SeekPosition{
+			Type: &orderer.SeekPosition_Specified{
+				Specified: &orderer.SeekSpecified{
+					Number: math.MaxUint64,
+				},
+			},
+		},
+		Behavior: orderer.SeekInfo_BLOCK_UNTIL_READY,
+	}
+	envelope := buildEnvelope(deliverer, channel, seekInfo)
+	return deliverer.DeliverWithContext(ctx, envelope, callback)
+}
+
 // GetGenesisBlock gets the genesis block from the specified channel.
 func GetGenesisBlock(deliverer Deliverer, channel string) (*common.Block, error) {
 	return GetSpecificBlock(deliverer, channel, 0)
@@ -12,6 +12,7 @@ import (
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fakes/deliverer.go --fake-name Deliverer . Deliverer
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -o fakes/streaming_deliverer.go --fake-name StreamingDeliverer . StreamingDeliverer
 
 func TestBlocks(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -5,7 +5,9 @@
 package blocks_test
 
 import (
+	"context"
 	"errors"
+	"math"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
 	"github.com/hyperledger-labs/microfab/internal/pkg/blocks/fakes"
@@ -373,4 +375,57 @@ var _ = Describe("the blocks package", func() {
 
 	})
 
+	Context("blocks.StreamBlocks()", func() {
+
+		var fakeStreamingDeliverer *fakes.StreamingDeliverer
+
+		BeforeEach(func() {
+			fakeStreamingDeliverer = &fakes.StreamingDeliverer{}
+			fakeStreamingDeliverer.MSPIDReturns("Org1MSP")
+			fakeStreamingDeliverer.IdentityReturns(testIdentity)
+			fakeStreamingDeliverer.DeliverWithContextCalls(func(_ context.Context, _ *common.Envelope, callback blocks.DeliverCallback) error {
+				if err := callback(fakeBlock); err != nil {
+					return err
+				}
+				return callback(fakeBlock)
+			})
+		})
+
+		When("called for a channel", func() {
+			It("calls the callback for every block from the start block", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				received := []*common.Block{}
+				err := blocks.StreamBlocks(ctx, fakeStreamingDeliverer, "mychannel", 1337, func(block *common.Block) error {
+					received = append(received, block)
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(received).To(Equal([]*common.Block{fakeBlock, fakeBlock}))
+				Expect(fakeStreamingDeliverer.DeliverWithContextCallCount()).To(Equal(1))
+				actualCtx, envelope, _ := fakeStreamingDeliverer.DeliverWithContextArgsForCall(0)
+				Expect(actualCtx).To(Equal(ctx))
+				channelHeader := getChannelHeader(envelope)
+				Expect(channelHeader.Type).To(BeEquivalentTo(common.HeaderType_DELIVER_SEEK_INFO))
+				Expect(channelHeader.ChannelId).To(Equal("mychannel"))
+				seekInfo := getSeekInfo(envelope)
+				start := seekInfo.Start.Type.(*orderer.SeekPosition_Specified)
+				Expect(start.Specified.Number).To(BeEquivalentTo(1337))
+				stop := seekInfo.Stop.Type.(*orderer.SeekPosition_Specified)
+				Expect(stop.Specified.Number).To(BeEquivalentTo(uint64(math.MaxUint64)))
+				Expect(seekInfo.Behavior).To(Equal(orderer.SeekInfo_BLOCK_UNTIL_READY))
+			})
+		})
+
+		When("the callback returns an error", func() {
+			It("returns the error", func() {
+				err := blocks.StreamBlocks(context.Background(), fakeStreamingDeliverer, "mychannel", 0, func(block *common.Block) error {
+					return errors.New("fake error")
+				})
+				Expect(err).To(MatchError("fake error"))
+			})
+		})
+
+	})
+
 })
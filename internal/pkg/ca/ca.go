@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/url"
 	"os/exec"
+	"path"
 	"strconv"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
@@ -25,6 +26,7 @@ type CA struct {
 	operationsURL  *url.URL
 	command        *exec.Cmd
 	tls            *identity.Identity
+	overrides      map[string]interface{}
 }
 
 // New creates a new CA.
@@ -38,7 +40,7 @@ func New(organization *organization.Organization, directory string, apiPort int3
 	if err != nil {
 		return nil, err
 	}
-	return &CA{organization, identity, directory, apiPort, parsedAPIURL, operationsPort, parsedOperationsURL, nil, nil}, nil
+	return &CA{organization, identity, directory, apiPort, parsedAPIURL, operationsPort, parsedOperationsURL, nil, nil, nil}, nil
 }
 
 // TLS gets the TLS identity for this CA.
@@ -51,11 +53,22 @@ func (c *CA) EnableTLS(tls *identity.Identity) {
 	c.tls = tls
 }
 
+// SetOverrides sets the fabric-ca-server settings, keyed by flag name (for example "registry.maxenrollments"), that are passed
+// to the CA in addition to the generated arguments.
+func (c *CA) SetOverrides(overrides map[string]interface{}) {
+	c.overrides = overrides
+}
+
 // Organization returns the organization of the CA.
 func (c *CA) Organization() *organization.Organization {
 	return c.organization
 }
 
+// LogFile returns the path to the file that the output of the CA is written to.
+func (c *CA) LogFile() string {
+	return path.Join(c.directory, "logs", "ca.log")
+}
+
 // APIHostname returns the hostname of the CA.
 func (c *CA) APIHostname(internal bool) string {
 	if internal {
@@ -5,23 +5,27 @@
 package ca
 
 import (
-	"bufio"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
 	"github.com/pkg/errors"
 )
 
+var logger = logging.New("ca")
+
 // Start starts the peer.
 func (c *CA) Start(timeout time.Duration) error {
 	logsDirectory := filepath.Join(c.directory, "logs")
@@ -77,7 +81,12 @@ func (c *CA) Start(timeout time.Duration) error {
 			return err
 		}
 	}
-	fmt.Print(args)
+	overrideArgs, err := c.createOverrides()
+	if err != nil {
+		return err
+	}
+	args = append(args, overrideArgs...)
+	logger.Debugf("Starting fabric-ca-server with arguments %v", args)
 	cmd := exec.Command(
 		"fabric-ca-server",
 		args...,
@@ -93,20 +102,9 @@ func (c *CA) Start(timeout time.Duration) error {
 	if err != nil {
 		return err
 	}
-	go func() {
-		reader := bufio.NewReader(pipe)
-		scanner := bufio.NewScanner(reader)
-		scanner.Split(bufio.ScanLines)
-		id := strings.ToLower(c.identity.Name())
-		id = strings.ReplaceAll(id, " ", "")
-		logger := log.New(os.Stdout, fmt.Sprintf("[%16s] ", id), 0)
-		for scanner.Scan() {
-			logger.Println(scanner.Text())
-			logFile.WriteString(scanner.Text())
-		}
-		pipe.Close()
-		logFile.Close()
-	}()
+	id := strings.ToLower(c.identity.Name())
+	id = strings.ReplaceAll(id, " ", "")
+	go logging.Pipe(id, pipe, logFile)
 	cmd.Stderr = cmd.Stdout
 	err = cmd.Start()
 	if err != nil {
@@ -150,6 +148,31 @@ func (c *CA) Stop() error {
 	return nil
 }
 
+var flagRegex = regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9]+)*$`)
+
+func (c *CA) createOverrides() ([]string, error) {
+	names := []string{}
+	for name := range c.overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []string{}
+	for _, name := range names {
+		if !flagRegex.MatchString(name) {
+			return nil, errors.Errorf("Invalid CA setting %s, must be a fabric-ca-server flag name such as registry.maxenrollments", name)
+		}
+		switch value := c.overrides[name].(type) {
+		case string, float64, int:
+			result = append(result, fmt.Sprintf("--%s=%s", name, util.FormatScalar(value)))
+		case bool:
+			result = append(result, fmt.Sprintf("--%s=%t", name, value))
+		default:
+			return nil, errors.Errorf("Invalid value for CA setting %s, must be a string, number or boolean", name)
+		}
+	}
+	return result, nil
+}
+
 func (c *CA) hasStarted() bool {
 	cli := &http.Client{
 		Transport: &http.Transport{
@@ -160,7 +183,7 @@ func (c *CA) hasStarted() bool {
 	}
 	resp, err := cli.Get(fmt.Sprintf("%s/healthz", c.OperationsURL(true)))
 	if err != nil {
-		log.Printf("error waiting for CA: %v\n", err)
+		logger.Debugf("error waiting for CA: %v", err)
 		return false
 	}
 	if resp.StatusCode != 200 {
@@ -18,6 +18,26 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 )
 
+// EndorsementError is returned when a peer does not successfully endorse a transaction.
+type EndorsementError struct {
+	Status  int32
+	Message string
+}
+
+func (e *EndorsementError) Error() string {
+	return fmt.Sprintf("Bad proposal response: status %d, mesage %s", e.Status, e.Message)
+}
+
+// ValidationError is returned when a submitted transaction is committed, but is marked as invalid.
+type ValidationError struct {
+	TxID string
+	Code fpeer.TxValidationCode
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Transaction %s failed validation with code %s", e.TxID, e.Code)
+}
+
 // ApproveChaincodeDefinition approves a chaincode definition on a channel.
 func ApproveChaincodeDefinition(peers []*peer.Connection, o *orderer.Connection, channel string, sequence int64, name string, version string, packageID string) error {
 	arg := &lifecycle.ApproveChaincodeDefinitionForMyOrgArgs{
@@ -137,7 +157,7 @@ func executeTransaction(peers []*peer.Connection, o *orderer.Connection, channel
 		if err != nil {
 			return nil, nil, nil, err
 		} else if response.Response.Status != int32(common.Status_SUCCESS) {
-			return nil, nil, nil, fmt.Errorf("Bad proposal response: status %d, mesage %s", response.Response.Status, response.Response.Message)
+			return nil, nil, nil, &EndorsementError{response.Response.Status, response.Response.Message}
 		}
 		responses = append(responses, response)
 		endorsements = append(endorsements, response.Endorsement)
@@ -184,7 +204,11 @@ func orderTransaction(peers []*peer.Connection, o *orderer.Connection, channel s
 				done <- err
 				return
 			}
-			for _, data := range nextBlock.Data.Data {
+			var filter []byte
+			if metadata := nextBlock.GetMetadata().GetMetadata(); len(metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+				filter = metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+			}
+			for i, data := range nextBlock.Data.Data {
 				envelope := &common.Envelope{}
 				util.UnmarshalOrPanic(data, envelope)
 				payload := &common.Payload{}
@@ -192,6 +216,10 @@ func orderTransaction(peers []*peer.Connection, o *orderer.Connection, channel s
 				channelHeader := &common.ChannelHeader{}
 				util.UnmarshalOrPanic(payload.Header.ChannelHeader, channelHeader)
 				if channelHeader.TxId == txID {
+					if i < len(filter) && fpeer.TxValidationCode(filter[i]) != fpeer.TxValidationCode_VALID {
+						done <- &ValidationError{txID, fpeer.TxValidationCode(filter[i])}
+						return
+					}
 					done <- nil
 					return
 				}
@@ -5,12 +5,14 @@
 package channel
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/hyperledger-labs/microfab/internal/pkg/config"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
 	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
 	"github.com/hyperledger-labs/microfab/internal/pkg/protoutil"
 	"github.com/hyperledger-labs/microfab/internal/pkg/txid"
 	"github.com/hyperledger-labs/microfab/internal/pkg/util"
@@ -89,6 +91,31 @@ func WithCapabilityLevel(capabilityLevel string) Option {
 	}
 }
 
+// WithPolicies sets the specified policies for the application group of the channel, replacing the default policies
+// with the same names.
+func WithPolicies(policies map[string]*common.Policy) Option {
+	return func(operation *channelOperation) error {
+		application := operation.config.GetChannelGroup().Groups["Application"]
+		for name, policy := range policies {
+			application.Policies[name] = &common.ConfigPolicy{
+				ModPolicy: "Admins",
+				Policy:    policy,
+			}
+		}
+		return nil
+	}
+}
+
+// WithConsortium creates the channel from the specified consortium, instead of SampleConsortium.
+func WithConsortium(consortium string) Option {
+	return func(operation *channelOperation) error {
+		operation.config.GetChannelGroup().Values["Consortium"].Value = util.MarshalOrPanic(&common.Consortium{
+			Name: consortium,
+		})
+		return nil
+	}
+}
+
 // UsingMSPID uses the specified MSP ID to create or update the channel.
 func UsingMSPID(mspID string) Option {
 	return func(operation *channelOperation) error {
@@ -107,6 +134,24 @@ func UsingIdentity(identity *identity.Identity) Option {
 
 // CreateChannel creates a new channel on the specified ordering service.
 func CreateChannel(o *orderer.Connection, channel string, opts ...Option) error {
+	operation, configUpdate, err := newCreateChannelOperation(o.MSPID(), o.Identity(), channel, opts...)
+	if err != nil {
+		return err
+	}
+	return createOrUpdateChannel(o, operation.mspID, operation.identity, configUpdate)
+}
+
+// CreateChannelEnvelope builds a signed transaction that creates a new channel, in the same format as the
+// channel creation transactions generated by configtxgen.
+func CreateChannelEnvelope(mspID string, identity *identity.Identity, channel string, opts ...Option) (*common.Envelope, error) {
+	operation, configUpdate, err := newCreateChannelOperation(mspID, identity, channel, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return buildConfigUpdateEnvelope(operation.mspID, operation.identity, operation.identity, configUpdate), nil
+}
+
+func newCreateChannelOperation(mspID string, identity *identity.Identity, channel string, opts ...Option) (*channelOperation, *common.ConfigUpdate, error) {
 	_ = &common.Policy{
 		Type: int32(common.Policy_SIGNATURE),
 		Value: util.MarshalOrPanic(&common.SignaturePolicyEnvelope{
@@ -175,19 +220,20 @@ func CreateChannel(o *orderer.Connection, channel string, opts ...Option) error
 		&common.Config{
 			ChannelGroup: configUpdate.WriteSet,
 		},
-		o.MSPID(),
-		o.Identity(),
+		mspID,
+		identity,
 	}
 	for _, opt := range opts {
 		err := opt(operation)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
 	for mspID := range configUpdate.WriteSet.Groups["Application"].Groups {
 		configUpdate.ReadSet.Groups["Application"].Groups[mspID] = &common.ConfigGroup{}
 	}
-	return createOrUpdateChannel(o, operation.mspID, operation.identity, configUpdate)
+	configUpdate.ReadSet.Values["Consortium"].Value = configUpdate.WriteSet.Values["Consortium"].Value
+	return operation, configUpdate, nil
 }
 
 // UpdateChannel updates an existing channel on the specified ordering service.
@@ -216,7 +262,64 @@ func UpdateChannel(o *orderer.Connection, channel string, opts ...Option) error
 	return createOrUpdateChannel(o, operation.mspID, operation.identity, configUpdate)
 }
 
+// AddConsortiumOrganizations adds the specified organizations to a consortium in the system channel of the specified
+// ordering service. Organizations that are already members of the consortium with the same MSP definition are left
+// unchanged, and an error is returned if a member with the same MSP ID has a different MSP definition, as replacing
+// it would lock that organization out of its existing channels. The connection must use an identity that satisfies the
+// Admins policy of the ordering service.
+//
+// This only works with ordering services that still have a system channel; ordering services that use the channel
+// participation API without a system channel do not have consortiums.
+func AddConsortiumOrganizations(o *orderer.Connection, systemChannel, consortium string, tls *identity.Identity, organizations ...*organization.Organization) error {
+	originalConfig, err := config.GetConfig(o, systemChannel)
+	if err != nil {
+		return err
+	}
+	newConfig := proto.Clone(originalConfig).(*common.Config)
+	consortiums, ok := newConfig.GetChannelGroup().Groups["Consortiums"]
+	if !ok {
+		return fmt.Errorf("The channel %s is not a system channel", systemChannel)
+	}
+	group, ok := consortiums.Groups[consortium]
+	if !ok {
+		return fmt.Errorf("The system channel %s does not contain a consortium named %s", systemChannel, consortium)
+	}
+	changed := false
+	for _, organization := range organizations {
+		configGroup, err := protoutil.BuildConfigGroupFromOrganization(organization, tls)
+		if err != nil {
+			return err
+		}
+		existing, ok := group.Groups[organization.MSPID()]
+		if ok && existing.Values["MSP"] != nil && bytes.Equal(existing.Values["MSP"].Value, configGroup.Values["MSP"].Value) {
+			continue
+		} else if ok {
+			return fmt.Errorf("The consortium %s already contains an organization with MSP ID %s and a different MSP definition", consortium, organization.MSPID())
+		}
+		group.Groups[organization.MSPID()] = configGroup
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	configUpdate, err := config.GenerateConfigUpdate(originalConfig, newConfig)
+	if err != nil {
+		return err
+	}
+	configUpdate.ChannelId = systemChannel
+	return createOrUpdateChannel(o, o.MSPID(), o.Identity(), configUpdate)
+}
+
 func createOrUpdateChannel(o *orderer.Connection, mspID string, identity *identity.Identity, configUpdate *common.ConfigUpdate) error {
+	envelope := buildConfigUpdateEnvelope(mspID, identity, o.Identity(), configUpdate)
+	err := o.Broadcast(envelope)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func buildConfigUpdateEnvelope(mspID string, identity *identity.Identity, signer *identity.Identity, configUpdate *common.ConfigUpdate) *common.Envelope {
 	txID := txid.New(mspID, identity)
 	header := protoutil.BuildHeader(common.HeaderType_CONFIG_UPDATE, configUpdate.ChannelId, txID)
 	configUpdateBytes := util.MarshalOrPanic(configUpdate)
@@ -231,10 +334,5 @@ func createOrUpdateChannel(o *orderer.Connection, mspID string, identity *identi
 		},
 	}
 	payload := protoutil.BuildPayload(header, configUpdateEnvelope)
-	envelope := protoutil.BuildEnvelope(payload, o.Identity())
-	err := o.Broadcast(envelope)
-	if err != nil {
-		return err
-	}
-	return nil
+	return protoutil.BuildEnvelope(payload, signer)
 }
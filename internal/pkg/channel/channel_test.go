@@ -0,0 +1,204 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package channel_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger/fabric-protos-go/common"
+	ab "github.com/hyperledger/fabric-protos-go/orderer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+)
+
+// systemChannelServer is an ordering service that always delivers the same system channel genesis block, and records the
+// envelopes that are broadcast to it.
+type systemChannelServer struct {
+	sync.Mutex
+	genesisBlock *common.Block
+	envelopes    []*common.Envelope
+}
+
+func (o *systemChannelServer) Broadcast(stream ab.AtomicBroadcast_BroadcastServer) error {
+	for {
+		envelope, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		o.Lock()
+		o.envelopes = append(o.envelopes, envelope)
+		o.Unlock()
+		if err := stream.Send(&ab.BroadcastResponse{Status: common.Status_SUCCESS}); err != nil {
+			return err
+		}
+	}
+}
+
+func (o *systemChannelServer) Deliver(stream ab.AtomicBroadcast_DeliverServer) error {
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := stream.Send(&ab.DeliverResponse{Type: &ab.DeliverResponse_Block{Block: o.genesisBlock}}); err != nil {
+			return err
+		}
+		if err := stream.Send(&ab.DeliverResponse{Type: &ab.DeliverResponse_Status{Status: common.Status_SUCCESS}}); err != nil {
+			return err
+		}
+	}
+}
+
+func (o *systemChannelServer) broadcastEnvelopes() []*common.Envelope {
+	o.Lock()
+	defer o.Unlock()
+	return o.envelopes
+}
+
+func unmarshalConfigUpdate(envelope *common.Envelope) *common.ConfigUpdate {
+	payload := &common.Payload{}
+	Expect(proto.Unmarshal(envelope.Payload, payload)).To(Succeed())
+	configUpdateEnvelope := &common.ConfigUpdateEnvelope{}
+	Expect(proto.Unmarshal(payload.Data, configUpdateEnvelope)).To(Succeed())
+	configUpdate := &common.ConfigUpdate{}
+	Expect(proto.Unmarshal(configUpdateEnvelope.ConfigUpdate, configUpdate)).To(Succeed())
+	return configUpdate
+}
+
+func unmarshalConsortium(value *common.ConfigValue) string {
+	consortium := &common.Consortium{}
+	Expect(proto.Unmarshal(value.Value, consortium)).To(Succeed())
+	return consortium.Name
+}
+
+var _ = Describe("the channel package", func() {
+
+	var testDirectory string
+	var ordererOrganization, org1, org2 *organization.Organization
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-channel")
+		Expect(err).NotTo(HaveOccurred())
+		ordererOrganization, err = organization.New("Orderer", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org1, err = organization.New("Org1", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org2, err = organization.New("Org2", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDirectory)
+	})
+
+	Context("channel.CreateChannelEnvelope()", func() {
+
+		When("called without a consortium", func() {
+			It("creates the channel from SampleConsortium", func() {
+				envelope, err := channel.CreateChannelEnvelope(org1.MSPID(), org1.Admin(), "channel1", channel.AddMSPID(org1.MSPID()))
+				Expect(err).NotTo(HaveOccurred())
+				configUpdate := unmarshalConfigUpdate(envelope)
+				Expect(unmarshalConsortium(configUpdate.ReadSet.Values["Consortium"])).To(Equal("SampleConsortium"))
+				Expect(unmarshalConsortium(configUpdate.WriteSet.Values["Consortium"])).To(Equal("SampleConsortium"))
+			})
+		})
+
+		When("called with channel.WithConsortium()", func() {
+			It("creates the channel from the specified consortium", func() {
+				envelope, err := channel.CreateChannelEnvelope(org1.MSPID(), org1.Admin(), "channel1", channel.AddMSPID(org1.MSPID()), channel.WithConsortium("MyConsortium"))
+				Expect(err).NotTo(HaveOccurred())
+				configUpdate := unmarshalConfigUpdate(envelope)
+				Expect(unmarshalConsortium(configUpdate.ReadSet.Values["Consortium"])).To(Equal("MyConsortium"))
+				Expect(unmarshalConsortium(configUpdate.WriteSet.Values["Consortium"])).To(Equal("MyConsortium"))
+				Expect(configUpdate.WriteSet.Groups["Application"].Groups).To(HaveKey(org1.MSPID()))
+			})
+		})
+
+	})
+
+	Context("channel.AddConsortiumOrganizations()", func() {
+
+		var server *grpc.Server
+		var systemChannel *systemChannelServer
+		var ordererConnection *orderer.Connection
+
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "localhost:0")
+			Expect(err).NotTo(HaveOccurred())
+			port := int32(listener.Addr().(*net.TCPAddr).Port)
+			testOrderer, err := orderer.New(ordererOrganization, testDirectory, 8080, port, "grpc://orderer-api.127-0-0-1.nip.io:8080", port+1, "http://orderer-operations.127-0-0-1.nip.io:8080")
+			Expect(err).NotTo(HaveOccurred())
+			genesisBlock, err := testOrderer.GenesisBlock([]*organization.Organization{org1})
+			Expect(err).NotTo(HaveOccurred())
+			systemChannel = &systemChannelServer{genesisBlock: genesisBlock}
+			server = grpc.NewServer()
+			ab.RegisterAtomicBroadcastServer(server, systemChannel)
+			go server.Serve(listener)
+			ordererConnection, err = orderer.Connect(testOrderer, ordererOrganization.MSPID(), ordererOrganization.Admin())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			ordererConnection.Close()
+			server.Stop()
+		})
+
+		When("the organization is not a member of the consortium", func() {
+			It("adds the organization to the consortium", func() {
+				err := channel.AddConsortiumOrganizations(ordererConnection, "testchainid", "SampleConsortium", nil, org2)
+				Expect(err).NotTo(HaveOccurred())
+				envelopes := systemChannel.broadcastEnvelopes()
+				Expect(envelopes).To(HaveLen(1))
+				configUpdate := unmarshalConfigUpdate(envelopes[0])
+				Expect(configUpdate.ChannelId).To(Equal("testchainid"))
+				members := configUpdate.WriteSet.Groups["Consortiums"].Groups["SampleConsortium"].Groups
+				Expect(members).To(HaveKey(org1.MSPID()))
+				Expect(members).To(HaveKey(org2.MSPID()))
+				Expect(members[org2.MSPID()].Values).To(HaveKey("MSP"))
+			})
+		})
+
+		When("the organization is already a member of the consortium with the same MSP", func() {
+			It("does not update the system channel", func() {
+				err := channel.AddConsortiumOrganizations(ordererConnection, "testchainid", "SampleConsortium", nil, org1)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(systemChannel.broadcastEnvelopes()).To(BeEmpty())
+			})
+		})
+
+		When("the consortium contains a different organization with the same MSP ID", func() {
+			It("returns an error without updating the system channel", func() {
+				impostor, err := organization.New("Org1", nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+				err = channel.AddConsortiumOrganizations(ordererConnection, "testchainid", "SampleConsortium", nil, org2, impostor)
+				Expect(err).To(MatchError("The consortium SampleConsortium already contains an organization with MSP ID Org1MSP and a different MSP definition"))
+				Expect(systemChannel.broadcastEnvelopes()).To(BeEmpty())
+			})
+		})
+
+		When("the consortium does not exist", func() {
+			It("returns an error", func() {
+				err := channel.AddConsortiumOrganizations(ordererConnection, "testchainid", "MyConsortium", nil, org2)
+				Expect(err).To(MatchError("The system channel testchainid does not contain a consortium named MyConsortium"))
+			})
+		})
+
+	})
+
+})
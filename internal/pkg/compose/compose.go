@@ -0,0 +1,507 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// containerDirectory is the directory that the exported files are mounted into in every container.
+const containerDirectory = "/var/hyperledger/microfab"
+
+const couchDBImage = "couchdb:3.3"
+
+// Channel represents a channel to be created in the exported network.
+type Channel struct {
+	Name            string
+	CapabilityLevel string
+	Organizations   []*organization.Organization
+	Policies        map[string]*common.Policy
+}
+
+// Network represents the network to be exported.
+type Network struct {
+	Orderer       *orderer.Orderer
+	Organizations []*organization.Organization
+	Peers         []*peer.Peer
+	Channels      []Channel
+	FabricVersion string
+}
+
+type composeFile struct {
+	Services map[string]*service          `yaml:"services"`
+	Volumes  map[string]map[string]string `yaml:"volumes,omitempty"`
+}
+
+type service struct {
+	Image       string   `yaml:"image"`
+	NetworkMode string   `yaml:"network_mode,omitempty"`
+	Environment []string `yaml:"environment,omitempty"`
+	Command     []string `yaml:"command,omitempty"`
+	Ports       []string `yaml:"ports,omitempty"`
+	Volumes     []string `yaml:"volumes,omitempty"`
+	DependsOn   []string `yaml:"depends_on,omitempty"`
+}
+
+type scriptOrganization struct {
+	ID         string
+	MSPID      string
+	PeerPort   int32
+	AnchorHost string
+	AnchorPort int32
+	HasPeer    bool
+}
+
+type scriptChannel struct {
+	Name          string
+	Organizations []string
+}
+
+type scriptData struct {
+	TLS           bool
+	Directory     string
+	OrdererPort   int32
+	Organizations []*scriptOrganization
+	Channels      []*scriptChannel
+}
+
+// Export writes a docker-compose network equivalent to the specified network into the specified directory.
+//
+// All of the containers share a single network namespace, so that the addresses recorded in the channel
+// configuration and the peer configuration are the same as they are in Microfab.
+func Export(directory string, network *Network) error {
+	if network.FabricVersion == "" {
+		return fmt.Errorf("Fabric version must be specified")
+	}
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+	ordererService, err := exportOrderer(directory, network)
+	if err != nil {
+		return errors.WithMessage(err, "failed to export orderer")
+	}
+	compose := &composeFile{
+		Services: map[string]*service{
+			"orderer": ordererService,
+		},
+		Volumes: map[string]map[string]string{
+			"orderer-data": {},
+		},
+	}
+	setupDependencies := []string{}
+	for _, p := range network.Peers {
+		id := peerID(p)
+		peerService, err := exportPeer(directory, network, p)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to export peer %s", id)
+		}
+		compose.Services[id] = peerService
+		compose.Volumes[fmt.Sprintf("%s-data", id)] = map[string]string{}
+		ordererService.Ports = append(ordererService.Ports,
+			publishPort(p.APIPort(true)),
+			publishPort(p.ChaincodePort(true)),
+			publishPort(p.OperationsPort(true)),
+		)
+		if p.CouchDB() {
+			couchDBService, err := exportCouchDB(directory, p)
+			if err != nil {
+				return errors.WithMessagef(err, "failed to export CouchDB for peer %s", id)
+			}
+			couchDBID := fmt.Sprintf("couchdb-%s", id)
+			compose.Services[couchDBID] = couchDBService
+			compose.Volumes[fmt.Sprintf("%s-data", couchDBID)] = map[string]string{}
+			peerService.DependsOn = append(peerService.DependsOn, couchDBID)
+		}
+		setupDependencies = append(setupDependencies, id)
+	}
+	setupService, err := exportSetup(directory, network)
+	if err != nil {
+		return errors.WithMessage(err, "failed to export channels")
+	}
+	setupService.DependsOn = append(setupService.DependsOn, setupDependencies...)
+	compose.Services["setup"] = setupService
+	data, err := yaml.Marshal(compose)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(directory, "docker-compose.yaml"), data, 0644)
+}
+
+func exportOrderer(directory string, network *Network) (*service, error) {
+	o := network.Orderer
+	ordererDirectory := path.Join(directory, "orderer")
+	err := os.MkdirAll(path.Join(ordererDirectory, "config"), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = util.CreateMSPDirectory(path.Join(ordererDirectory, "msp"), o.Identity())
+	if err != nil {
+		return nil, err
+	}
+	genesisBlock, err := o.GenesisBlock(network.Organizations)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(path.Join(ordererDirectory, "config", "genesisblock"), util.MarshalOrPanic(genesisBlock), 0644)
+	if err != nil {
+		return nil, err
+	}
+	containerOrdererDirectory := path.Join(containerDirectory, "orderer")
+	volumes := []string{
+		"./orderer/config:" + path.Join(containerOrdererDirectory, "config") + ":ro",
+		"./orderer/msp:" + path.Join(containerOrdererDirectory, "msp") + ":ro",
+		"orderer-data:" + path.Join(containerOrdererDirectory, "data"),
+	}
+	if o.TLS() != nil {
+		err = writeTLS(path.Join(ordererDirectory, "tls"), o.TLS().Certificate().Bytes(), o.TLS().PrivateKey().Bytes(), o.TLS().CA().Bytes())
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, "./orderer/tls:"+path.Join(containerOrdererDirectory, "tls")+":ro")
+	}
+	environment, err := o.Environment(containerOrdererDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return &service{
+		Image:       fmt.Sprintf("hyperledger/fabric-orderer:%s", network.FabricVersion),
+		Environment: environment,
+		Command:     []string{"orderer"},
+		Ports: []string{
+			publishPort(o.APIPort(true)),
+			publishPort(o.OperationsPort(true)),
+		},
+		Volumes: volumes,
+	}, nil
+}
+
+func exportPeer(directory string, network *Network, p *peer.Peer) (*service, error) {
+	id := peerID(p)
+	peerDirectory := path.Join(directory, id)
+	containerPeerDirectory := path.Join(containerDirectory, id)
+	err := os.MkdirAll(path.Join(peerDirectory, "config"), 0755)
+	if err != nil {
+		return nil, err
+	}
+	err = util.CreateMSPDirectory(path.Join(peerDirectory, "msp"), p.Identity())
+	if err != nil {
+		return nil, err
+	}
+	config, err := p.CoreConfig(containerPeerDirectory)
+	if err != nil {
+		return nil, err
+	}
+	// The builders that Microfab uses are not available in the official images, so chaincode must be deployed
+	// using the chaincode-as-a-service builder that is.
+	chaincode, ok := config["chaincode"].(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("core.yaml missing chaincode section")
+	}
+	chaincode["externalBuilders"] = []map[interface{}]interface{}{
+		{
+			"path": "/opt/hyperledger/ccaas_builder",
+			"name": "ccaas_builder",
+			"propagateEnvironment": []string{
+				"CHAINCODE_AS_A_SERVICE_BUILDER_CONFIG",
+			},
+		},
+	}
+	configData, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(path.Join(peerDirectory, "config", "core.yaml"), configData, 0644)
+	if err != nil {
+		return nil, err
+	}
+	volumes := []string{
+		fmt.Sprintf("./%s/config:%s:ro", id, path.Join(containerPeerDirectory, "config")),
+		fmt.Sprintf("./%s/msp:%s:ro", id, path.Join(containerPeerDirectory, "msp")),
+		fmt.Sprintf("%s-data:%s", id, path.Join(containerPeerDirectory, "data")),
+	}
+	if p.TLS() != nil {
+		err = writeTLS(path.Join(peerDirectory, "tls"), p.TLS().Certificate().Bytes(), p.TLS().PrivateKey().Bytes(), p.TLS().CA().Bytes())
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, fmt.Sprintf("./%s/tls:%s:ro", id, path.Join(containerPeerDirectory, "tls")))
+	}
+	return &service{
+		Image:       fmt.Sprintf("hyperledger/fabric-peer:%s", network.FabricVersion),
+		NetworkMode: "service:orderer",
+		Environment: []string{
+			fmt.Sprintf("FABRIC_CFG_PATH=%s", path.Join(containerPeerDirectory, "config")),
+		},
+		Command:   []string{"peer", "node", "start"},
+		Volumes:   volumes,
+		DependsOn: []string{"orderer"},
+	}, nil
+}
+
+// exportCouchDB exports a CouchDB instance for the peer, listening on the port that the peer uses to connect to CouchDB.
+func exportCouchDB(directory string, p *peer.Peer) (*service, error) {
+	id := peerID(p)
+	couchDBDirectory := path.Join(directory, "couchdb")
+	err := os.MkdirAll(couchDBDirectory, 0755)
+	if err != nil {
+		return nil, err
+	}
+	ini := fmt.Sprintf("[chttpd]\nport = %d\nbind_address = 127.0.0.1\n", p.CouchDBPort())
+	err = ioutil.WriteFile(path.Join(couchDBDirectory, fmt.Sprintf("%s.ini", id)), []byte(ini), 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &service{
+		Image:       couchDBImage,
+		NetworkMode: "service:orderer",
+		Environment: []string{
+			"COUCHDB_USER=admin",
+			"COUCHDB_PASSWORD=adminpw",
+		},
+		Volumes: []string{
+			fmt.Sprintf("./couchdb/%s.ini:/opt/couchdb/etc/local.d/microfab.ini:ro", id),
+			fmt.Sprintf("couchdb-%s-data:/opt/couchdb/data", id),
+		},
+		DependsOn: []string{"orderer"},
+	}, nil
+}
+
+// exportSetup exports the channel creation transactions, the organization administrators, and a script that creates
+// and joins all of the channels.
+func exportSetup(directory string, network *Network) (*service, error) {
+	o := network.Orderer
+	data := &scriptData{
+		TLS:           o.TLS() != nil,
+		Directory:     containerDirectory,
+		OrdererPort:   o.APIPort(true),
+		Organizations: []*scriptOrganization{},
+		Channels:      []*scriptChannel{},
+	}
+	volumes := []string{
+		"./admins:" + path.Join(containerDirectory, "admins") + ":ro",
+		"./channel-artifacts:" + path.Join(containerDirectory, "channel-artifacts"),
+		"./scripts:" + path.Join(containerDirectory, "scripts") + ":ro",
+	}
+	if data.TLS {
+		err := os.MkdirAll(path.Join(directory, "tls"), 0755)
+		if err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(path.Join(directory, "tls", "ca.pem"), o.TLS().CA().Bytes(), 0644)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, "./tls:"+path.Join(containerDirectory, "tls")+":ro")
+	}
+	for _, organization := range network.Organizations {
+		id := strings.ToLower(organization.Name())
+		err := util.CreateMSPDirectory(path.Join(directory, "admins", id, "msp"), organization.Admin())
+		if err != nil {
+			return nil, err
+		}
+		scriptOrganization := &scriptOrganization{
+			ID:    id,
+			MSPID: organization.MSPID(),
+		}
+		for _, p := range network.Peers {
+			if p.Organization() == organization {
+				if data.TLS {
+					err = ioutil.WriteFile(path.Join(directory, "tls", fmt.Sprintf("%s.pem", id)), p.TLS().CA().Bytes(), 0644)
+					if err != nil {
+						return nil, err
+					}
+				}
+				scriptOrganization.HasPeer = true
+				scriptOrganization.PeerPort = p.APIPort(true)
+				scriptOrganization.AnchorHost = p.APIHostname(false)
+				scriptOrganization.AnchorPort = p.APIPort(true)
+				break
+			}
+		}
+		data.Organizations = append(data.Organizations, scriptOrganization)
+	}
+	err := os.MkdirAll(path.Join(directory, "channel-artifacts"), 0755)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range network.Channels {
+		if len(c.Organizations) == 0 {
+			return nil, fmt.Errorf("Channel %s has no endorsing organizations", c.Name)
+		}
+		opts := []channel.Option{
+			channel.WithCapabilityLevel(c.CapabilityLevel),
+			channel.WithPolicies(c.Policies),
+		}
+		scriptChannel := &scriptChannel{
+			Name:          c.Name,
+			Organizations: []string{},
+		}
+		for _, organization := range c.Organizations {
+			opts = append(opts, channel.AddMSPID(organization.MSPID()))
+			scriptChannel.Organizations = append(scriptChannel.Organizations, strings.ToLower(organization.Name()))
+		}
+		creator := c.Organizations[0]
+		envelope, err := channel.CreateChannelEnvelope(creator.MSPID(), creator.Admin(), c.Name, opts...)
+		if err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(path.Join(directory, "channel-artifacts", fmt.Sprintf("%s.tx", c.Name)), util.MarshalOrPanic(envelope), 0644)
+		if err != nil {
+			return nil, err
+		}
+		data.Channels = append(data.Channels, scriptChannel)
+	}
+	err = os.MkdirAll(path.Join(directory, "scripts"), 0755)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path.Join(directory, "scripts", "create-channels.sh"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	err = scriptTemplate.Execute(file, data)
+	if err != nil {
+		return nil, err
+	}
+	return &service{
+		Image:       fmt.Sprintf("hyperledger/fabric-tools:%s", network.FabricVersion),
+		NetworkMode: "service:orderer",
+		Command:     []string{"bash", path.Join(containerDirectory, "scripts", "create-channels.sh")},
+		Volumes:     volumes,
+	}, nil
+}
+
+func writeTLS(directory string, certificate, privateKey, ca []byte) error {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(directory, "cert.pem"), certificate, 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(directory, "key.pem"), privateKey, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(directory, "ca.pem"), ca, 0644)
+}
+
+func peerID(p *peer.Peer) string {
+	return fmt.Sprintf("%speer", strings.ToLower(p.Organization().Name()))
+}
+
+func publishPort(port int32) string {
+	return fmt.Sprintf("%d:%d", port, port)
+}
+
+var scriptTemplate = template.Must(template.New("create-channels.sh").Parse(`#!/usr/bin/env bash
+#
+# SPDX-License-Identifier: Apache-2.0
+#
+# Generated by Microfab. Creates all of the channels, joins the peers to them, and sets the anchor peers.
+# Channels that the first peer in the channel has already joined are skipped, so this script can be run again.
+#
+set -euo pipefail
+
+ARTIFACTS={{.Directory}}/channel-artifacts
+ORDERER_ARGS=(-o localhost:{{.OrdererPort}}{{if .TLS}} --tls --cafile {{.Directory}}/tls/ca.pem{{end}})
+{{- if .TLS}}
+export CORE_PEER_TLS_ENABLED=true
+{{- end}}
+
+retry() {
+    local attempt
+    for attempt in $(seq 1 30); do
+        if "$@"; then
+            return 0
+        fi
+        sleep 2
+    done
+    return 1
+}
+
+use_organization() {
+    case "$1" in
+{{- range .Organizations}}
+    {{.ID}})
+        export CORE_PEER_LOCALMSPID={{.MSPID}}
+        export CORE_PEER_MSPCONFIGPATH={{$.Directory}}/admins/{{.ID}}/msp
+{{- if .HasPeer}}
+        export CORE_PEER_ADDRESS=localhost:{{.PeerPort}}
+{{- if $.TLS}}
+        export CORE_PEER_TLS_ROOTCERT_FILE={{$.Directory}}/tls/{{.ID}}.pem
+{{- end}}
+        ANCHOR_PEER_HOST={{.AnchorHost}}
+        ANCHOR_PEER_PORT={{.AnchorPort}}
+{{- end}}
+        ;;
+{{- end}}
+    *)
+        echo "Unknown organization $1"
+        exit 1
+        ;;
+    esac
+}
+
+set_anchor_peer() {
+    local channel=$1
+    local work
+    work=$(mktemp -d)
+    retry peer channel fetch config "${work}/config_block.pb" -c "${channel}" "${ORDERER_ARGS[@]}"
+    configtxlator proto_decode --input "${work}/config_block.pb" --type common.Block | jq '.data.data[0].payload.data.config' > "${work}/config.json"
+    jq --arg mspid "${CORE_PEER_LOCALMSPID}" --arg host "${ANCHOR_PEER_HOST}" --argjson port "${ANCHOR_PEER_PORT}" \
+        '.channel_group.groups.Application.groups[$mspid].values.AnchorPeers = {"mod_policy": "Admins", "value": {"anchor_peers": [{"host": $host, "port": $port}]}, "version": "0"}' \
+        "${work}/config.json" > "${work}/modified_config.json"
+    configtxlator proto_encode --input "${work}/config.json" --type common.Config --output "${work}/config.pb"
+    configtxlator proto_encode --input "${work}/modified_config.json" --type common.Config --output "${work}/modified_config.pb"
+    configtxlator compute_update --channel_id "${channel}" --original "${work}/config.pb" --updated "${work}/modified_config.pb" --output "${work}/config_update.pb"
+    configtxlator proto_decode --input "${work}/config_update.pb" --type common.ConfigUpdate > "${work}/config_update.json"
+    jq -n --arg channel "${channel}" --slurpfile update "${work}/config_update.json" \
+        '{"payload": {"header": {"channel_header": {"channel_id": $channel, "type": 2}}, "data": {"config_update": $update[0]}}}' > "${work}/envelope.json"
+    configtxlator proto_encode --input "${work}/envelope.json" --type common.Envelope --output "${work}/envelope.pb"
+    retry peer channel update -f "${work}/envelope.pb" -c "${channel}" "${ORDERER_ARGS[@]}"
+    rm -rf "${work}"
+}
+
+create_channel() {
+    local channel=$1
+    shift
+    local channels
+    use_organization "$1"
+    channels=$(retry peer channel list)
+    if grep -qx "${channel}" <<< "${channels}"; then
+        echo "Channel ${channel} already exists"
+        return
+    fi
+    echo "Creating channel ${channel} ..."
+    retry peer channel create -c "${channel}" -f "${ARTIFACTS}/${channel}.tx" --outputBlock "${ARTIFACTS}/${channel}.block" "${ORDERER_ARGS[@]}"
+    local organization
+    for organization in "$@"; do
+        use_organization "${organization}"
+        echo "Joining channel ${channel} on peer for organization ${organization} ..."
+        retry peer channel join -b "${ARTIFACTS}/${channel}.block"
+        set_anchor_peer "${channel}"
+    done
+    echo "Created channel ${channel}"
+}
+{{range .Channels}}
+create_channel {{.Name}}{{range .Organizations}} {{.}}{{end}}
+{{- end}}
+`))
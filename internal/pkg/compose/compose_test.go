@@ -0,0 +1,224 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package compose_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/compose"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger/fabric-protos-go/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+const coreYAML = `
+peer:
+  gossip: {}
+  tls: {}
+metrics: {}
+operations:
+  tls: {}
+vm: {}
+chaincode: {}
+ledger:
+  state:
+    couchDBConfig: {}
+  snapshots: {}
+`
+
+type composeFile struct {
+	Services map[string]struct {
+		Image       string   `yaml:"image"`
+		NetworkMode string   `yaml:"network_mode"`
+		Environment []string `yaml:"environment"`
+		Ports       []string `yaml:"ports"`
+		Volumes     []string `yaml:"volumes"`
+		DependsOn   []string `yaml:"depends_on"`
+	} `yaml:"services"`
+	Volumes map[string]interface{} `yaml:"volumes"`
+}
+
+var _ = Describe("the compose package", func() {
+
+	var testDirectory string
+	var exportDirectory string
+	var ordererOrganization, org1, org2 *organization.Organization
+	var testOrderer *orderer.Orderer
+	var testPeers []*peer.Peer
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-compose")
+		Expect(err).NotTo(HaveOccurred())
+		exportDirectory = path.Join(testDirectory, "export")
+		configDirectory := path.Join(testDirectory, "config")
+		Expect(os.MkdirAll(configDirectory, 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path.Join(configDirectory, "core.yaml"), []byte(coreYAML), 0644)).To(Succeed())
+		os.Setenv("FABRIC_CFG_PATH", configDirectory)
+		ordererOrganization, err = organization.New("Orderer", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org1, err = organization.New("Org1", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org2, err = organization.New("Org2", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		testOrderer, err = orderer.New(ordererOrganization, path.Join(testDirectory, "orderer"), 8080, 2000, "grpc://orderer-api.127-0-0-1.nip.io:8080", 2001, "http://orderer-operations.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		peer1, err := peer.New(org1, path.Join(testDirectory, "peer-org1"), 8080, 2002, "grpc://org1peer-api.127-0-0-1.nip.io:8080", 2003, "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", 2004, "http://org1peer-operations.127-0-0-1.nip.io:8080", true, 2005, 4000, "http://org1peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		peer2, err := peer.New(org2, path.Join(testDirectory, "peer-org2"), 8080, 2006, "grpc://org2peer-api.127-0-0-1.nip.io:8080", 2007, "grpc://org2peer-chaincode.127-0-0-1.nip.io:8080", 2008, "http://org2peer-operations.127-0-0-1.nip.io:8080", false, 0, 4001, "http://org2peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		testPeers = []*peer.Peer{peer1, peer2}
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("FABRIC_CFG_PATH")
+		os.RemoveAll(testDirectory)
+	})
+
+	network := func() *compose.Network {
+		return &compose.Network{
+			Orderer:       testOrderer,
+			Organizations: []*organization.Organization{org1, org2},
+			Peers:         testPeers,
+			Channels: []compose.Channel{
+				{
+					Name:            "channel1",
+					CapabilityLevel: "V2_5",
+					Organizations:   []*organization.Organization{org1, org2},
+				},
+				{
+					Name:            "channel2",
+					CapabilityLevel: "V2_0",
+					Organizations:   []*organization.Organization{org2},
+				},
+			},
+			FabricVersion: "2.5",
+		}
+	}
+
+	readComposeFile := func() *composeFile {
+		data, err := ioutil.ReadFile(path.Join(exportDirectory, "docker-compose.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+		result := &composeFile{}
+		Expect(yaml.Unmarshal(data, result)).To(Succeed())
+		return result
+	}
+
+	Context("compose.Export()", func() {
+
+		When("called", func() {
+			It("writes a docker-compose file for the network", func() {
+				Expect(compose.Export(exportDirectory, network())).To(Succeed())
+				result := readComposeFile()
+				Expect(result.Services).To(HaveLen(5))
+				Expect(result.Services).To(HaveKey("orderer"))
+				Expect(result.Services).To(HaveKey("org1peer"))
+				Expect(result.Services).To(HaveKey("org2peer"))
+				Expect(result.Services).To(HaveKey("couchdb-org1peer"))
+				Expect(result.Services).To(HaveKey("setup"))
+				Expect(result.Volumes).To(HaveKey("orderer-data"))
+				Expect(result.Volumes).To(HaveKey("org1peer-data"))
+				Expect(result.Volumes).To(HaveKey("couchdb-org1peer-data"))
+				ordererService := result.Services["orderer"]
+				Expect(ordererService.Image).To(Equal("hyperledger/fabric-orderer:2.5"))
+				Expect(ordererService.Ports).To(ContainElements("2000:2000", "2001:2001", "2002:2002", "2006:2006"))
+				Expect(ordererService.Environment).To(ContainElement("ORDERER_GENERAL_LOCALMSPDIR=/var/hyperledger/microfab/orderer/msp"))
+				Expect(ordererService.Environment).To(ContainElement("ORDERER_GENERAL_BOOTSTRAPFILE=/var/hyperledger/microfab/orderer/config/genesisblock"))
+				peerService := result.Services["org1peer"]
+				Expect(peerService.Image).To(Equal("hyperledger/fabric-peer:2.5"))
+				Expect(peerService.NetworkMode).To(Equal("service:orderer"))
+				Expect(peerService.DependsOn).To(ConsistOf("orderer", "couchdb-org1peer"))
+				Expect(result.Services["setup"].DependsOn).To(ConsistOf("org1peer", "org2peer"))
+			})
+
+			It("writes the configuration and crypto material for the orderer and peers", func() {
+				Expect(compose.Export(exportDirectory, network())).To(Succeed())
+				Expect(path.Join(exportDirectory, "orderer", "config", "genesisblock")).To(BeAnExistingFile())
+				Expect(path.Join(exportDirectory, "orderer", "msp", "signcerts", "cert.pem")).To(BeAnExistingFile())
+				Expect(path.Join(exportDirectory, "orderer", "tls")).NotTo(BeADirectory())
+				Expect(path.Join(exportDirectory, "org1peer", "msp", "keystore", "key.pem")).To(BeAnExistingFile())
+				Expect(path.Join(exportDirectory, "admins", "org2", "msp", "signcerts", "cert.pem")).To(BeAnExistingFile())
+				Expect(path.Join(exportDirectory, "couchdb", "org1peer.ini")).To(BeAnExistingFile())
+				data, err := ioutil.ReadFile(path.Join(exportDirectory, "org1peer", "config", "core.yaml"))
+				Expect(err).NotTo(HaveOccurred())
+				config := map[string]interface{}{}
+				Expect(yaml.Unmarshal(data, config)).To(Succeed())
+				peerConfig := config["peer"].(map[interface{}]interface{})
+				Expect(peerConfig["mspConfigPath"]).To(Equal("/var/hyperledger/microfab/org1peer/msp"))
+				Expect(peerConfig["fileSystemPath"]).To(Equal("/var/hyperledger/microfab/org1peer/data"))
+				couchDBConfig := config["ledger"].(map[interface{}]interface{})["state"].(map[interface{}]interface{})["couchDBConfig"].(map[interface{}]interface{})
+				Expect(couchDBConfig["couchDBAddress"]).To(Equal("localhost:2005"))
+				builders := config["chaincode"].(map[interface{}]interface{})["externalBuilders"].([]interface{})
+				Expect(builders).To(HaveLen(1))
+			})
+
+			It("writes the channel creation transactions and script", func() {
+				Expect(compose.Export(exportDirectory, network())).To(Succeed())
+				data, err := ioutil.ReadFile(path.Join(exportDirectory, "channel-artifacts", "channel1.tx"))
+				Expect(err).NotTo(HaveOccurred())
+				envelope := &common.Envelope{}
+				Expect(proto.Unmarshal(data, envelope)).To(Succeed())
+				payload := &common.Payload{}
+				Expect(proto.Unmarshal(envelope.Payload, payload)).To(Succeed())
+				configUpdateEnvelope := &common.ConfigUpdateEnvelope{}
+				Expect(proto.Unmarshal(payload.Data, configUpdateEnvelope)).To(Succeed())
+				configUpdate := &common.ConfigUpdate{}
+				Expect(proto.Unmarshal(configUpdateEnvelope.ConfigUpdate, configUpdate)).To(Succeed())
+				Expect(configUpdate.ChannelId).To(Equal("channel1"))
+				Expect(configUpdate.WriteSet.Groups["Application"].Groups).To(HaveKey("Org1MSP"))
+				Expect(configUpdate.WriteSet.Groups["Application"].Groups).To(HaveKey("Org2MSP"))
+				Expect(path.Join(exportDirectory, "channel-artifacts", "channel2.tx")).To(BeAnExistingFile())
+				script, err := ioutil.ReadFile(path.Join(exportDirectory, "scripts", "create-channels.sh"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(script)).To(ContainSubstring("create_channel channel1 org1 org2\n"))
+				Expect(string(script)).To(ContainSubstring("create_channel channel2 org2\n"))
+				Expect(string(script)).To(ContainSubstring("export CORE_PEER_ADDRESS=localhost:2002"))
+				Expect(string(script)).To(ContainSubstring("ANCHOR_PEER_HOST=org2peer-api.127-0-0-1.nip.io"))
+				Expect(string(script)).NotTo(ContainSubstring("CORE_PEER_TLS_ENABLED"))
+			})
+		})
+
+		When("TLS is enabled", func() {
+			It("writes the TLS material and enables TLS in the script", func() {
+				ca, err := identity.New("*.127-0-0-1.nip.io", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				tls, err := identity.New("*.127-0-0-1.nip.io", identity.UsingSigner(ca))
+				Expect(err).NotTo(HaveOccurred())
+				testOrderer.EnableTLS(tls)
+				for _, p := range testPeers {
+					p.EnableTLS(tls)
+				}
+				Expect(compose.Export(exportDirectory, network())).To(Succeed())
+				Expect(path.Join(exportDirectory, "orderer", "tls", "key.pem")).To(BeAnExistingFile())
+				Expect(path.Join(exportDirectory, "org2peer", "tls", "cert.pem")).To(BeAnExistingFile())
+				Expect(path.Join(exportDirectory, "tls", "ca.pem")).To(BeAnExistingFile())
+				result := readComposeFile()
+				Expect(result.Services["orderer"].Environment).To(ContainElement("ORDERER_GENERAL_TLS_CERTIFICATE=/var/hyperledger/microfab/orderer/tls/cert.pem"))
+				script, err := ioutil.ReadFile(path.Join(exportDirectory, "scripts", "create-channels.sh"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(script)).To(ContainSubstring("export CORE_PEER_TLS_ENABLED=true"))
+				Expect(string(script)).To(ContainSubstring("--tls --cafile /var/hyperledger/microfab/tls/ca.pem"))
+			})
+		})
+
+		When("called without a Fabric version", func() {
+			It("returns an error", func() {
+				n := network()
+				n.FabricVersion = ""
+				Expect(compose.Export(exportDirectory, n)).To(MatchError("Fabric version must be specified"))
+			})
+		})
+
+	})
+
+})
@@ -0,0 +1,306 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package configtx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/privatekey"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/policydsl"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Policy represents a policy in a configtx.yaml file.
+type Policy struct {
+	Type string `yaml:"Type"`
+	Rule string `yaml:"Rule"`
+}
+
+// Organization represents an organization in a configtx.yaml file.
+type Organization struct {
+	Name     string            `yaml:"Name"`
+	ID       string            `yaml:"ID"`
+	MSPDir   string            `yaml:"MSPDir"`
+	Policies map[string]Policy `yaml:"Policies"`
+}
+
+// Application represents the application section of a profile in a configtx.yaml file.
+type Application struct {
+	Organizations []*Organization   `yaml:"Organizations"`
+	Policies      map[string]Policy `yaml:"Policies"`
+	Capabilities  map[string]bool   `yaml:"Capabilities"`
+}
+
+// Orderer represents the orderer section of a profile in a configtx.yaml file.
+type Orderer struct {
+	Organizations []*Organization `yaml:"Organizations"`
+}
+
+// Consortium represents a consortium in a profile in a configtx.yaml file.
+type Consortium struct {
+	Organizations []*Organization `yaml:"Organizations"`
+}
+
+// Profile represents a profile in a configtx.yaml file.
+type Profile struct {
+	Consortium  string                 `yaml:"Consortium"`
+	Orderer     *Orderer               `yaml:"Orderer"`
+	Application *Application           `yaml:"Application"`
+	Consortiums map[string]*Consortium `yaml:"Consortiums"`
+}
+
+// ConfigTx represents a loaded configtx.yaml file.
+type ConfigTx struct {
+	Profiles  map[string]*Profile `yaml:"Profiles"`
+	directory string
+}
+
+// Material represents the crypto material for an organization, loaded from a crypto-config directory.
+type Material struct {
+	CA      *identity.Identity
+	Admin   *identity.Identity
+	Node    *identity.Identity
+	TLSCA   *identity.Identity
+	NodeTLS *identity.Identity
+}
+
+// Load loads the specified configtx.yaml file.
+func Load(file string) (*ConfigTx, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	result := &ConfigTx{}
+	err = yaml.Unmarshal(data, result)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse %s", file)
+	}
+	result.directory = path.Dir(file)
+	return result, nil
+}
+
+// Profile returns the specified profile.
+func (c *ConfigTx) Profile(name string) (*Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("Profile %s not found", name)
+	}
+	return profile, nil
+}
+
+// OrderingOrganizations returns the organizations in the orderer section of the profile.
+func (p *Profile) OrderingOrganizations() []*Organization {
+	if p.Orderer == nil {
+		return nil
+	}
+	return p.Orderer.Organizations
+}
+
+// EndorsingOrganizations returns the organizations in the application section of the profile. If the profile has no
+// application section, the organizations in all of the consortiums are returned instead.
+func (p *Profile) EndorsingOrganizations() []*Organization {
+	if p.Application != nil {
+		return p.Application.Organizations
+	}
+	names := []string{}
+	for name := range p.Consortiums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []*Organization{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		for _, organization := range p.Consortiums[name].Organizations {
+			if !seen[organization.Name] {
+				seen[organization.Name] = true
+				result = append(result, organization)
+			}
+		}
+	}
+	return result
+}
+
+// CapabilityLevel returns the highest application capability level enabled in the profile, or an empty string if
+// the profile does not enable any.
+func (p *Profile) CapabilityLevel() string {
+	result := ""
+	if p.Application == nil {
+		return result
+	}
+	for capability, enabled := range p.Application.Capabilities {
+		if enabled && capability > result {
+			result = capability
+		}
+	}
+	return result
+}
+
+// ApplicationPolicies returns the policies in the application section of the profile.
+func (p *Profile) ApplicationPolicies() (map[string]*common.Policy, error) {
+	if p.Application == nil {
+		return nil, nil
+	}
+	return BuildPolicies(p.Application.Policies)
+}
+
+// BuildPolicies converts the specified configtx.yaml policies into Fabric policies.
+func BuildPolicies(policies map[string]Policy) (map[string]*common.Policy, error) {
+	result := map[string]*common.Policy{}
+	for name, policy := range policies {
+		temp, err := policy.Build()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to build policy %s", name)
+		}
+		result[name] = temp
+	}
+	return result, nil
+}
+
+// Build converts the configtx.yaml policy into a Fabric policy.
+func (p Policy) Build() (*common.Policy, error) {
+	switch p.Type {
+	case "Signature":
+		envelope, err := policydsl.FromString(p.Rule)
+		if err != nil {
+			return nil, err
+		}
+		return &common.Policy{
+			Type:  int32(common.Policy_SIGNATURE),
+			Value: util.MarshalOrPanic(envelope),
+		}, nil
+	case "ImplicitMeta":
+		parts := strings.Fields(p.Rule)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid implicit meta policy rule %s, must be <ANY|ALL|MAJORITY> <SubPolicy>", p.Rule)
+		}
+		rule, ok := common.ImplicitMetaPolicy_Rule_value[strings.ToUpper(parts[0])]
+		if !ok {
+			return nil, fmt.Errorf("Invalid implicit meta policy rule %s, must be <ANY|ALL|MAJORITY> <SubPolicy>", p.Rule)
+		}
+		return &common.Policy{
+			Type: int32(common.Policy_IMPLICIT_META),
+			Value: util.MarshalOrPanic(&common.ImplicitMetaPolicy{
+				Rule:      common.ImplicitMetaPolicy_Rule(rule),
+				SubPolicy: parts[1],
+			}),
+		}, nil
+	default:
+		return nil, fmt.Errorf("Invalid policy type %s, must be Signature or ImplicitMeta", p.Type)
+	}
+}
+
+// Directory returns the crypto-config directory for the organization, which is the parent of its MSP directory.
+// Relative MSP directories are resolved against the directory containing the configtx.yaml file. If a crypto-config
+// directory is specified, the organization directory with the same name is used from there instead.
+func (c *ConfigTx) Directory(organization *Organization, cryptoConfig string, kind string) string {
+	mspDir := organization.MSPDir
+	if !path.IsAbs(mspDir) {
+		mspDir = path.Join(c.directory, mspDir)
+	}
+	directory := path.Dir(mspDir)
+	if cryptoConfig != "" {
+		directory = path.Join(cryptoConfig, kind, path.Base(directory))
+	}
+	return directory
+}
+
+// LoadMaterial loads the CA, admin and node identities for the organization from the crypto-config directory. The
+// kind is either "peerOrganizations" or "ordererOrganizations". The admin and node identities are optional, and are
+// nil if they cannot be found. The TLS CA and node TLS identities are also optional, and are only loaded if both the
+// certificate and private key of the TLS CA can be found.
+func (c *ConfigTx) LoadMaterial(organization *Organization, cryptoConfig string, kind string) (*Material, error) {
+	directory := c.Directory(organization, cryptoConfig, kind)
+	ca, err := loadIdentity(path.Join(directory, "ca"), "*-cert.pem", "*_sk", nil)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load CA for organization %s from %s", organization.Name, directory)
+	}
+	result := &Material{CA: ca}
+	admins, _ := filepath.Glob(path.Join(directory, "users", "Admin@*", "msp"))
+	if len(admins) > 0 {
+		result.Admin, err = loadMSP(admins[0], ca)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to load admin for organization %s", organization.Name)
+		}
+	}
+	nodes := "peers"
+	if kind == "ordererOrganizations" {
+		nodes = "orderers"
+	}
+	matches, _ := filepath.Glob(path.Join(directory, nodes, "*", "msp"))
+	sort.Strings(matches)
+	if len(matches) > 0 {
+		result.Node, err = loadMSP(matches[0], ca)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to load node for organization %s", organization.Name)
+		}
+	}
+	// Without the private key of the TLS CA, Microfab cannot issue TLS certificates, so the TLS material is ignored.
+	result.TLSCA, err = loadIdentity(path.Join(directory, "tlsca"), "*-cert.pem", "*_sk", nil)
+	if err != nil {
+		result.TLSCA = nil
+		return result, nil
+	}
+	if len(matches) > 0 {
+		tlsDirectory := path.Join(path.Dir(matches[0]), "tls")
+		if _, err := os.Stat(path.Join(tlsDirectory, "server.crt")); err == nil {
+			result.NodeTLS, err = loadIdentity(tlsDirectory, "server.crt", "server.key", result.TLSCA)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to load node TLS for organization %s", organization.Name)
+			}
+		}
+	}
+	return result, nil
+}
+
+func loadMSP(directory string, ca *identity.Identity) (*identity.Identity, error) {
+	return loadIdentity(directory, path.Join("signcerts", "*.pem"), path.Join("keystore", "*"), ca)
+}
+
+func loadIdentity(directory, certificatePattern, privateKeyPattern string, ca *identity.Identity) (*identity.Identity, error) {
+	certificateData, err := readFirst(directory, certificatePattern)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := certificate.FromBytes(certificateData)
+	if err != nil {
+		return nil, err
+	}
+	privateKeyData, err := readFirst(directory, privateKeyPattern)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := privatekey.FromBytes(privateKeyData)
+	if err != nil {
+		return nil, err
+	}
+	var caCertificate *certificate.Certificate
+	if ca != nil {
+		caCertificate = ca.Certificate()
+	}
+	return identity.FromParts(cert.Certificate().Subject.CommonName, cert, pk, caCertificate)
+}
+
+func readFirst(directory, pattern string) ([]byte, error) {
+	matches, err := filepath.Glob(path.Join(directory, pattern))
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("No files matching %s found in %s", pattern, directory)
+	}
+	sort.Strings(matches)
+	return ioutil.ReadFile(matches[0])
+}
@@ -0,0 +1,17 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package configtx_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestConfigTx(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ConfigTx Suite")
+}
@@ -0,0 +1,288 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package configtx_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/configtx"
+	"github.com/hyperledger-labs/microfab/internal/pkg/cryptoconfig"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger/fabric-protos-go/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const configTxYAML = `
+Organizations:
+  - &OrdererOrg
+    Name: OrdererOrg
+    ID: OrdererMSP
+    MSPDir: crypto-config/ordererOrganizations/orderer.example.com/msp
+    Policies:
+      Readers:
+        Type: Signature
+        Rule: "OR('OrdererMSP.member')"
+  - &Org1
+    Name: Org1MSP
+    ID: Org1MSP
+    MSPDir: crypto-config/peerOrganizations/org1.example.com/msp
+    Policies:
+      Admins:
+        Type: Signature
+        Rule: "OR('Org1MSP.admin')"
+  - &Org2
+    Name: Org2MSP
+    ID: Org2MSP
+    MSPDir: crypto-config/peerOrganizations/org2.example.com/msp
+
+Capabilities:
+  Application: &ApplicationCapabilities
+    V2_0: true
+    V2_5: true
+
+Application: &ApplicationDefaults
+  Policies:
+    Readers:
+      Type: ImplicitMeta
+      Rule: "ANY Readers"
+    Admins:
+      Type: ImplicitMeta
+      Rule: "MAJORITY Admins"
+  Capabilities:
+    <<: *ApplicationCapabilities
+
+Profiles:
+  TwoOrgsApplicationGenesis:
+    Orderer:
+      Organizations:
+        - *OrdererOrg
+    Application:
+      <<: *ApplicationDefaults
+      Organizations:
+        - *Org1
+        - *Org2
+  TwoOrgsOrdererGenesis:
+    Orderer:
+      Organizations:
+        - *OrdererOrg
+    Consortiums:
+      SampleConsortium:
+        Organizations:
+          - *Org2
+          - *Org1
+  BadPolicy:
+    Application:
+      Policies:
+        Readers:
+          Type: Magic
+          Rule: "ANY Readers"
+`
+
+var _ = Describe("the configtx package", func() {
+
+	var testDirectory string
+	var ordererOrganization, org1 *organization.Organization
+	var testOrderer *orderer.Orderer
+	var testPeer *peer.Peer
+	var configTx *configtx.ConfigTx
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-configtx")
+		Expect(err).NotTo(HaveOccurred())
+		ordererOrganization, err = organization.New("Orderer", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org1, err = organization.New("Org1", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		testOrderer, err = orderer.New(ordererOrganization, path.Join(testDirectory, "orderer"), 8080, 2000, "grpc://orderer-api.127-0-0-1.nip.io:8080", 2001, "http://orderer-operations.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		testPeer, err = peer.New(org1, path.Join(testDirectory, "peer-org1"), 8080, 2002, "grpc://org1peer-api.127-0-0-1.nip.io:8080", 2003, "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", 2004, "http://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, 4000, "http://org1peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		err = cryptoconfig.Export(path.Join(testDirectory, "crypto-config"), &cryptoconfig.Network{
+			Domain:        "example.com",
+			Orderer:       testOrderer,
+			Organizations: []*organization.Organization{org1},
+			Peers:         []*peer.Peer{testPeer},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		configTxFile := path.Join(testDirectory, "configtx.yaml")
+		Expect(ioutil.WriteFile(configTxFile, []byte(configTxYAML), 0644)).To(Succeed())
+		configTx, err = configtx.Load(configTxFile)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDirectory)
+	})
+
+	Context("configtx.Load()", func() {
+
+		When("the file does not exist", func() {
+			It("returns an error", func() {
+				_, err := configtx.Load(path.Join(testDirectory, "missing.yaml"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+	})
+
+	Context("configtx.ConfigTx.Profile()", func() {
+
+		When("the profile does not exist", func() {
+			It("returns an error", func() {
+				_, err := configTx.Profile("Missing")
+				Expect(err).To(MatchError("Profile Missing not found"))
+			})
+		})
+
+		When("the profile has an application section", func() {
+			It("returns the organizations, capability level and policies", func() {
+				profile, err := configTx.Profile("TwoOrgsApplicationGenesis")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(profile.OrderingOrganizations()).To(HaveLen(1))
+				Expect(profile.OrderingOrganizations()[0].ID).To(Equal("OrdererMSP"))
+				endorsingOrganizations := profile.EndorsingOrganizations()
+				Expect(endorsingOrganizations).To(HaveLen(2))
+				Expect(endorsingOrganizations[0].Name).To(Equal("Org1MSP"))
+				Expect(endorsingOrganizations[1].Name).To(Equal("Org2MSP"))
+				Expect(profile.CapabilityLevel()).To(Equal("V2_5"))
+				policies, err := profile.ApplicationPolicies()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(policies).To(HaveLen(2))
+				Expect(policies["Admins"].Type).To(Equal(int32(common.Policy_IMPLICIT_META)))
+				implicitMetaPolicy := &common.ImplicitMetaPolicy{}
+				Expect(proto.Unmarshal(policies["Admins"].Value, implicitMetaPolicy)).To(Succeed())
+				Expect(implicitMetaPolicy.Rule).To(Equal(common.ImplicitMetaPolicy_MAJORITY))
+				Expect(implicitMetaPolicy.SubPolicy).To(Equal("Admins"))
+			})
+		})
+
+		When("the profile has a consortium", func() {
+			It("returns the consortium organizations as the endorsing organizations", func() {
+				profile, err := configTx.Profile("TwoOrgsOrdererGenesis")
+				Expect(err).NotTo(HaveOccurred())
+				endorsingOrganizations := profile.EndorsingOrganizations()
+				Expect(endorsingOrganizations).To(HaveLen(2))
+				Expect(endorsingOrganizations[0].Name).To(Equal("Org2MSP"))
+				Expect(profile.CapabilityLevel()).To(Equal(""))
+			})
+		})
+
+		When("the profile has an invalid policy", func() {
+			It("returns an error", func() {
+				profile, err := configTx.Profile("BadPolicy")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = profile.ApplicationPolicies()
+				Expect(err).To(MatchError("failed to build policy Readers: Invalid policy type Magic, must be Signature or ImplicitMeta"))
+			})
+		})
+
+	})
+
+	Context("configtx.Policy.Build()", func() {
+
+		When("called with a signature policy", func() {
+			It("returns a signature policy", func() {
+				policy, err := configtx.Policy{Type: "Signature", Rule: "OR('Org1MSP.admin')"}.Build()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(policy.Type).To(Equal(int32(common.Policy_SIGNATURE)))
+				envelope := &common.SignaturePolicyEnvelope{}
+				Expect(proto.Unmarshal(policy.Value, envelope)).To(Succeed())
+				Expect(envelope.Identities).To(HaveLen(1))
+			})
+		})
+
+		When("called with an invalid implicit meta policy", func() {
+			It("returns an error", func() {
+				_, err := configtx.Policy{Type: "ImplicitMeta", Rule: "SOME Readers"}.Build()
+				Expect(err).To(MatchError("Invalid implicit meta policy rule SOME Readers, must be <ANY|ALL|MAJORITY> <SubPolicy>"))
+			})
+		})
+
+	})
+
+	Context("configtx.ConfigTx.LoadMaterial()", func() {
+
+		When("called for a peer organization", func() {
+			It("loads the CA, admin and peer identities", func() {
+				profile, err := configTx.Profile("TwoOrgsApplicationGenesis")
+				Expect(err).NotTo(HaveOccurred())
+				material, err := configTx.LoadMaterial(profile.EndorsingOrganizations()[0], "", "peerOrganizations")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(material.CA.Certificate().Bytes()).To(Equal(org1.CA().Certificate().Bytes()))
+				Expect(material.CA.PrivateKey().Bytes()).To(Equal(org1.CA().PrivateKey().Bytes()))
+				Expect(material.Admin.Certificate().Bytes()).To(Equal(org1.Admin().Certificate().Bytes()))
+				Expect(material.Admin.PrivateKey().Bytes()).To(Equal(org1.Admin().PrivateKey().Bytes()))
+				Expect(material.Admin.CA().Bytes()).To(Equal(org1.CA().Certificate().Bytes()))
+				Expect(material.Node.Certificate().Bytes()).To(Equal(testPeer.Identity().Certificate().Bytes()))
+				Expect(material.TLSCA).To(BeNil())
+				Expect(material.NodeTLS).To(BeNil())
+			})
+		})
+
+		When("called for a peer organization with a TLS CA", func() {
+			It("loads the TLS CA and peer TLS identities", func() {
+				tlsCA, err := identity.New("Org1 TLS CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				tlsOrg1, err := organization.New("Org1", nil, tlsCA)
+				Expect(err).NotTo(HaveOccurred())
+				tlsPeer, err := peer.New(tlsOrg1, path.Join(testDirectory, "tls-peer-org1"), 8080, 2002, "grpcs://org1peer-api.127-0-0-1.nip.io:8080", 2003, "grpcs://org1peer-chaincode.127-0-0-1.nip.io:8080", 2004, "https://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, 4000, "grpcs://org1peer-gossip.127-0-0-1.nip.io:8080")
+				Expect(err).NotTo(HaveOccurred())
+				peerTLS, err := identity.New("Org1 Peer TLS", identity.WithHostnames("peer0.org1.example.com"), identity.UsingSigner(tlsCA))
+				Expect(err).NotTo(HaveOccurred())
+				tlsPeer.EnableTLS(peerTLS)
+				cryptoConfig := path.Join(testDirectory, "tls-crypto-config")
+				err = cryptoconfig.Export(cryptoConfig, &cryptoconfig.Network{
+					Domain:        "example.com",
+					Organizations: []*organization.Organization{tlsOrg1},
+					Peers:         []*peer.Peer{tlsPeer},
+					TLS:           peerTLS,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				profile, err := configTx.Profile("TwoOrgsApplicationGenesis")
+				Expect(err).NotTo(HaveOccurred())
+				material, err := configTx.LoadMaterial(profile.EndorsingOrganizations()[0], cryptoConfig, "peerOrganizations")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(material.TLSCA.Certificate().Bytes()).To(Equal(tlsCA.Certificate().Bytes()))
+				Expect(material.TLSCA.PrivateKey().Bytes()).To(Equal(tlsCA.PrivateKey().Bytes()))
+				Expect(material.NodeTLS.Certificate().Bytes()).To(Equal(peerTLS.Certificate().Bytes()))
+				Expect(material.NodeTLS.PrivateKey().Bytes()).To(Equal(peerTLS.PrivateKey().Bytes()))
+				Expect(material.NodeTLS.CA().Bytes()).To(Equal(tlsCA.Certificate().Bytes()))
+			})
+		})
+
+		When("called for an ordering organization with a separate crypto-config directory", func() {
+			It("loads the CA, admin and orderer identities", func() {
+				cryptoConfig := path.Join(testDirectory, "moved")
+				Expect(os.Rename(path.Join(testDirectory, "crypto-config"), cryptoConfig)).To(Succeed())
+				profile, err := configTx.Profile("TwoOrgsApplicationGenesis")
+				Expect(err).NotTo(HaveOccurred())
+				material, err := configTx.LoadMaterial(profile.OrderingOrganizations()[0], cryptoConfig, "ordererOrganizations")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(material.CA.Certificate().Bytes()).To(Equal(ordererOrganization.CA().Certificate().Bytes()))
+				Expect(material.Node.Certificate().Bytes()).To(Equal(testOrderer.Identity().Certificate().Bytes()))
+			})
+		})
+
+		When("the CA cannot be found", func() {
+			It("returns an error", func() {
+				profile, err := configTx.Profile("TwoOrgsApplicationGenesis")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = configTx.LoadMaterial(profile.EndorsingOrganizations()[1], "", "peerOrganizations")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to load CA for organization Org2MSP"))
+			})
+		})
+
+	})
+
+})
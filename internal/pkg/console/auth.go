@@ -0,0 +1,228 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+)
+
+// ClientCertificateHeader is the header that the proxy uses to pass the TLS client certificate presented to it, as
+// base64 encoded DER, on to the console.
+const ClientCertificateHeader = "X-Microfab-Client-Certificate"
+
+// ProxySecretHeader is the header that the proxy uses to prove to the console that it passed on the TLS client
+// certificate, as the proxy has verified that the client holds the private key for the certificate.
+const ProxySecretHeader = "X-Microfab-Proxy-Secret"
+
+// principal represents an authenticated client of the console, and the organizations that it can access. A principal
+// without any organizations can access every organization.
+type principal struct {
+	organizations map[string]bool
+}
+
+type principalKey struct{}
+
+// unauthenticatedPaths are the paths that clients can request without authenticating.
+var unauthenticatedPaths = map[string]bool{
+	"/ak/api/v1/health":    true,
+	"/ak/api/v1/ready":     true,
+	"/api/v2/openapi.json": true,
+}
+
+type tokenCredential struct {
+	token     string
+	principal *principal
+}
+
+type userCredential struct {
+	username  string
+	password  string
+	principal *principal
+}
+
+func newPrincipal(organizations []string) *principal {
+	if len(organizations) == 0 {
+		return &principal{}
+	}
+	result := &principal{organizations: map[string]bool{}}
+	for _, organization := range organizations {
+		result.organizations[strings.ToLower(organization)] = true
+	}
+	return result
+}
+
+// AddToken allows clients that present the specified bearer token to use the console. The clients can only access the
+// specified organizations, or every organization if none are specified.
+func (c *Console) AddToken(token string, organizations ...string) {
+	c.tokens = append(c.tokens, &tokenCredential{token, newPrincipal(organizations)})
+}
+
+// AddUser allows clients that present the specified username and password using basic authentication to use the
+// console. The clients can only access the specified organizations, or every organization if none are specified.
+func (c *Console) AddUser(username, password string, organizations ...string) {
+	c.users = append(c.users, &userCredential{username, password, newPrincipal(organizations)})
+}
+
+// EnableClientCertificates allows clients that present a TLS client certificate to use the console. Clients with a
+// certificate issued by the CA of the specified TLS identity can access every organization, and clients with a
+// certificate issued by the TLS CA of an organization can only access that organization.
+func (c *Console) EnableClientCertificates(tls *identity.Identity) {
+	c.clientCA = tls.CA()
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	c.proxySecret = hex.EncodeToString(secret)
+	if c.httpServer.TLSConfig != nil {
+		c.httpServer.TLSConfig.ClientAuth = gotls.RequestClientCert
+	}
+}
+
+// ClientCertificates returns true if clients can use TLS client certificates to authenticate with the console.
+func (c *Console) ClientCertificates() bool {
+	return c.clientCA != nil
+}
+
+// ProxySecret returns the secret that the proxy must send in the proxy secret header when it passes on a TLS client
+// certificate. The secret is generated every time Microfab starts, and is never sent to clients.
+func (c *Console) ProxySecret() string {
+	return c.proxySecret
+}
+
+func (c *Console) authenticationEnabled() bool {
+	return len(c.tokens) > 0 || len(c.users) > 0 || c.clientCA != nil
+}
+
+// authenticate rejects any request that is not from an authenticated client when authentication is enabled, except
+// for requests for the health and readiness of the console, and for the OpenAPI document.
+func (c *Console) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !c.authenticationEnabled() || unauthenticatedPaths[req.URL.Path] {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		p := c.findPrincipal(req)
+		if p == nil {
+			if len(c.users) > 0 {
+				rw.Header().Add("WWW-Authenticate", `Basic realm="microfab"`)
+			}
+			http.Error(rw, "Authentication required", 401)
+			return
+		}
+		next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), principalKey{}, p)))
+	})
+}
+
+func (c *Console) findPrincipal(req *http.Request) *principal {
+	if authorization := req.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		for _, credential := range c.tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(credential.token)) == 1 {
+				return credential.principal
+			}
+		}
+	}
+	if username, password, ok := req.BasicAuth(); ok {
+		for _, credential := range c.users {
+			usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(credential.username)) == 1
+			passwordMatches := subtle.ConstantTimeCompare([]byte(password), []byte(credential.password)) == 1
+			if usernameMatches && passwordMatches {
+				return credential.principal
+			}
+		}
+	}
+	if c.clientCA != nil {
+		if cert := c.clientCertificate(req); cert != nil {
+			return c.verifyClientCertificate(cert)
+		}
+	}
+	return nil
+}
+
+// clientCertificate returns the TLS client certificate presented to the console, or to the proxy in front of the
+// console. The certificate passed on by the proxy is only trusted if the request also contains the proxy secret, as
+// anything that can connect to the console could otherwise send the public certificate of another client.
+func (c *Console) clientCertificate(req *http.Request) *x509.Certificate {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return req.TLS.PeerCertificates[0]
+	}
+	header := req.Header.Get(ClientCertificateHeader)
+	if header == "" {
+		return nil
+	}
+	secret := req.Header.Get(ProxySecretHeader)
+	if c.proxySecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(c.proxySecret)) != 1 {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(header)
+	if err != nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(data)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
+func (c *Console) verifyClientCertificate(cert *x509.Certificate) *principal {
+	if verifyCertificate(cert, c.clientCA) {
+		return &principal{}
+	}
+	for _, organization := range c.organizations {
+		tlsCA := organization.TLSCA()
+		if tlsCA != nil && verifyCertificate(cert, tlsCA.Certificate()) {
+			return newPrincipal([]string{organization.Name()})
+		}
+	}
+	return nil
+}
+
+func verifyCertificate(cert *x509.Certificate, ca *certificate.Certificate) bool {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate())
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err == nil
+}
+
+// canAccess returns true if the client that sent the request can access the specified organization.
+func canAccess(req *http.Request, organization string) bool {
+	p, ok := req.Context().Value(principalKey{}).(*principal)
+	if !ok || p.organizations == nil {
+		return true
+	}
+	return p.organizations[strings.ToLower(organization)]
+}
+
+// checkAccess writes a forbidden error, and returns false, if the client that sent the request cannot access the
+// specified organization.
+func checkAccess(rw http.ResponseWriter, req *http.Request, organization string) bool {
+	if canAccess(req, organization) {
+		return true
+	}
+	http.Error(rw, fmt.Sprintf("Access to organization %s is not allowed", organization), 403)
+	return false
+}
+
+// canAccessAll returns true if the client that sent the request can access every organization.
+func canAccessAll(req *http.Request) bool {
+	p, ok := req.Context().Value(principalKey{}).(*principal)
+	return !ok || p.organizations == nil
+}
@@ -0,0 +1,127 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/loadgen"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+)
+
+// postBenchmark runs a benchmark, and returns the report once it has completed. The benchmark stops starting new
+// transactions if the client disconnects.
+func (c *Console) postBenchmark(rw http.ResponseWriter, req *http.Request) {
+	options := &loadgen.Options{
+		Transactions: 100,
+		Concurrency:  10,
+	}
+	if err := json.NewDecoder(req.Body).Decode(options); err != nil {
+		http.Error(rw, err.Error(), 400)
+		return
+	} else if options.Transactions > loadgen.MaxTransactions {
+		http.Error(rw, fmt.Sprintf("Number of transactions must be at most %d", loadgen.MaxTransactions), 400)
+		return
+	} else if options.Concurrency > loadgen.MaxConcurrency {
+		http.Error(rw, fmt.Sprintf("Concurrency must be at most %d", loadgen.MaxConcurrency), 400)
+		return
+	}
+	var ch *channel
+	for _, temp := range c.channels {
+		if temp.name == options.Channel {
+			ch = temp
+			break
+		}
+	}
+	if ch == nil {
+		http.Error(rw, fmt.Sprintf("Channel %s not found", options.Channel), 404)
+		return
+	}
+	if len(options.Organizations) == 0 && !canAccessAll(req) {
+		// Clients that can only access some organizations only submit transactions as those organizations.
+		for _, organization := range ch.organizations {
+			if canAccess(req, organization.Name()) {
+				options.Organizations = append(options.Organizations, organization.Name())
+			}
+		}
+		if len(options.Organizations) == 0 {
+			http.Error(rw, fmt.Sprintf("Access to the organizations in channel %s is not allowed", ch.name), 403)
+			return
+		}
+	}
+	for _, name := range options.Organizations {
+		if !checkAccess(rw, req, name) {
+			return
+		}
+	}
+	transactors, closeAll, err := c.connectBenchmark(ch, options.Organizations)
+	defer closeAll()
+	if err != nil {
+		http.Error(rw, err.Error(), 400)
+		return
+	}
+	report, err := loadgen.Run(req.Context(), transactors, options)
+	if req.Context().Err() != nil {
+		// The client has disconnected, so there is nobody to send the report to.
+		return
+	} else if err != nil {
+		http.Error(rw, err.Error(), 400)
+		return
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(report)
+}
+
+// connectBenchmark connects to the peer of each organization in the channel, or only the specified organizations
+// if any are specified, as the admin for that organization. Each organization endorses its own transactions.
+func (c *Console) connectBenchmark(ch *channel, organizations []string) ([]loadgen.Transactor, func(), error) {
+	transactors := []loadgen.Transactor{}
+	closers := []func() error{}
+	closeAll := func() {
+		for _, closer := range closers {
+			closer()
+		}
+	}
+	selected, seen := map[string]bool{}, map[string]bool{}
+	for _, name := range organizations {
+		selected[name] = true
+	}
+	for _, organization := range ch.organizations {
+		if len(selected) > 0 && !selected[organization.Name()] {
+			continue
+		}
+		seen[organization.Name()] = true
+		var p *peer.Peer
+		for _, temp := range c.peers {
+			if temp.Organization().Name() == organization.Name() {
+				p = temp
+				break
+			}
+		}
+		if p == nil {
+			continue
+		}
+		peerConnection, err := peer.Connect(p, organization.MSPID(), organization.Admin())
+		if err != nil {
+			return nil, closeAll, err
+		}
+		closers = append(closers, peerConnection.Close)
+		ordererConnection, err := orderer.Connect(c.orderer, organization.MSPID(), organization.Admin())
+		if err != nil {
+			return nil, closeAll, err
+		}
+		closers = append(closers, ordererConnection.Close)
+		transactors = append(transactors, loadgen.NewTransactor(organization.Name(), []*peer.Connection{peerConnection}, ordererConnection))
+	}
+	for _, name := range organizations {
+		if !seen[name] {
+			return nil, closeAll, fmt.Errorf("Organization %s is not a member of channel %s", name, ch.name)
+		}
+	}
+	return transactors, closeAll, nil
+}
@@ -0,0 +1,280 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+)
+
+type bundleVariable struct {
+	name  string
+	value string
+	file  bool
+}
+
+type jsonWalletIdentity struct {
+	Credentials struct {
+		Certificate string `json:"certificate"`
+		PrivateKey  string `json:"privateKey"`
+	} `json:"credentials"`
+	MSPID   string `json:"mspId"`
+	Type    string `json:"type"`
+	Version int    `json:"version"`
+}
+
+func (c *Console) getOrganizationBundle(rw http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["org"]
+	var organization *organization.Organization
+	for _, temp := range c.organizations {
+		if strings.EqualFold(temp.Name(), name) {
+			organization = temp
+			break
+		}
+	}
+	if organization == nil {
+		http.Error(rw, fmt.Sprintf("Organization %s not found", name), 404)
+		return
+	} else if !checkAccess(rw, req, organization.Name()) {
+		return
+	}
+	var write func(io.Writer, string) error
+	var contentType, extension string
+	switch req.URL.Query().Get("format") {
+	case "", "tar.gz", "tgz":
+		write, contentType, extension = writeTarGz, "application/gzip", "tar.gz"
+	case "zip":
+		write, contentType, extension = writeZip, "application/zip", "zip"
+	default:
+		rw.WriteHeader(400)
+		return
+	}
+	directory, err := ioutil.TempDir("", "microfab-bundle")
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	defer os.RemoveAll(directory)
+	bundleName := strings.ToLower(organization.Name())
+	err = c.createBundle(req, path.Join(directory, bundleName), organization)
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	buffer := &bytes.Buffer{}
+	err = write(buffer, directory)
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	rw.Header().Add("Content-Type", contentType)
+	rw.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.%s\"", bundleName, extension))
+	rw.Write(buffer.Bytes())
+}
+
+// createBundle creates the bundle for the specified organization in the specified directory. The bundle contains the
+// MSP and wallet for the admin of the organization, the TLS CA certificates, a connection profile for the peer of the
+// organization, and the environment variables for the Peer CLI as a shell script, a dotenv file and a JSON file.
+func (c *Console) createBundle(req *http.Request, directory string, organization *organization.Organization) error {
+	admin := organization.Admin()
+	err := util.CreateMSPDirectory(path.Join(directory, "msp"), admin)
+	if err != nil {
+		return err
+	}
+	wallet := &jsonWalletIdentity{MSPID: organization.MSPID(), Type: "X.509", Version: 1}
+	wallet.Credentials.Certificate = string(admin.Certificate().Bytes())
+	wallet.Credentials.PrivateKey = string(admin.PrivateKey().Bytes())
+	err = writeBundleJSON(path.Join(directory, "wallet", fmt.Sprintf("%s.id", admin.Name())), wallet)
+	if err != nil {
+		return err
+	}
+	variables := []bundleVariable{
+		{"CORE_PEER_LOCALMSPID", organization.MSPID(), false},
+		{"CORE_PEER_MSPCONFIGPATH", "msp", true},
+	}
+	var p *peer.Peer
+	for _, temp := range c.peers {
+		if temp.Organization() == organization {
+			p = temp
+			break
+		}
+	}
+	if p != nil {
+		variables = append(variables, bundleVariable{"CORE_PEER_ADDRESS", urlHost(c.getPeer(req, p).APIURL), false})
+		if tls := p.TLS(); tls != nil {
+			err = writeBundleFile(path.Join(directory, "tls", "ca.pem"), tls.CA().Bytes())
+			if err != nil {
+				return err
+			}
+			variables = append(variables,
+				bundleVariable{"CORE_PEER_TLS_ENABLED", "true", false},
+				bundleVariable{"CORE_PEER_TLS_ROOTCERT_FILE", "tls/ca.pem", true},
+			)
+		} else {
+			variables = append(variables, bundleVariable{"CORE_PEER_TLS_ENABLED", "false", false})
+		}
+		err = writeBundleJSON(path.Join(directory, "connection.json"), c.getGateway(req, p))
+		if err != nil {
+			return err
+		}
+	}
+	variables = append(variables, bundleVariable{"ORDERER_ADDRESS", urlHost(c.getOrderer(req).APIURL), false})
+	if tls := c.orderer.TLS(); tls != nil {
+		err = writeBundleFile(path.Join(directory, "tls", "orderer-ca.pem"), tls.CA().Bytes())
+		if err != nil {
+			return err
+		}
+		variables = append(variables, bundleVariable{"ORDERER_CA", "tls/orderer-ca.pem", true})
+	}
+	return writeBundleEnvironment(directory, variables)
+}
+
+// writeBundleEnvironment writes the environment variables as a shell script, a dotenv file and a JSON file. The shell
+// script resolves file paths relative to its own location, and the other formats contain paths relative to the bundle.
+func writeBundleEnvironment(directory string, variables []bundleVariable) error {
+	script := &strings.Builder{}
+	script.WriteString("BUNDLE_DIR=\"$(cd \"$(dirname \"${BASH_SOURCE[0]:-$0}\")\" && pwd)\"\n")
+	dotenv := &strings.Builder{}
+	env := map[string]string{}
+	for _, variable := range variables {
+		if variable.file {
+			fmt.Fprintf(script, "export %s=\"${BUNDLE_DIR}/%s\"\n", variable.name, variable.value)
+		} else {
+			fmt.Fprintf(script, "export %s=%s\n", variable.name, variable.value)
+		}
+		fmt.Fprintf(dotenv, "%s=%s\n", variable.name, variable.value)
+		env[variable.name] = variable.value
+	}
+	err := writeBundleFile(path.Join(directory, "env.sh"), []byte(script.String()))
+	if err != nil {
+		return err
+	}
+	err = writeBundleFile(path.Join(directory, ".env"), []byte(dotenv.String()))
+	if err != nil {
+		return err
+	}
+	return writeBundleJSON(path.Join(directory, "env.json"), env)
+}
+
+func writeBundleJSON(file string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeBundleFile(file, data)
+}
+
+func writeBundleFile(file string, data []byte) error {
+	err := os.MkdirAll(path.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+func urlHost(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsedURL.Host
+}
+
+// walkBundle calls the specified function for every file and directory below the specified directory, with the path
+// of that file or directory relative to the specified directory.
+func walkBundle(directory string, fn func(name string, info os.FileInfo, file string) error) error {
+	return filepath.Walk(directory, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if file == directory {
+			return nil
+		}
+		name, err := filepath.Rel(directory, file)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(name), info, file)
+	})
+}
+
+func writeTarGz(w io.Writer, directory string) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err := walkBundle(directory, func(name string, info os.FileInfo, file string) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		err = tarWriter.WriteHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		_, err = tarWriter.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func writeZip(w io.Writer, directory string) error {
+	zipWriter := zip.NewWriter(w)
+	err := walkBundle(directory, func(name string, info os.FileInfo, file string) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		_, err = writer.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
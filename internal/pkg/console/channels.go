@@ -0,0 +1,237 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/config"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	fpeer "github.com/hyperledger/fabric-protos-go/peer"
+)
+
+type jsonChannelOrganization struct {
+	Name        string   `json:"name"`
+	MSPID       string   `json:"msp_id"`
+	AnchorPeers []string `json:"anchor_peers"`
+}
+
+type jsonChannelPeer struct {
+	ID     string `json:"id"`
+	MSPID  string `json:"msp_id"`
+	Height uint64 `json:"height"`
+}
+
+type jsonChaincodeDefinition struct {
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	Sequence          int64  `json:"sequence"`
+	InitRequired      bool   `json:"init_required"`
+	EndorsementPlugin string `json:"endorsement_plugin"`
+	ValidationPlugin  string `json:"validation_plugin"`
+}
+
+type jsonChannel struct {
+	Name          string                     `json:"name"`
+	Capabilities  map[string][]string        `json:"capabilities"`
+	Organizations []*jsonChannelOrganization `json:"organizations"`
+	Peers         []*jsonChannelPeer         `json:"peers"`
+	Chaincodes    []*jsonChaincodeDefinition `json:"chaincodes"`
+}
+
+type channelPeer struct {
+	peer       *peer.Peer
+	connection *peer.Connection
+}
+
+// channelPeers connects to every peer as the admin of its organization, and returns the peers that have joined each
+// channel. Peers that cannot be reached are ignored.
+func (c *Console) channelPeers() (map[string][]*channelPeer, func(), error) {
+	connections := []*peer.Connection{}
+	closeAll := func() {
+		for _, connection := range connections {
+			connection.Close()
+		}
+	}
+	result := map[string][]*channelPeer{}
+	for _, p := range c.peers {
+		organization := p.Organization()
+		connection, err := peer.Connect(p, organization.MSPID(), organization.Admin())
+		if err != nil {
+			return nil, closeAll, err
+		}
+		connections = append(connections, connection)
+		channels, err := connection.ListChannels()
+		if err != nil {
+			logger.Printf("Failed to list channels for peer for organization %s: %v", organization.Name(), err)
+			continue
+		}
+		for _, channel := range channels {
+			result[channel] = append(result[channel], &channelPeer{p, connection})
+		}
+	}
+	return result, closeAll, nil
+}
+
+func (c *Console) getChannels(rw http.ResponseWriter, req *http.Request) {
+	channelPeers, closeAll, err := c.channelPeers()
+	defer closeAll()
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	names := []string{}
+	for name := range channelPeers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []*jsonChannel{}
+	for _, name := range names {
+		channel, err := c.buildChannel(name, channelPeers[name])
+		if err != nil {
+			http.Error(rw, err.Error(), 500)
+			return
+		}
+		result = append(result, channel)
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(result)
+}
+
+func (c *Console) getChannel(rw http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["name"]
+	channelPeers, closeAll, err := c.channelPeers()
+	defer closeAll()
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	peers, ok := channelPeers[name]
+	if !ok {
+		http.Error(rw, fmt.Sprintf("Channel %s not found", name), 404)
+		return
+	}
+	result, err := c.buildChannel(name, peers)
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(result)
+}
+
+// buildChannel builds the information for the specified channel. The configuration and chaincode definitions are
+// read from the first peer that has joined the channel, and the block height is read from every peer.
+func (c *Console) buildChannel(name string, peers []*channelPeer) (*jsonChannel, error) {
+	channelConfig, err := config.GetConfig(peers[0].connection, name)
+	if err != nil {
+		return nil, err
+	}
+	result := &jsonChannel{
+		Name:          name,
+		Capabilities:  map[string][]string{},
+		Organizations: []*jsonChannelOrganization{},
+		Peers:         []*jsonChannelPeer{},
+		Chaincodes:    []*jsonChaincodeDefinition{},
+	}
+	channelGroup := channelConfig.ChannelGroup
+	result.Capabilities["channel"] = getCapabilities(channelGroup)
+	for _, groupName := range []string{"Application", "Orderer"} {
+		if group, ok := channelGroup.Groups[groupName]; ok {
+			result.Capabilities[strings.ToLower(groupName)] = getCapabilities(group)
+		}
+	}
+	if application, ok := channelGroup.Groups["Application"]; ok {
+		for organizationName, group := range application.Groups {
+			organization, err := getChannelOrganization(organizationName, group)
+			if err != nil {
+				return nil, err
+			}
+			result.Organizations = append(result.Organizations, organization)
+		}
+	}
+	sort.Slice(result.Organizations, func(i, j int) bool {
+		return result.Organizations[i].Name < result.Organizations[j].Name
+	})
+	for _, p := range peers {
+		info, err := p.connection.GetChainInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		result.Peers = append(result.Peers, &jsonChannelPeer{
+			ID:     fmt.Sprintf("%speer", strings.ToLower(p.peer.Organization().Name())),
+			MSPID:  p.peer.MSPID(),
+			Height: info.Height,
+		})
+	}
+	definitions, err := peers[0].connection.QueryChaincodeDefinitions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, definition := range definitions {
+		result.Chaincodes = append(result.Chaincodes, &jsonChaincodeDefinition{
+			Name:              definition.Name,
+			Version:           definition.Version,
+			Sequence:          definition.Sequence,
+			InitRequired:      definition.InitRequired,
+			EndorsementPlugin: definition.EndorsementPlugin,
+			ValidationPlugin:  definition.ValidationPlugin,
+		})
+	}
+	return result, nil
+}
+
+func getCapabilities(group *common.ConfigGroup) []string {
+	result := []string{}
+	value, ok := group.Values["Capabilities"]
+	if !ok {
+		return result
+	}
+	capabilities := &common.Capabilities{}
+	if err := proto.Unmarshal(value.Value, capabilities); err != nil {
+		return result
+	}
+	for name := range capabilities.Capabilities {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func getChannelOrganization(name string, group *common.ConfigGroup) (*jsonChannelOrganization, error) {
+	result := &jsonChannelOrganization{
+		Name:        name,
+		AnchorPeers: []string{},
+	}
+	if value, ok := group.Values["MSP"]; ok {
+		mspConfig := &msp.MSPConfig{}
+		if err := proto.Unmarshal(value.Value, mspConfig); err != nil {
+			return nil, err
+		}
+		fabricMSPConfig := &msp.FabricMSPConfig{}
+		if err := proto.Unmarshal(mspConfig.Config, fabricMSPConfig); err != nil {
+			return nil, err
+		}
+		result.MSPID = fabricMSPConfig.Name
+	}
+	if value, ok := group.Values["AnchorPeers"]; ok {
+		anchorPeers := &fpeer.AnchorPeers{}
+		if err := proto.Unmarshal(value.Value, anchorPeers); err != nil {
+			return nil, err
+		}
+		for _, anchorPeer := range anchorPeers.AnchorPeers {
+			result.AnchorPeers = append(result.AnchorPeers, fmt.Sprintf("%s:%d", anchorPeer.Host, anchorPeer.Port))
+		}
+	}
+	return result, nil
+}
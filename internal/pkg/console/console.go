@@ -8,24 +8,23 @@ import (
 	gotls "crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
 	"github.com/hyperledger-labs/microfab/internal/pkg/ca"
+	"github.com/hyperledger-labs/microfab/internal/pkg/couchdb"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
 	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
 	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "console"), log.LstdFlags)
-
-type jsonHealth struct {
-}
+var logger = logging.New("console")
 
 type jsonOptions struct {
 	DefaultAuthority      string `json:"grpc.default_authority"`
@@ -104,11 +103,20 @@ type components map[string]interface{}
 
 // Console represents an instance of a console.
 type Console struct {
+	sync.Mutex
 	httpServer       *http.Server
 	staticComponents components
+	organizations    []*organization.Organization
 	orderer          *orderer.Orderer
 	peers            []*peer.Peer
 	cas              []*ca.CA
+	couchDB          *couchdb.CouchDB
+	channels         []*channel
+	tokens           []*tokenCredential
+	users            []*userCredential
+	clientCA         *certificate.Certificate
+	proxySecret      string
+	ready            bool
 	port             int
 	url              *url.URL
 }
@@ -121,16 +129,33 @@ func New(port int, curl string) (*Console, error) {
 	}
 	console := &Console{
 		staticComponents: components{},
+		organizations:    []*organization.Organization{},
 		port:             port,
 		url:              parsedURL,
 		orderer:          nil,
 		peers:            []*peer.Peer{},
 		cas:              []*ca.CA{},
+		channels:         []*channel{},
 	}
 	router := mux.NewRouter()
+	router.Use(console.authenticate)
 	router.HandleFunc("/ak/api/v1/health", console.getHealth).Methods("GET")
+	router.HandleFunc("/ak/api/v1/ready", console.getReady).Methods("GET")
 	router.HandleFunc("/ak/api/v1/components", console.getComponents).Methods("GET")
 	router.HandleFunc("/ak/api/v1/components/{id}", console.getComponent).Methods("GET")
+	router.HandleFunc("/ak/api/v1/components/{id}/logs", console.getLogs).Methods("GET")
+	router.HandleFunc("/ak/api/v1/caliper/network", console.getCaliperNetworkConfig).Methods("GET")
+	router.HandleFunc("/ak/api/v1/explorer/connection-profile", console.getExplorerConnectionProfile).Methods("GET")
+	router.HandleFunc("/ak/api/v1/benchmark", console.postBenchmark).Methods("POST")
+	router.HandleFunc("/ak/api/v1/topology", console.getTopology).Methods("GET")
+	router.HandleFunc("/ak/api/v1/channels", console.getChannels).Methods("GET")
+	router.HandleFunc("/ak/api/v1/channels/{name}", console.getChannel).Methods("GET")
+	router.HandleFunc("/ak/api/v1/channels/{name}/blocks/{number}", console.getBlock).Methods("GET")
+	router.HandleFunc("/ak/api/v1/channels/{name}/transactions/{txid}", console.getTransaction).Methods("GET")
+	router.HandleFunc("/ak/api/v1/channels/{name}/events", console.getEvents).Methods("GET")
+	router.HandleFunc("/ak/api/v1/organizations/{org}/bundle", console.getOrganizationBundle).Methods("GET")
+	router.HandleFunc("/ak/api/v1/organizations/{org}/connection-profile", console.getConnectionProfile).Methods("GET")
+	console.registerV2(router)
 	HTTPServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: router,
@@ -154,7 +179,8 @@ func (c *Console) EnableTLS(tls *identity.Identity) error {
 
 // RegisterOrganization registers the specified organization with the console.
 func (c *Console) RegisterOrganization(organization *organization.Organization) {
-	logger.Printf("RegisterOrganization %v", organization)
+	logger.Debugf("RegisterOrganization %v", organization.Name())
+	c.organizations = append(c.organizations, organization)
 	for _, identity := range organization.GetIdentities() {
 		identityHide := identity != organization.Admin()
 		id := strings.ToLower(identity.Name())
@@ -211,30 +237,29 @@ func (c *Console) URL() *url.URL {
 	return c.url
 }
 
-func (c *Console) getHealth(rw http.ResponseWriter, req *http.Request) {
-	rw.Header().Add("Content-Type", "application/json")
-	json.NewEncoder(rw).Encode(&jsonHealth{})
-}
-
 func (c *Console) getComponents(rw http.ResponseWriter, req *http.Request) {
-	logger.Print("Getting components for REST response")
-	logger.Printf("%+v", c.staticComponents)
-	logger.Printf("%+v", c.getDynamicComponents(req))
+	logger.Debugf("Getting components for REST response")
 	components := []interface{}{}
 	for _, component := range c.staticComponents {
+		if identity, ok := component.(*jsonIdentity); ok && !canAccess(req, identity.Wallet) {
+			continue
+		}
 		components = append(components, component)
 	}
 	for _, component := range c.getDynamicComponents(req) {
 		components = append(components, component)
 	}
 	rw.Header().Add("Content-Type", "application/json")
-	logger.Printf("components== %+v", components)
+	logger.Debugf("components== %+v", components)
 	json.NewEncoder(rw).Encode(components)
 }
 
 func (c *Console) getComponent(rw http.ResponseWriter, req *http.Request) {
 	id := mux.Vars(req)["id"]
 	component, ok := c.staticComponents[id]
+	if identity, isIdentity := component.(*jsonIdentity); isIdentity && !canAccess(req, identity.Wallet) {
+		ok = false
+	}
 	if !ok {
 		component, ok = c.getDynamicComponents(req)[id]
 		if !ok {
@@ -273,10 +298,16 @@ func (c *Console) getOrderer(req *http.Request) *jsonOrderer {
 			SSLTargetNameOverride: c.orderer.OperationsHostname(false),
 			RequestTimeout:        300 * 1000,
 		},
-		MSPID:    "OrdererMSP",
+		MSPID:    c.orderer.MSPID(),
 		Identity: c.orderer.Organization().Admin().Name(),
 		Wallet:   c.orderer.Organization().Name(),
 	}
+	if c.orderer.Remote() {
+		// A remote ordering service is not behind the proxy, and does not expose its operations endpoint.
+		result.APIURL = c.orderer.APIURL(false).String()
+		result.OperationsURL = ""
+		result.OperationsOptions = nil
+	}
 	if tls := c.orderer.TLS(); tls != nil {
 		result.PEM = tls.CA().Bytes()
 		result.TLSCARootCert = tls.CA().Bytes()
@@ -332,7 +363,18 @@ func (c *Console) getPeers(req *http.Request) []*jsonPeer {
 func (c *Console) getGateway(req *http.Request, peer *peer.Peer) map[string]interface{} {
 	orgName := peer.Organization().Name()
 	lowerOrgName := strings.ToLower(orgName)
-	id := fmt.Sprintf("%sgateway", lowerOrgName)
+	result := c.getGatewayProfile(req, peer)
+	result["id"] = fmt.Sprintf("%sgateway", lowerOrgName)
+	result["display_name"] = fmt.Sprintf("%s Gateway", orgName)
+	result["type"] = "gateway"
+	result["wallet"] = orgName
+	return result
+}
+
+// getGatewayProfile returns the connection profile for the gateway of the specified peer, which only contains the
+// peer and CA of its own organization.
+func (c *Console) getGatewayProfile(req *http.Request, peer *peer.Peer) map[string]interface{} {
+	orgName := peer.Organization().Name()
 	var ca *ca.CA
 	for _, temp := range c.cas {
 		if temp.Organization().Name() == peer.Organization().Name() {
@@ -340,25 +382,9 @@ func (c *Console) getGateway(req *http.Request, peer *peer.Peer) map[string]inte
 			break
 		}
 	}
-	p := map[string]interface{}{
-		"url": c.getDynamicURL(req, peer.APIURL(false)),
-		"grpcOptions": map[string]interface{}{
-			"grpc.default_authority":        peer.APIHost(false),
-			"grpc.ssl_target_name_override": peer.APIHostname(false),
-		},
-	}
-	if tls := peer.TLS(); tls != nil {
-		p["tlsCACerts"] = map[string]string{
-			"pem": string(tls.CA().Bytes()),
-		}
-	}
 	result := map[string]interface{}{
-		"id":           id,
-		"display_name": fmt.Sprintf("%s Gateway", orgName),
-		"type":         "gateway",
-		"name":         fmt.Sprintf("%s Gateway", orgName),
-		"version":      "1.0",
-		"wallet":       peer.Organization().Name(),
+		"name":    fmt.Sprintf("%s Gateway", orgName),
+		"version": "1.0",
 		"client": map[string]interface{}{
 			"organization": peer.Organization().Name(),
 			"connection": map[string]interface{}{
@@ -379,7 +405,7 @@ func (c *Console) getGateway(req *http.Request, peer *peer.Peer) map[string]inte
 			},
 		},
 		"peers": map[string]interface{}{
-			peer.APIHost(false): p,
+			peer.APIHost(false): c.getPeerProfile(req, peer),
 		},
 	}
 	if ca != nil {
@@ -388,16 +414,38 @@ func (c *Console) getGateway(req *http.Request, peer *peer.Peer) map[string]inte
 		organization["certificateAuthorities"] = []interface{}{
 			ca.APIHost(false),
 		}
-		c := map[string]interface{}{
-			"url": c.getDynamicURL(req, ca.APIURL(false)),
+		result["certificateAuthorities"] = map[string]interface{}{
+			ca.APIHost(false): c.getCAProfile(req, ca),
 		}
-		if tls := ca.TLS(); tls != nil {
-			c["tlsCACerts"] = map[string][]string{
-				"pem": {string(tls.CA().Bytes())},
-			}
+	}
+	return result
+}
+
+// getPeerProfile returns the entry for the specified peer in the peers section of a connection profile.
+func (c *Console) getPeerProfile(req *http.Request, peer *peer.Peer) map[string]interface{} {
+	result := map[string]interface{}{
+		"url": c.getDynamicURL(req, peer.APIURL(false)),
+		"grpcOptions": map[string]interface{}{
+			"grpc.default_authority":        peer.APIHost(false),
+			"grpc.ssl_target_name_override": peer.APIHostname(false),
+		},
+	}
+	if tls := peer.TLS(); tls != nil {
+		result["tlsCACerts"] = map[string]string{
+			"pem": string(tls.CA().Bytes()),
 		}
-		result["certificateAuthorities"] = map[string]interface{}{
-			ca.APIHost(false): c,
+	}
+	return result
+}
+
+// getCAProfile returns the entry for the specified CA in the certificateAuthorities section of a connection profile.
+func (c *Console) getCAProfile(req *http.Request, ca *ca.CA) map[string]interface{} {
+	result := map[string]interface{}{
+		"url": c.getDynamicURL(req, ca.APIURL(false)),
+	}
+	if tls := ca.TLS(); tls != nil {
+		result["tlsCACerts"] = map[string][]string{
+			"pem": {string(tls.CA().Bytes())},
 		}
 	}
 	return result
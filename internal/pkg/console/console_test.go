@@ -0,0 +1,1353 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/console"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/loadgen"
+	"github.com/hyperledger-labs/microfab/internal/pkg/mock"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+func freePort() int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+var _ = Describe("the console package", func() {
+
+	var testDirectory string
+	var testConsole *console.Console
+	var consoleURL string
+	var ordererOrganization, org1, org2 *organization.Organization
+	var testOrderer *orderer.Orderer
+	var peer1, peer2 *peer.Peer
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-console")
+		Expect(err).NotTo(HaveOccurred())
+		ordererOrganization, err = organization.New("Orderer", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org1, err = organization.New("Org1", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org2, err = organization.New("Org2", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		testOrderer, err = orderer.New(ordererOrganization, path.Join(testDirectory, "orderer"), 8080, int32(freePort()), "grpc://orderer-api.127-0-0-1.nip.io:8080", int32(freePort()), "http://orderer-operations.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		peer1, err = peer.New(org1, path.Join(testDirectory, "peer-org1"), 8080, int32(freePort()), "grpc://org1peer-api.127-0-0-1.nip.io:8080", int32(freePort()), "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", int32(freePort()), "http://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, 4000, "http://org1peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		peer2, err = peer.New(org2, path.Join(testDirectory, "peer-org2"), 8080, 2005, "grpc://org2peer-api.127-0-0-1.nip.io:8080", 2006, "grpc://org2peer-chaincode.127-0-0-1.nip.io:8080", 2007, "http://org2peer-operations.127-0-0-1.nip.io:8080", false, 0, 4001, "http://org2peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		port := freePort()
+		consoleURL = fmt.Sprintf("http://localhost:%d", port)
+		testConsole, err = console.New(port, consoleURL)
+		Expect(err).NotTo(HaveOccurred())
+		testConsole.RegisterOrderer(testOrderer)
+		for _, organization := range []*organization.Organization{ordererOrganization, org1, org2} {
+			testConsole.RegisterOrganization(organization)
+		}
+		testConsole.RegisterPeer(peer1)
+		testConsole.RegisterPeer(peer2)
+		testConsole.RegisterChannel("channel1", []*organization.Organization{org1, org2})
+		testConsole.RegisterChannel("channel2", []*organization.Organization{org2})
+		go testConsole.Start()
+		Eventually(func() error {
+			resp, err := http.Get(consoleURL + "/ak/api/v1/health")
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		}).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		testConsole.Stop()
+		os.RemoveAll(testDirectory)
+	})
+
+	get := func(path string) (int, []byte) {
+		resp, err := http.Get(consoleURL + path)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp.StatusCode, data
+	}
+
+	startMocks := func() func() {
+		ledger := mock.NewLedger()
+		mockOrderer := mock.NewOrderer(testOrderer, []*organization.Organization{org1, org2}, ledger)
+		Expect(mockOrderer.Start()).To(Succeed())
+		mockPeer := mock.NewPeer(peer1, ledger)
+		Expect(mockPeer.Start()).To(Succeed())
+		ordererConnection, err := orderer.Connect(testOrderer, org1.MSPID(), org1.Admin())
+		Expect(err).NotTo(HaveOccurred())
+		defer ordererConnection.Close()
+		Expect(channel.CreateChannel(ordererConnection, "channel1", channel.AddMSPID(org1.MSPID()), channel.AddMSPID(org2.MSPID()))).To(Succeed())
+		genesisBlock, err := blocks.GetGenesisBlock(ordererConnection, "channel1")
+		Expect(err).NotTo(HaveOccurred())
+		peerConnection, err := peer.Connect(peer1, org1.MSPID(), org1.Admin())
+		Expect(err).NotTo(HaveOccurred())
+		defer peerConnection.Close()
+		Expect(peerConnection.JoinChannel(genesisBlock)).To(Succeed())
+		Expect(channel.UpdateChannel(ordererConnection, "channel1", channel.AddAnchorPeer(org1.MSPID(), "org1peer-api.127-0-0-1.nip.io", 8080), channel.UsingMSPID(org1.MSPID()), channel.UsingIdentity(org1.Admin()))).To(Succeed())
+		return func() {
+			Expect(mockPeer.Stop()).To(Succeed())
+			Expect(mockOrderer.Stop()).To(Succeed())
+		}
+	}
+
+	Context("GET /ak/api/v1/caliper/network", func() {
+
+		When("called", func() {
+			It("returns a Caliper network configuration", func() {
+				status, data := get("/ak/api/v1/caliper/network")
+				Expect(status).To(Equal(200))
+				config := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &config)).To(Succeed())
+				Expect(config["caliper"]).To(Equal(map[string]interface{}{"blockchain": "fabric"}))
+				Expect(config["channels"]).To(ConsistOf(
+					map[string]interface{}{"channelName": "channel1", "contracts": []interface{}{}},
+					map[string]interface{}{"channelName": "channel2", "contracts": []interface{}{}},
+				))
+				organizations := config["organizations"].([]interface{})
+				Expect(organizations).To(HaveLen(2))
+				organization := organizations[0].(map[string]interface{})
+				Expect(organization["mspid"]).To(Equal("Org1MSP"))
+				Expect(organization["connectionProfile"]).To(Equal(map[string]interface{}{"path": "connection-org1.json", "discover": true}))
+				certificate := organization["identities"].(map[string]interface{})["certificates"].([]interface{})[0].(map[string]interface{})
+				Expect(certificate["clientSignedCert"]).To(Equal(map[string]interface{}{"pem": string(org1.Admin().Certificate().Bytes())}))
+				Expect(certificate["clientPrivateKey"]).To(Equal(map[string]interface{}{"pem": string(org1.Admin().PrivateKey().Bytes())}))
+			})
+		})
+
+		When("called for a channel with a committed chaincode definition", func() {
+			It("returns the chaincode as a contract in the channel", func() {
+				defer startMocks()()
+				ordererConnection, err := orderer.Connect(testOrderer, org1.MSPID(), org1.Admin())
+				Expect(err).NotTo(HaveOccurred())
+				defer ordererConnection.Close()
+				peerConnection, err := peer.Connect(peer1, org1.MSPID(), org1.Admin())
+				Expect(err).NotTo(HaveOccurred())
+				defer peerConnection.Close()
+				peers := []*peer.Connection{peerConnection}
+				Expect(channel.ApproveChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0", "asset:1234")).To(Succeed())
+				Expect(channel.CommitChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0")).To(Succeed())
+				status, data := get("/ak/api/v1/caliper/network")
+				Expect(status).To(Equal(200))
+				config := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &config)).To(Succeed())
+				Expect(config["channels"]).To(ConsistOf(
+					map[string]interface{}{"channelName": "channel1", "contracts": []interface{}{map[string]interface{}{"id": "asset"}}},
+					map[string]interface{}{"channelName": "channel2", "contracts": []interface{}{}},
+				))
+			})
+		})
+
+		When("called with format=yaml", func() {
+			It("returns the configuration as YAML", func() {
+				status, data := get("/ak/api/v1/caliper/network?format=yaml")
+				Expect(status).To(Equal(200))
+				config := map[string]interface{}{}
+				Expect(yaml.Unmarshal(data, &config)).To(Succeed())
+				Expect(config["version"]).To(Equal("2.0.0"))
+			})
+		})
+
+		When("called with an invalid format", func() {
+			It("returns a bad request error", func() {
+				status, _ := get("/ak/api/v1/caliper/network?format=xml")
+				Expect(status).To(Equal(400))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/explorer/connection-profile", func() {
+
+		When("called", func() {
+			It("returns an Explorer connection profile for the first organization", func() {
+				status, data := get("/ak/api/v1/explorer/connection-profile")
+				Expect(status).To(Equal(200))
+				profile := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &profile)).To(Succeed())
+				client := profile["client"].(map[string]interface{})
+				Expect(client["organization"]).To(Equal("Org1MSP"))
+				Expect(client["tlsEnable"]).To(BeFalse())
+				channels := profile["channels"].(map[string]interface{})
+				Expect(channels["channel1"]).To(Equal(map[string]interface{}{
+					"peers": map[string]interface{}{
+						"org1peer-api.127-0-0-1.nip.io:8080": map[string]interface{}{},
+						"org2peer-api.127-0-0-1.nip.io:8080": map[string]interface{}{},
+					},
+				}))
+				Expect(channels["channel2"]).To(Equal(map[string]interface{}{
+					"peers": map[string]interface{}{
+						"org2peer-api.127-0-0-1.nip.io:8080": map[string]interface{}{},
+					},
+				}))
+				organization := profile["organizations"].(map[string]interface{})["Org1MSP"].(map[string]interface{})
+				Expect(organization["peers"]).To(Equal([]interface{}{"org1peer-api.127-0-0-1.nip.io:8080"}))
+				Expect(organization["adminPrivateKey"]).To(Equal(map[string]interface{}{"pem": string(org1.Admin().PrivateKey().Bytes())}))
+				peer := profile["peers"].(map[string]interface{})["org1peer-api.127-0-0-1.nip.io:8080"].(map[string]interface{})
+				Expect(peer["url"]).To(Equal("grpc://org1peer-api.127-0-0-1.nip.io:8080"))
+				Expect(peer).NotTo(HaveKey("tlsCACerts"))
+			})
+		})
+
+		When("called for an organization with TLS enabled", func() {
+			It("returns an Explorer connection profile with TLS", func() {
+				tlsCA, err := identity.New("TLS CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				tls, err := identity.New("TLS", identity.UsingSigner(tlsCA))
+				Expect(err).NotTo(HaveOccurred())
+				peer1.EnableTLS(tls)
+				status, data := get("/ak/api/v1/explorer/connection-profile?organization=Org1")
+				Expect(status).To(Equal(200))
+				profile := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &profile)).To(Succeed())
+				Expect(profile["client"].(map[string]interface{})["tlsEnable"]).To(BeTrue())
+				peer := profile["peers"].(map[string]interface{})["org1peer-api.127-0-0-1.nip.io:8080"].(map[string]interface{})
+				Expect(peer["tlsCACerts"]).To(Equal(map[string]interface{}{"pem": string(tlsCA.Certificate().Bytes())}))
+			})
+		})
+
+		When("called for an organization without a peer", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/explorer/connection-profile?organization=Orderer")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+	})
+
+	// startMocks starts a mock orderer, and a mock peer for Org1 that has joined channel1. The peer for Org2 is not
+	// started.
+	Context("GET /ak/api/v1/topology", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("called", func() {
+			It("returns a Mermaid diagram with the channels that each peer has joined", func() {
+				status, data := get("/ak/api/v1/topology")
+				Expect(status).To(Equal(200))
+				diagram := string(data)
+				Expect(diagram).To(HavePrefix("flowchart LR\n"))
+				Expect(diagram).To(ContainSubstring(`subgraph org_Org1["Org1 (Org1MSP)"]`))
+				Expect(diagram).To(ContainSubstring(`org1peer["Org1 Peer<br/>grpc://org1peer-api.127-0-0-1.nip.io:8080"]`))
+				Expect(diagram).To(ContainSubstring(`org2peer["Org2 Peer<br/>grpc://org2peer-api.127-0-0-1.nip.io:8080"]`))
+				Expect(diagram).To(ContainSubstring(`orderer["Orderer<br/>grpc://orderer-api.127-0-0-1.nip.io:8080"]`))
+				Expect(diagram).To(ContainSubstring(`channel_channel1{{"channel1"}}`))
+				Expect(diagram).To(ContainSubstring("org1peer --- channel_channel1\n"))
+				Expect(diagram).To(ContainSubstring("channel_channel1 --- orderer\n"))
+				Expect(diagram).NotTo(ContainSubstring("org2peer --- channel_channel1"))
+				Expect(diagram).NotTo(ContainSubstring("channel2"))
+			})
+		})
+
+		When("called with format=dot", func() {
+			It("returns a Graphviz DOT diagram", func() {
+				status, data := get("/ak/api/v1/topology?format=dot")
+				Expect(status).To(Equal(200))
+				diagram := string(data)
+				Expect(diagram).To(HavePrefix("graph microfab {\n"))
+				Expect(diagram).To(ContainSubstring(`subgraph "cluster_Org1" {`))
+				Expect(diagram).To(ContainSubstring(`"org1peer" [label="Org1 Peer\ngrpc://org1peer-api.127-0-0-1.nip.io:8080", shape=box];`))
+				Expect(diagram).To(ContainSubstring(`"channel_channel1" [label="channel1", shape=hexagon];`))
+				Expect(diagram).To(ContainSubstring(`"org1peer" -- "channel_channel1";`))
+				Expect(diagram).To(HaveSuffix("}\n"))
+			})
+		})
+
+		When("called with an invalid format", func() {
+			It("returns a bad request error", func() {
+				status, _ := get("/ak/api/v1/topology?format=svg")
+				Expect(status).To(Equal(400))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/channels", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("called", func() {
+			It("returns the channels that the peers have joined", func() {
+				status, data := get("/ak/api/v1/channels")
+				Expect(status).To(Equal(200))
+				channels := []map[string]interface{}{}
+				Expect(json.Unmarshal(data, &channels)).To(Succeed())
+				Expect(channels).To(HaveLen(1))
+				Expect(channels[0]["name"]).To(Equal("channel1"))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/channels/{name}", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("called for a channel that a peer has joined", func() {
+			It("returns the members, capabilities, peers and chaincodes of the channel", func() {
+				status, data := get("/ak/api/v1/channels/channel1")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["name"]).To(Equal("channel1"))
+				Expect(result["organizations"]).To(Equal([]interface{}{
+					map[string]interface{}{"name": "Org1MSP", "msp_id": "Org1MSP", "anchor_peers": []interface{}{"org1peer-api.127-0-0-1.nip.io:8080"}},
+					map[string]interface{}{"name": "Org2MSP", "msp_id": "Org2MSP", "anchor_peers": []interface{}{}},
+				}))
+				capabilities := result["capabilities"].(map[string]interface{})
+				Expect(capabilities).To(HaveKey("channel"))
+				Expect(capabilities["application"]).To(ContainElement("V2_5"))
+				peers := result["peers"].([]interface{})
+				Expect(peers).To(HaveLen(1))
+				Expect(peers[0]).To(HaveKeyWithValue("id", "org1peer"))
+				Expect(peers[0]).To(HaveKeyWithValue("msp_id", "Org1MSP"))
+				Expect(peers[0].(map[string]interface{})["height"]).To(BeNumerically(">=", 1))
+				Expect(result["chaincodes"]).To(BeEmpty())
+			})
+		})
+
+		When("called for a channel that no peer has joined", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/channels/channel2")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/channels/{name}/blocks/{number}", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("called with a block number", func() {
+			It("returns the decoded block", func() {
+				status, data := get("/ak/api/v1/channels/channel1/blocks/0")
+				Expect(status).To(Equal(200))
+				block := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &block)).To(Succeed())
+				Expect(block["number"]).To(BeEquivalentTo(0))
+				transactions := block["transactions"].([]interface{})
+				Expect(transactions).To(HaveLen(1))
+				Expect(transactions[0]).To(HaveKeyWithValue("type", "CONFIG"))
+				Expect(transactions[0]).To(HaveKeyWithValue("channel_id", "channel1"))
+			})
+		})
+
+		When("called with newest", func() {
+			It("returns the newest block", func() {
+				status, data := get("/ak/api/v1/channels/channel1/blocks/newest")
+				Expect(status).To(Equal(200))
+				block := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &block)).To(Succeed())
+				Expect(block["number"]).To(BeEquivalentTo(1))
+			})
+		})
+
+		When("called with a block number that does not exist yet", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/channels/channel1/blocks/99")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+		When("called with an invalid block number", func() {
+			It("returns a bad request error", func() {
+				status, _ := get("/ak/api/v1/channels/channel1/blocks/oldest")
+				Expect(status).To(Equal(400))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/channels/{name}/transactions/{txid}", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("called with the ID of a committed transaction", func() {
+			It("returns the decoded transaction", func() {
+				_, data := get("/ak/api/v1/channels/channel1/blocks/1")
+				block := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &block)).To(Succeed())
+				txID := block["transactions"].([]interface{})[0].(map[string]interface{})["tx_id"].(string)
+				status, data := get("/ak/api/v1/channels/channel1/transactions/" + txID)
+				Expect(status).To(Equal(200))
+				transaction := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &transaction)).To(Succeed())
+				Expect(transaction["tx_id"]).To(Equal(txID))
+				Expect(transaction["block_number"]).To(BeEquivalentTo(1))
+				Expect(transaction["validation_code"]).To(Equal("VALID"))
+				Expect(transaction["type"]).To(Equal("CONFIG"))
+			})
+		})
+
+		When("called with an unknown transaction ID", func() {
+			It("returns a not found error", func() {
+				status, data := get("/ak/api/v1/channels/channel1/transactions/abc")
+				Expect(status).To(Equal(404))
+				Expect(string(data)).To(Equal("Transaction abc not found in channel channel1\n"))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/components/{id}/logs", func() {
+
+		var logFile string
+
+		BeforeEach(func() {
+			logFile = peer1.LogFile()
+			Expect(os.MkdirAll(path.Dir(logFile), 0755)).To(Succeed())
+			lines := []string{
+				"time=2020-01-01T00:00:00.000Z level=INFO msg=\"first line\" component=org1peer",
+				"time=2020-01-01T00:01:00.000Z level=INFO msg=\"second line\" component=org1peer",
+				"time=2020-01-01T00:02:00.000Z level=INFO msg=\"third line\" component=org1peer",
+			}
+			Expect(ioutil.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)).To(Succeed())
+		})
+
+		getLines := func(path string) (int, []string) {
+			status, data := get(path)
+			return status, strings.Split(strings.TrimSpace(string(data)), "\n")
+		}
+
+		When("called", func() {
+			It("returns every line of the log", func() {
+				status, lines := getLines("/ak/api/v1/components/org1peer/logs")
+				Expect(status).To(Equal(200))
+				Expect(lines).To(HaveLen(3))
+				Expect(lines[0]).To(ContainSubstring("first line"))
+			})
+		})
+
+		When("called with tail", func() {
+			It("returns the last lines of the log", func() {
+				status, lines := getLines("/ak/api/v1/components/org1peer/logs?tail=2")
+				Expect(status).To(Equal(200))
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0]).To(ContainSubstring("second line"))
+				Expect(lines[1]).To(ContainSubstring("third line"))
+			})
+		})
+
+		When("called with since", func() {
+			It("returns the lines logged since that time", func() {
+				status, lines := getLines("/ak/api/v1/components/org1peer/logs?since=2020-01-01T00:01:00Z")
+				Expect(status).To(Equal(200))
+				Expect(lines).To(HaveLen(2))
+				Expect(lines[0]).To(ContainSubstring("second line"))
+			})
+		})
+
+		When("called with grep", func() {
+			It("returns the lines that match the regular expression", func() {
+				status, lines := getLines("/ak/api/v1/components/org1peer/logs?grep=fir.t")
+				Expect(status).To(Equal(200))
+				Expect(lines).To(HaveLen(1))
+				Expect(lines[0]).To(ContainSubstring("first line"))
+			})
+		})
+
+		When("called with follow", func() {
+			It("streams new lines as they are logged", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				req, err := http.NewRequestWithContext(ctx, "GET", consoleURL+"/ak/api/v1/components/org1peer/logs?tail=1&follow=true", nil)
+				Expect(err).NotTo(HaveOccurred())
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(200))
+				reader := bufio.NewReader(resp.Body)
+				line, err := reader.ReadString('\n')
+				Expect(err).NotTo(HaveOccurred())
+				Expect(line).To(ContainSubstring("third line"))
+				file, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.WriteString("time=2020-01-01T00:03:00.000Z level=INFO msg=\"fourth line\" component=org1peer\n")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(file.Close()).To(Succeed())
+				line, err = reader.ReadString('\n')
+				Expect(err).NotTo(HaveOccurred())
+				Expect(line).To(ContainSubstring("fourth line"))
+			})
+		})
+
+		When("called with an invalid parameter", func() {
+			It("returns a bad request error", func() {
+				for _, query := range []string{"tail=last", "since=yesterday", "grep=(", "follow=maybe"} {
+					status, _ := get("/ak/api/v1/components/org1peer/logs?" + query)
+					Expect(status).To(Equal(400), query)
+				}
+			})
+		})
+
+		When("called for a component that has not logged anything", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/components/orderer/logs")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+		When("called for an unknown component", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/components/org9peer/logs")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/channels/{name}/events", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("called with a start block number", func() {
+			It("streams the blocks and transactions from that block", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				req, err := http.NewRequestWithContext(ctx, "GET", consoleURL+"/ak/api/v1/channels/channel1/events?start=0", nil)
+				Expect(err).NotTo(HaveOccurred())
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(200))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+				reader := bufio.NewReader(resp.Body)
+				readEvent := func() (string, map[string]interface{}) {
+					event, data := "", map[string]interface{}{}
+					for {
+						line, err := reader.ReadString('\n')
+						Expect(err).NotTo(HaveOccurred())
+						line = strings.TrimSpace(line)
+						if line == "" {
+							return event, data
+						} else if strings.HasPrefix(line, "event: ") {
+							event = strings.TrimPrefix(line, "event: ")
+						} else if strings.HasPrefix(line, "data: ") {
+							Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data)).To(Succeed())
+						}
+					}
+				}
+				event, data := readEvent()
+				Expect(event).To(Equal("block"))
+				Expect(data["number"]).To(BeEquivalentTo(0))
+				Expect(data["transactions"]).To(BeEquivalentTo(1))
+				event, data = readEvent()
+				Expect(event).To(Equal("transaction"))
+				Expect(data["type"]).To(Equal("CONFIG"))
+				Expect(data["block_number"]).To(BeEquivalentTo(0))
+				event, data = readEvent()
+				Expect(event).To(Equal("block"))
+				Expect(data["number"]).To(BeEquivalentTo(1))
+			})
+		})
+
+		When("called with an invalid start block number", func() {
+			It("returns a bad request error", func() {
+				status, _ := get("/ak/api/v1/channels/channel1/events?start=oldest")
+				Expect(status).To(Equal(400))
+			})
+		})
+
+		When("called with an unknown channel", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/channels/channel9/events")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/health", func() {
+
+		var stopMocks func()
+
+		BeforeEach(func() {
+			stopMocks = startMocks()
+		})
+
+		AfterEach(func() {
+			stopMocks()
+		})
+
+		When("every component is healthy", func() {
+			It("returns the status and latency of every component", func() {
+				mockPeer := mock.NewPeer(peer2, mock.NewLedger())
+				Expect(mockPeer.Start()).To(Succeed())
+				defer mockPeer.Stop()
+				status, data := get("/ak/api/v1/health")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["status"]).To(Equal("OK"))
+				components := result["components"].([]interface{})
+				Expect(components).To(HaveLen(3))
+				for _, component := range components {
+					Expect(component).To(HaveKeyWithValue("status", "OK"))
+					Expect(component).To(HaveKey("latency_ms"))
+				}
+				Expect(components[0]).To(HaveKeyWithValue("id", "orderer"))
+				Expect(components[1]).To(HaveKeyWithValue("id", "org1peer"))
+				Expect(components[2]).To(HaveKeyWithValue("id", "org2peer"))
+			})
+		})
+
+		When("a component is not healthy", func() {
+			It("returns a service unavailable error", func() {
+				status, data := get("/ak/api/v1/health")
+				Expect(status).To(Equal(503))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["status"]).To(Equal("UNAVAILABLE"))
+				components := result["components"].([]interface{})
+				Expect(components).To(HaveLen(3))
+				Expect(components[1]).To(HaveKeyWithValue("status", "OK"))
+				Expect(components[2]).To(HaveKeyWithValue("id", "org2peer"))
+				Expect(components[2]).To(HaveKeyWithValue("status", "UNAVAILABLE"))
+				Expect(components[2]).To(HaveKey("error"))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/ready", func() {
+
+		When("Microfab is still starting", func() {
+			It("returns a service unavailable error", func() {
+				status, data := get("/ak/api/v1/ready")
+				Expect(status).To(Equal(503))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["ready"]).To(BeFalse())
+			})
+		})
+
+		When("a channel has not been joined by every member organization", func() {
+			It("returns a service unavailable error", func() {
+				defer startMocks()()
+				testConsole.MarkReady()
+				status, data := get("/ak/api/v1/ready")
+				Expect(status).To(Equal(503))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["ready"]).To(BeFalse())
+				Expect(result["channels"]).To(Equal([]interface{}{
+					map[string]interface{}{"name": "channel1", "joined": false, "organizations": []interface{}{"Org1"}},
+					map[string]interface{}{"name": "channel2", "joined": false, "organizations": []interface{}{}},
+				}))
+			})
+		})
+
+		When("every channel has been joined by every member organization", func() {
+			It("returns the channels", func() {
+				ledger := mock.NewLedger()
+				mockOrderer := mock.NewOrderer(testOrderer, []*organization.Organization{org1, org2}, ledger)
+				Expect(mockOrderer.Start()).To(Succeed())
+				defer mockOrderer.Stop()
+				ordererConnection, err := orderer.Connect(testOrderer, org1.MSPID(), org1.Admin())
+				Expect(err).NotTo(HaveOccurred())
+				defer ordererConnection.Close()
+				for _, testPeer := range []*peer.Peer{peer1, peer2} {
+					mockPeer := mock.NewPeer(testPeer, ledger)
+					Expect(mockPeer.Start()).To(Succeed())
+					defer mockPeer.Stop()
+				}
+				join := func(name string, peers ...*peer.Peer) {
+					Expect(channel.CreateChannel(ordererConnection, name, channel.AddMSPID(org1.MSPID()), channel.AddMSPID(org2.MSPID()))).To(Succeed())
+					genesisBlock, err := blocks.GetGenesisBlock(ordererConnection, name)
+					Expect(err).NotTo(HaveOccurred())
+					for _, testPeer := range peers {
+						peerConnection, err := peer.Connect(testPeer, testPeer.Organization().MSPID(), testPeer.Organization().Admin())
+						Expect(err).NotTo(HaveOccurred())
+						defer peerConnection.Close()
+						Expect(peerConnection.JoinChannel(genesisBlock)).To(Succeed())
+					}
+				}
+				join("channel1", peer1, peer2)
+				join("channel2", peer2)
+				testConsole.MarkReady()
+				status, data := get("/ak/api/v1/ready")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["ready"]).To(BeTrue())
+				Expect(result["channels"]).To(Equal([]interface{}{
+					map[string]interface{}{"name": "channel1", "joined": true, "organizations": []interface{}{"Org1", "Org2"}},
+					map[string]interface{}{"name": "channel2", "joined": true, "organizations": []interface{}{"Org2"}},
+				}))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/organizations/{org}/connection-profile", func() {
+
+		When("called", func() {
+			It("returns a connection profile with every peer, orderer and channel", func() {
+				status, data := get("/ak/api/v1/organizations/Org1/connection-profile")
+				Expect(status).To(Equal(200))
+				profile := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &profile)).To(Succeed())
+				Expect(profile["client"].(map[string]interface{})["organization"]).To(Equal("Org1"))
+				organizations := profile["organizations"].(map[string]interface{})
+				Expect(organizations["Org1"]).To(HaveKeyWithValue("peers", []interface{}{"org1peer-api.127-0-0-1.nip.io:8080"}))
+				Expect(organizations["Org2"]).To(HaveKeyWithValue("mspid", "Org2MSP"))
+				Expect(organizations["Orderer"]).To(HaveKeyWithValue("orderers", []interface{}{"orderer-api.127-0-0-1.nip.io:8080"}))
+				Expect(profile["peers"]).To(HaveKey("org1peer-api.127-0-0-1.nip.io:8080"))
+				Expect(profile["peers"]).To(HaveKey("org2peer-api.127-0-0-1.nip.io:8080"))
+				orderer := profile["orderers"].(map[string]interface{})["orderer-api.127-0-0-1.nip.io:8080"].(map[string]interface{})
+				Expect(orderer["url"]).To(Equal("grpc://orderer-api.127-0-0-1.nip.io:8080"))
+				channels := profile["channels"].(map[string]interface{})
+				channel1 := channels["channel1"].(map[string]interface{})
+				Expect(channel1["orderers"]).To(Equal([]interface{}{"orderer-api.127-0-0-1.nip.io:8080"}))
+				Expect(channel1["peers"]).To(HaveLen(2))
+				Expect(channel1["peers"]).To(HaveKeyWithValue("org2peer-api.127-0-0-1.nip.io:8080", map[string]interface{}{
+					"endorsingPeer":  true,
+					"chaincodeQuery": true,
+					"ledgerQuery":    true,
+					"eventSource":    true,
+				}))
+				channel2 := channels["channel2"].(map[string]interface{})
+				Expect(channel2["peers"]).To(HaveLen(1))
+				Expect(channel2["peers"]).To(HaveKey("org2peer-api.127-0-0-1.nip.io:8080"))
+			})
+		})
+
+		When("called with a YAML Accept header", func() {
+			It("returns the connection profile as YAML", func() {
+				req, err := http.NewRequest("GET", consoleURL+"/ak/api/v1/organizations/Org2/connection-profile", nil)
+				Expect(err).NotTo(HaveOccurred())
+				req.Header.Set("Accept", "application/yaml")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(200))
+				Expect(resp.Header.Get("Content-Type")).To(Equal("application/x-yaml"))
+				data, err := ioutil.ReadAll(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				profile := map[string]interface{}{}
+				Expect(yaml.Unmarshal(data, &profile)).To(Succeed())
+				Expect(profile["name"]).To(Equal("microfab-org2"))
+			})
+		})
+
+		When("called for an organization that does not exist", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/organizations/Org3/connection-profile")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+	})
+
+	Context("GET /ak/api/v1/organizations/{org}/bundle", func() {
+
+		readTarGz := func(data []byte) map[string][]byte {
+			gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+			tarReader := tar.NewReader(gzipReader)
+			files := map[string][]byte{}
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).NotTo(HaveOccurred())
+				if header.Typeflag == tar.TypeReg {
+					files[header.Name], err = ioutil.ReadAll(tarReader)
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+			return files
+		}
+
+		When("called for an organization with a peer", func() {
+			It("returns a tar.gz bundle with the admin MSP, wallet, connection profile and environment", func() {
+				status, data := get("/ak/api/v1/organizations/Org1/bundle")
+				Expect(status).To(Equal(200))
+				files := readTarGz(data)
+				Expect(files).To(HaveKeyWithValue("org1/msp/signcerts/cert.pem", org1.Admin().Certificate().Bytes()))
+				Expect(files).To(HaveKeyWithValue("org1/msp/keystore/key.pem", org1.Admin().PrivateKey().Bytes()))
+				Expect(files).To(HaveKeyWithValue("org1/msp/cacerts/ca.pem", org1.CA().Certificate().Bytes()))
+				Expect(files).To(HaveKey("org1/msp/config.yaml"))
+				Expect(files).NotTo(HaveKey("org1/tls/ca.pem"))
+				wallet := map[string]interface{}{}
+				Expect(json.Unmarshal(files["org1/wallet/Org1 Admin.id"], &wallet)).To(Succeed())
+				Expect(wallet["mspId"]).To(Equal("Org1MSP"))
+				Expect(wallet["type"]).To(Equal("X.509"))
+				profile := map[string]interface{}{}
+				Expect(json.Unmarshal(files["org1/connection.json"], &profile)).To(Succeed())
+				Expect(profile["id"]).To(Equal("org1gateway"))
+				env := map[string]string{}
+				Expect(json.Unmarshal(files["org1/env.json"], &env)).To(Succeed())
+				Expect(env).To(Equal(map[string]string{
+					"CORE_PEER_LOCALMSPID":    "Org1MSP",
+					"CORE_PEER_MSPCONFIGPATH": "msp",
+					"CORE_PEER_ADDRESS":       "org1peer-api.127-0-0-1.nip.io:8080",
+					"CORE_PEER_TLS_ENABLED":   "false",
+					"ORDERER_ADDRESS":         "orderer-api.127-0-0-1.nip.io:8080",
+				}))
+				Expect(string(files["org1/.env"])).To(ContainSubstring("CORE_PEER_ADDRESS=org1peer-api.127-0-0-1.nip.io:8080\n"))
+				Expect(string(files["org1/env.sh"])).To(ContainSubstring("export CORE_PEER_MSPCONFIGPATH=\"${BUNDLE_DIR}/msp\"\n"))
+			})
+		})
+
+		When("called for an organization with TLS enabled", func() {
+			It("returns a bundle with the TLS CA certificates", func() {
+				tlsCA, err := identity.New("TLS CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				tls, err := identity.New("TLS", identity.UsingSigner(tlsCA))
+				Expect(err).NotTo(HaveOccurred())
+				peer1.EnableTLS(tls)
+				testOrderer.EnableTLS(tls)
+				status, data := get("/ak/api/v1/organizations/org1/bundle")
+				Expect(status).To(Equal(200))
+				files := readTarGz(data)
+				Expect(files).To(HaveKeyWithValue("org1/tls/ca.pem", tlsCA.Certificate().Bytes()))
+				Expect(files).To(HaveKeyWithValue("org1/tls/orderer-ca.pem", tlsCA.Certificate().Bytes()))
+				env := map[string]string{}
+				Expect(json.Unmarshal(files["org1/env.json"], &env)).To(Succeed())
+				Expect(env).To(HaveKeyWithValue("CORE_PEER_TLS_ENABLED", "true"))
+				Expect(env).To(HaveKeyWithValue("CORE_PEER_TLS_ROOTCERT_FILE", "tls/ca.pem"))
+				Expect(env).To(HaveKeyWithValue("ORDERER_CA", "tls/orderer-ca.pem"))
+			})
+		})
+
+		When("called with format=zip", func() {
+			It("returns a zip bundle", func() {
+				status, data := get("/ak/api/v1/organizations/Orderer/bundle?format=zip")
+				Expect(status).To(Equal(200))
+				zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				Expect(err).NotTo(HaveOccurred())
+				names := []string{}
+				for _, file := range zipReader.File {
+					names = append(names, file.Name)
+				}
+				Expect(names).To(ContainElements("orderer/msp/signcerts/cert.pem", "orderer/wallet/Orderer Admin.id", "orderer/env.sh", "orderer/.env", "orderer/env.json"))
+				Expect(names).NotTo(ContainElement("orderer/connection.json"))
+			})
+		})
+
+		When("called for an organization that does not exist", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/ak/api/v1/organizations/Org3/bundle")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+		When("called with an invalid format", func() {
+			It("returns a bad request error", func() {
+				status, _ := get("/ak/api/v1/organizations/Org1/bundle?format=rar")
+				Expect(status).To(Equal(400))
+			})
+		})
+
+	})
+
+	post := func(path string, body string) (int, []byte) {
+		resp, err := http.Post(consoleURL+path, "application/json", strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp.StatusCode, data
+	}
+
+	Context("POST /ak/api/v1/benchmark", func() {
+
+		When("called with an invalid request", func() {
+			It("returns a bad request error", func() {
+				status, _ := post("/ak/api/v1/benchmark", "{")
+				Expect(status).To(Equal(400))
+			})
+		})
+
+		When("called with too many transactions or too much concurrency", func() {
+			It("returns a bad request error", func() {
+				status, data := post("/ak/api/v1/benchmark", `{"channel":"channel1","chaincode":"asset","function":"get","transactions":100001}`)
+				Expect(status).To(Equal(400))
+				Expect(string(data)).To(ContainSubstring("Number of transactions must be at most 100000"))
+				status, data = post("/ak/api/v1/benchmark", `{"channel":"channel1","chaincode":"asset","function":"get","concurrency":101}`)
+				Expect(status).To(Equal(400))
+				Expect(string(data)).To(ContainSubstring("Concurrency must be at most 100"))
+			})
+		})
+
+		When("called for a channel that does not exist", func() {
+			It("returns a not found error", func() {
+				status, data := post("/ak/api/v1/benchmark", `{"channel":"channel3","chaincode":"asset","function":"get"}`)
+				Expect(status).To(Equal(404))
+				Expect(string(data)).To(ContainSubstring("Channel channel3 not found"))
+			})
+		})
+
+		When("called for an organization that is not a member of the channel", func() {
+			It("returns a bad request error", func() {
+				status, data := post("/ak/api/v1/benchmark", `{"channel":"channel2","chaincode":"asset","function":"get","organizations":["Org1"]}`)
+				Expect(status).To(Equal(400))
+				Expect(string(data)).To(ContainSubstring("Organization Org1 is not a member of channel channel2"))
+			})
+		})
+
+		When("called for one organization in a channel with several organizations", func() {
+			It("only submits transactions as that organization", func() {
+				stopMocks := startMocks()
+				defer stopMocks()
+				status, data := post("/ak/api/v1/benchmark", `{"channel":"channel1","chaincode":"asset","function":"get","transactions":2,"concurrency":1,"organizations":["Org1"]}`)
+				Expect(status).To(Equal(200))
+				report := &loadgen.Report{}
+				Expect(json.Unmarshal(data, report)).To(Succeed())
+				Expect(report.Organizations).To(HaveLen(1))
+				Expect(report.Organizations[0].Organization).To(Equal("Org1"))
+				Expect(report.Organizations[0].Transactions).To(Equal(2))
+			})
+		})
+
+	})
+
+	getList := func(path string) []map[string]interface{} {
+		status, data := get(path)
+		Expect(status).To(Equal(200))
+		result := []map[string]interface{}{}
+		Expect(json.Unmarshal(data, &result)).To(Succeed())
+		return result
+	}
+
+	ids := func(resources []map[string]interface{}) []string {
+		result := []string{}
+		for _, resource := range resources {
+			result = append(result, resource["id"].(string))
+		}
+		return result
+	}
+
+	Context("GET /api/v2/organizations", func() {
+
+		When("called", func() {
+			It("returns every organization, sorted by ID", func() {
+				organizations := getList("/api/v2/organizations")
+				Expect(ids(organizations)).To(Equal([]string{"orderer", "org1", "org2"}))
+				Expect(organizations[0]["orderers"]).To(Equal([]interface{}{"orderer"}))
+				Expect(organizations[0]["peers"]).To(BeEmpty())
+				Expect(organizations[1]).To(Equal(map[string]interface{}{
+					"id":         "org1",
+					"name":       "Org1",
+					"msp_id":     "Org1MSP",
+					"admin":      "org1admin",
+					"identities": []interface{}{"org1admin"},
+					"peers":      []interface{}{"org1peer"},
+					"orderers":   []interface{}{},
+					"cas":        []interface{}{},
+					"gateways":   []interface{}{"org1gateway"},
+				}))
+			})
+		})
+
+	})
+
+	Context("GET /api/v2/{collection}/{id}", func() {
+
+		When("called for a peer", func() {
+			It("returns the peer", func() {
+				status, data := get("/api/v2/peers/org1peer")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["organization"]).To(Equal("org1"))
+				Expect(result["msp_id"]).To(Equal("Org1MSP"))
+				Expect(result["api_url"]).To(Equal("grpc://org1peer-api.127-0-0-1.nip.io:8080"))
+				Expect(result["identity"]).To(Equal("org1admin"))
+				Expect(result).NotTo(HaveKey("type"))
+				Expect(result).NotTo(HaveKey("wallet"))
+			})
+		})
+
+		When("called for an orderer", func() {
+			It("returns the orderer", func() {
+				status, data := get("/api/v2/orderers/orderer")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["organization"]).To(Equal("orderer"))
+				Expect(result["remote"]).To(BeFalse())
+				Expect(result["operations_url"]).To(Equal("http://orderer-operations.127-0-0-1.nip.io:8080"))
+			})
+		})
+
+		When("called for an identity", func() {
+			It("returns the identity", func() {
+				status, data := get("/api/v2/identities/org2admin")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["name"]).To(Equal(org2.Admin().Name()))
+				Expect(result["organization"]).To(Equal("org2"))
+				Expect(result["admin"]).To(BeTrue())
+				Expect(result["cert"]).To(Equal(base64.StdEncoding.EncodeToString(org2.Admin().Certificate().Bytes())))
+			})
+		})
+
+		When("called for a gateway", func() {
+			It("returns the gateway with its connection profile", func() {
+				status, data := get("/api/v2/gateways/org1gateway")
+				Expect(status).To(Equal(200))
+				result := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &result)).To(Succeed())
+				Expect(result["peer"]).To(Equal("org1peer"))
+				profile := result["connection_profile"].(map[string]interface{})
+				Expect(profile["name"]).To(Equal("Org1 Gateway"))
+				Expect(profile["peers"]).To(HaveKey("org1peer-api.127-0-0-1.nip.io:8080"))
+				Expect(profile).NotTo(HaveKey("wallet"))
+			})
+		})
+
+		When("called for a resource that does not exist", func() {
+			It("returns a not found error", func() {
+				status, _ := get("/api/v2/peers/org3peer")
+				Expect(status).To(Equal(404))
+				status, _ = get("/api/v2/cas/org1ca")
+				Expect(status).To(Equal(404))
+			})
+		})
+
+	})
+
+	Context("GET /api/v2/openapi.json", func() {
+
+		When("called", func() {
+			It("returns an OpenAPI document that describes every collection", func() {
+				status, data := get("/api/v2/openapi.json")
+				Expect(status).To(Equal(200))
+				document := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &document)).To(Succeed())
+				Expect(document["openapi"]).To(HavePrefix("3."))
+				Expect(document["servers"]).To(Equal([]interface{}{map[string]interface{}{"url": consoleURL}}))
+				paths := document["paths"].(map[string]interface{})
+				Expect(paths).To(HaveLen(12))
+				for path := range paths {
+					if strings.HasSuffix(path, "/{id}") {
+						continue
+					}
+					status, _ := get(path)
+					Expect(status).To(Equal(200), path)
+					Expect(paths).To(HaveKey(path + "/{id}"))
+				}
+			})
+		})
+
+		When("called with format=yaml", func() {
+			It("returns the OpenAPI document as YAML", func() {
+				status, data := get("/api/v2/openapi.json?format=yaml")
+				Expect(status).To(Equal(200))
+				document := map[string]interface{}{}
+				Expect(yaml.Unmarshal(data, &document)).To(Succeed())
+				Expect(document).To(HaveKey("paths"))
+			})
+		})
+
+	})
+
+	Context("authentication", func() {
+
+		getWith := func(path string, prepare func(req *http.Request)) (int, []byte) {
+			req, err := http.NewRequest("GET", consoleURL+path, nil)
+			Expect(err).NotTo(HaveOccurred())
+			prepare(req)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			data, err := ioutil.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			return resp.StatusCode, data
+		}
+
+		withToken := func(token string) func(req *http.Request) {
+			return func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+
+		identityIDs := func(data []byte) []string {
+			components := []map[string]interface{}{}
+			Expect(json.Unmarshal(data, &components)).To(Succeed())
+			result := []string{}
+			for _, component := range components {
+				if component["type"] == "identity" {
+					result = append(result, component["id"].(string))
+				}
+			}
+			return result
+		}
+
+		When("authentication is not enabled", func() {
+			It("allows every request", func() {
+				status, data := get("/ak/api/v1/components")
+				Expect(status).To(Equal(200))
+				Expect(identityIDs(data)).To(ConsistOf("ordereradmin", "org1admin", "org2admin"))
+			})
+		})
+
+		When("tokens are configured", func() {
+
+			BeforeEach(func() {
+				testConsole.AddToken("admin-token")
+				testConsole.AddToken("org1-token", "Org1")
+			})
+
+			It("rejects requests without a token", func() {
+				status, _ := get("/ak/api/v1/components")
+				Expect(status).To(Equal(401))
+				status, _ = getWith("/ak/api/v1/components", withToken("wrong-token"))
+				Expect(status).To(Equal(401))
+			})
+
+			It("allows requests for the health of the console without a token", func() {
+				status, _ := get("/ak/api/v1/health")
+				Expect(status).NotTo(Equal(401))
+				status, _ = get("/ak/api/v1/ready")
+				Expect(status).NotTo(Equal(401))
+			})
+
+			It("allows a token for every organization to access every organization", func() {
+				status, data := getWith("/ak/api/v1/components", withToken("admin-token"))
+				Expect(status).To(Equal(200))
+				Expect(identityIDs(data)).To(ConsistOf("ordereradmin", "org1admin", "org2admin"))
+				status, _ = getWith("/ak/api/v1/organizations/Org2/connection-profile", withToken("admin-token"))
+				Expect(status).To(Equal(200))
+			})
+
+			It("only allows a token for an organization to access that organization", func() {
+				status, data := getWith("/ak/api/v1/components", withToken("org1-token"))
+				Expect(status).To(Equal(200))
+				Expect(identityIDs(data)).To(ConsistOf("org1admin"))
+				status, _ = getWith("/ak/api/v1/components/org2admin", withToken("org1-token"))
+				Expect(status).To(Equal(404))
+				status, _ = getWith("/ak/api/v1/organizations/Org1/connection-profile", withToken("org1-token"))
+				Expect(status).To(Equal(200))
+				status, _ = getWith("/ak/api/v1/organizations/Org2/connection-profile", withToken("org1-token"))
+				Expect(status).To(Equal(403))
+				status, _ = getWith("/ak/api/v1/organizations/Org2/bundle", withToken("org1-token"))
+				Expect(status).To(Equal(403))
+				status, _ = getWith("/ak/api/v1/explorer/connection-profile?organization=Org2", withToken("org1-token"))
+				Expect(status).To(Equal(403))
+				status, data = getWith("/ak/api/v1/caliper/network", withToken("org1-token"))
+				Expect(status).To(Equal(200))
+				config := map[string]interface{}{}
+				Expect(json.Unmarshal(data, &config)).To(Succeed())
+				Expect(config["organizations"]).To(HaveLen(1))
+				Expect(config["organizations"].([]interface{})[0]).To(HaveKeyWithValue("mspid", "Org1MSP"))
+			})
+
+			It("only returns the version 2 resources of the organizations that a token can access", func() {
+				status, data := getWith("/api/v2/organizations", withToken("org1-token"))
+				Expect(status).To(Equal(200))
+				organizations := []map[string]interface{}{}
+				Expect(json.Unmarshal(data, &organizations)).To(Succeed())
+				Expect(organizations).To(HaveLen(1))
+				Expect(organizations[0]["id"]).To(Equal("org1"))
+				status, _ = getWith("/api/v2/peers/org1peer", withToken("org1-token"))
+				Expect(status).To(Equal(200))
+				status, _ = getWith("/api/v2/identities/org2admin", withToken("org1-token"))
+				Expect(status).To(Equal(404))
+				status, _ = get("/api/v2/peers")
+				Expect(status).To(Equal(401))
+				status, _ = get("/api/v2/openapi.json")
+				Expect(status).To(Equal(200))
+			})
+
+			It("does not accept the token as a query parameter", func() {
+				status, _ := get("/ak/api/v1/components?token=org1-token")
+				Expect(status).To(Equal(401))
+			})
+
+		})
+
+		When("users are configured", func() {
+
+			BeforeEach(func() {
+				testConsole.AddUser("org2", "secret", "Org2")
+			})
+
+			It("rejects requests without valid credentials", func() {
+				status, _ := get("/ak/api/v1/components")
+				Expect(status).To(Equal(401))
+				status, _ = getWith("/ak/api/v1/components", func(req *http.Request) {
+					req.SetBasicAuth("org2", "wrong")
+				})
+				Expect(status).To(Equal(401))
+			})
+
+			It("only allows a user to access the organizations of that user", func() {
+				status, data := getWith("/ak/api/v1/components", func(req *http.Request) {
+					req.SetBasicAuth("org2", "secret")
+				})
+				Expect(status).To(Equal(200))
+				Expect(identityIDs(data)).To(ConsistOf("org2admin"))
+			})
+
+		})
+
+		When("client certificates are enabled", func() {
+
+			var tlsCA *identity.Identity
+
+			BeforeEach(func() {
+				var err error
+				tlsCA, err = identity.New("TLS CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				tls, err := identity.New("TLS", identity.UsingSigner(tlsCA))
+				Expect(err).NotTo(HaveOccurred())
+				testConsole.EnableClientCertificates(tls)
+			})
+
+			withCertificate := func(id *identity.Identity) func(req *http.Request) {
+				return func(req *http.Request) {
+					req.Header.Set(console.ClientCertificateHeader, base64.StdEncoding.EncodeToString(id.Certificate().Certificate().Raw))
+					req.Header.Set(console.ProxySecretHeader, testConsole.ProxySecret())
+				}
+			}
+
+			It("allows a certificate issued by the TLS CA to access every organization", func() {
+				client, err := identity.New("Client", identity.UsingSigner(tlsCA))
+				Expect(err).NotTo(HaveOccurred())
+				status, data := getWith("/ak/api/v1/components", withCertificate(client))
+				Expect(status).To(Equal(200))
+				Expect(identityIDs(data)).To(ConsistOf("ordereradmin", "org1admin", "org2admin"))
+			})
+
+			It("rejects a certificate issued by another CA", func() {
+				otherCA, err := identity.New("Other CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				client, err := identity.New("Client", identity.UsingSigner(otherCA))
+				Expect(err).NotTo(HaveOccurred())
+				status, _ := getWith("/ak/api/v1/components", withCertificate(client))
+				Expect(status).To(Equal(401))
+			})
+
+			It("rejects a certificate issued by the TLS CA that cannot be used for client authentication", func() {
+				template := &x509.Certificate{
+					SerialNumber: big.NewInt(1),
+					Subject:      pkix.Name{CommonName: "Server"},
+					NotBefore:    time.Now().Add(-time.Hour),
+					NotAfter:     time.Now().Add(time.Hour),
+					KeyUsage:     x509.KeyUsageDigitalSignature,
+					ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				}
+				privateKey := tlsCA.PrivateKey().PrivateKey()
+				data, err := x509.CreateCertificate(rand.Reader, template, tlsCA.Certificate().Certificate(), &privateKey.PublicKey, privateKey)
+				Expect(err).NotTo(HaveOccurred())
+				status, _ := getWith("/ak/api/v1/components", func(req *http.Request) {
+					req.Header.Set(console.ClientCertificateHeader, base64.StdEncoding.EncodeToString(data))
+					req.Header.Set(console.ProxySecretHeader, testConsole.ProxySecret())
+				})
+				Expect(status).To(Equal(401))
+			})
+
+			It("rejects a certificate that was not passed on by the proxy", func() {
+				client, err := identity.New("Client", identity.UsingSigner(tlsCA))
+				Expect(err).NotTo(HaveOccurred())
+				status, _ := getWith("/ak/api/v1/components", func(req *http.Request) {
+					req.Header.Set(console.ClientCertificateHeader, base64.StdEncoding.EncodeToString(client.Certificate().Certificate().Raw))
+				})
+				Expect(status).To(Equal(401))
+				status, _ = getWith("/ak/api/v1/components", func(req *http.Request) {
+					req.Header.Set(console.ClientCertificateHeader, base64.StdEncoding.EncodeToString(client.Certificate().Certificate().Raw))
+					req.Header.Set(console.ProxySecretHeader, "guess")
+				})
+				Expect(status).To(Equal(401))
+			})
+
+		})
+
+	})
+
+})
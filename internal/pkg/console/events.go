@@ -0,0 +1,201 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger-labs/microfab/internal/pkg/explorer"
+	"github.com/hyperledger/fabric-protos-go/common"
+)
+
+// eventsKeepaliveInterval is how often a comment is sent to clients streaming events, so that proxies and clients do
+// not close the connection while no blocks are being committed.
+const eventsKeepaliveInterval = 15 * time.Second
+
+type jsonBlockSummary struct {
+	Number       uint64 `json:"number"`
+	DataHash     string `json:"data_hash"`
+	PreviousHash string `json:"previous_hash"`
+	Transactions int    `json:"transactions"`
+}
+
+type jsonTransactionSummary struct {
+	TxID           string             `json:"tx_id"`
+	Type           string             `json:"type"`
+	BlockNumber    uint64             `json:"block_number"`
+	ValidationCode string             `json:"validation_code"`
+	Creator        *explorer.Identity `json:"creator"`
+	Chaincodes     []string           `json:"chaincodes"`
+}
+
+type jsonChaincodeEvent struct {
+	TxID           string `json:"tx_id"`
+	BlockNumber    uint64 `json:"block_number"`
+	ValidationCode string `json:"validation_code"`
+	Chaincode      string `json:"chaincode"`
+	Name           string `json:"name"`
+	Payload        string `json:"payload"`
+}
+
+// getEvents streams the blocks, transactions and chaincode events in a channel as server-sent events, as they are
+// committed. The chaincode and event query parameters filter the transactions and chaincode events, and the start
+// query parameter replays the channel from the specified block number.
+func (c *Console) getEvents(rw http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["name"]
+	query := req.URL.Query()
+	chaincode, eventName := query.Get("chaincode"), query.Get("event")
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "Streaming is not supported", 500)
+		return
+	}
+	p, closeAll, ok := c.firstChannelPeer(rw, name)
+	defer closeAll()
+	if !ok {
+		return
+	}
+	var start uint64
+	if value := query.Get("start"); value != "" {
+		var err error
+		start, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("Invalid start block number %s", value), 400)
+			return
+		}
+	} else {
+		info, err := p.connection.GetChainInfo(name)
+		if err != nil {
+			http.Error(rw, err.Error(), 500)
+			return
+		}
+		start = info.Height
+	}
+	rw.Header().Add("Content-Type", "text/event-stream")
+	rw.Header().Add("Cache-Control", "no-cache")
+	rw.WriteHeader(200)
+	flusher.Flush()
+	var lock sync.Mutex
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		keepEventsAlive(rw, flusher, &lock, done)
+	}()
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+	err := blocks.StreamBlocks(req.Context(), p.connection, name, start, func(block *common.Block) error {
+		lock.Lock()
+		defer lock.Unlock()
+		decoded, err := explorer.DecodeBlock(block)
+		if err != nil {
+			return err
+		}
+		err = writeEvent(rw, "block", &jsonBlockSummary{
+			Number:       decoded.Number,
+			DataHash:     decoded.DataHash,
+			PreviousHash: decoded.PreviousHash,
+			Transactions: len(decoded.Transactions),
+		})
+		if err != nil {
+			return err
+		}
+		for _, transaction := range decoded.Transactions {
+			err = writeTransactionEvents(rw, transaction, chaincode, eventName)
+			if err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && req.Context().Err() == nil {
+		logger.Printf("Stopped streaming events for channel %s: %v", name, err)
+	}
+}
+
+// keepEventsAlive writes a comment to the event stream at regular intervals until done is closed, or a write fails
+// because the client has disconnected.
+func keepEventsAlive(rw http.ResponseWriter, flusher http.Flusher, lock *sync.Mutex, done <-chan struct{}) {
+	ticker := time.NewTicker(eventsKeepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			lock.Lock()
+			_, err := fmt.Fprint(rw, ": keepalive\n\n")
+			if err == nil {
+				flusher.Flush()
+			}
+			lock.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeTransactionEvents writes the transaction, and the chaincode events in it, unless they do not match the
+// specified chaincode and event name filters.
+func writeTransactionEvents(rw http.ResponseWriter, transaction *explorer.Transaction, chaincode, eventName string) error {
+	summary := &jsonTransactionSummary{
+		TxID:           transaction.TxID,
+		Type:           transaction.Type,
+		BlockNumber:    transaction.BlockNumber,
+		ValidationCode: transaction.ValidationCode,
+		Creator:        transaction.Creator,
+		Chaincodes:     []string{},
+	}
+	matched := chaincode == ""
+	for _, action := range transaction.Actions {
+		summary.Chaincodes = append(summary.Chaincodes, action.Chaincode)
+		if action.Chaincode == chaincode {
+			matched = true
+		}
+	}
+	if !matched {
+		return nil
+	}
+	if err := writeEvent(rw, "transaction", summary); err != nil {
+		return err
+	}
+	for _, action := range transaction.Actions {
+		if action.Event == nil || (chaincode != "" && action.Chaincode != chaincode) || (eventName != "" && action.Event.Name != eventName) {
+			continue
+		}
+		err := writeEvent(rw, "chaincode_event", &jsonChaincodeEvent{
+			TxID:           transaction.TxID,
+			BlockNumber:    transaction.BlockNumber,
+			ValidationCode: transaction.ValidationCode,
+			Chaincode:      action.Chaincode,
+			Name:           action.Event.Name,
+			Payload:        action.Event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeEvent(rw http.ResponseWriter, event string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
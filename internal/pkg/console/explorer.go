@@ -0,0 +1,111 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger-labs/microfab/internal/pkg/explorer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger/fabric-protos-go/common"
+)
+
+// firstChannelPeer returns the first peer that has joined the specified channel, or writes a not found error if no
+// peer has joined the channel. Only the connection to that peer is kept open, as it may be used to stream events
+// for as long as the client is connected.
+func (c *Console) firstChannelPeer(rw http.ResponseWriter, name string) (*channelPeer, func(), bool) {
+	channelPeers, closeAll, err := c.channelPeers()
+	closeAll()
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return nil, func() {}, false
+	}
+	peers, ok := channelPeers[name]
+	if !ok {
+		http.Error(rw, fmt.Sprintf("Channel %s not found", name), 404)
+		return nil, func() {}, false
+	}
+	organization := peers[0].peer.Organization()
+	connection, err := peer.Connect(peers[0].peer, organization.MSPID(), organization.Admin())
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return nil, func() {}, false
+	}
+	return &channelPeer{peers[0].peer, connection}, func() { connection.Close() }, true
+}
+
+func (c *Console) getBlock(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	name, number := vars["name"], vars["number"]
+	p, closeAll, ok := c.firstChannelPeer(rw, name)
+	defer closeAll()
+	if !ok {
+		return
+	}
+	info, err := p.connection.GetChainInfo(name)
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	var block *common.Block
+	if number == "newest" {
+		block, err = blocks.GetNewestBlock(p.connection, name)
+	} else {
+		parsedNumber, parseErr := strconv.ParseUint(number, 10, 64)
+		if parseErr != nil {
+			http.Error(rw, fmt.Sprintf("Invalid block number %s, must be a number or newest", number), 400)
+			return
+		} else if parsedNumber >= info.Height {
+			// Requesting a block that does not exist yet would wait until that block is committed.
+			http.Error(rw, fmt.Sprintf("Block %d not found in channel %s, height is %d", parsedNumber, name, info.Height), 404)
+			return
+		}
+		block, err = blocks.GetSpecificBlock(p.connection, name, parsedNumber)
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	result, err := explorer.DecodeBlock(block)
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(result)
+}
+
+func (c *Console) getTransaction(rw http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	name, txID := vars["name"], vars["txid"]
+	p, closeAll, ok := c.firstChannelPeer(rw, name)
+	defer closeAll()
+	if !ok {
+		return
+	}
+	block, err := p.connection.GetBlockByTxID(name, txID)
+	if peer.IsTransactionNotFound(err) {
+		http.Error(rw, fmt.Sprintf("Transaction %s not found in channel %s", txID, name), 404)
+		return
+	} else if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	result, err := explorer.FindTransaction(block, txID)
+	if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	} else if result == nil {
+		http.Error(rw, fmt.Sprintf("Transaction %s not found in channel %s", txID, name), 404)
+		return
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(result)
+}
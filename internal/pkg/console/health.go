@@ -0,0 +1,193 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthTimeout is how long the console waits for a component to report its health.
+const healthTimeout = 5 * time.Second
+
+var healthClient = &http.Client{
+	Timeout: healthTimeout,
+	Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	},
+}
+
+type jsonHealth struct {
+	Status     string                 `json:"status"`
+	Components []*jsonComponentHealth `json:"components"`
+}
+
+type jsonComponentHealth struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+type jsonReadiness struct {
+	Ready    bool                `json:"ready"`
+	Reason   string              `json:"reason,omitempty"`
+	Channels []*jsonChannelReady `json:"channels"`
+}
+
+type jsonChannelReady struct {
+	Name          string   `json:"name"`
+	Joined        bool     `json:"joined"`
+	Organizations []string `json:"organizations"`
+}
+
+type healthCheck struct {
+	id            string
+	componentType string
+	url           *url.URL
+}
+
+// MarkReady marks the console as ready, once all of the channels have been created and joined.
+func (c *Console) MarkReady() {
+	c.Lock()
+	defer c.Unlock()
+	c.ready = true
+}
+
+func (c *Console) isReady() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.ready
+}
+
+// healthChecks returns the URL that reports the health of every component that runs inside Microfab.
+func (c *Console) healthChecks() []*healthCheck {
+	result := []*healthCheck{}
+	if c.orderer != nil && !c.orderer.Remote() {
+		result = append(result, &healthCheck{"orderer", "fabric-orderer", c.orderer.OperationsURL(true).ResolveReference(&url.URL{Path: "/healthz"})})
+	}
+	for _, peer := range c.peers {
+		id := fmt.Sprintf("%speer", strings.ToLower(peer.Organization().Name()))
+		result = append(result, &healthCheck{id, "fabric-peer", peer.OperationsURL(true).ResolveReference(&url.URL{Path: "/healthz"})})
+	}
+	for _, ca := range c.cas {
+		id := fmt.Sprintf("%sca", strings.ToLower(ca.Organization().Name()))
+		result = append(result, &healthCheck{id, "fabric-ca", ca.OperationsURL(true).ResolveReference(&url.URL{Path: "/healthz"})})
+	}
+	if c.couchDB != nil {
+		result = append(result, &healthCheck{"couchdb", "couchdb", c.couchDB.URL(true).ResolveReference(&url.URL{Path: "/_up"})})
+	}
+	return result
+}
+
+func checkHealth(check *healthCheck) *jsonComponentHealth {
+	result := &jsonComponentHealth{ID: check.id, Type: check.componentType, Status: "OK"}
+	start := time.Now()
+	resp, err := healthClient.Get(check.url.String())
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Status = "UNAVAILABLE"
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		result.Status = "UNAVAILABLE"
+		result.Error = fmt.Sprintf("Health check returned status code %d", resp.StatusCode)
+	}
+	return result
+}
+
+// getHealth checks the health of every component concurrently, and returns a service unavailable error if any of
+// them are not healthy.
+func (c *Console) getHealth(rw http.ResponseWriter, req *http.Request) {
+	checks := c.healthChecks()
+	result := &jsonHealth{Status: "OK", Components: make([]*jsonComponentHealth, len(checks))}
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result.Components[i] = checkHealth(checks[i])
+		}(i)
+	}
+	wg.Wait()
+	status := 200
+	for _, component := range result.Components {
+		if component.Status != "OK" {
+			result.Status = "UNAVAILABLE"
+			status = 503
+		}
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(result)
+}
+
+// getReady returns a service unavailable error until Microfab has created all of the channels, and a peer in every
+// member organization has joined each of them.
+func (c *Console) getReady(rw http.ResponseWriter, req *http.Request) {
+	result := &jsonReadiness{Ready: true, Channels: []*jsonChannelReady{}}
+	if !c.isReady() {
+		result.Ready = false
+		result.Reason = "Microfab is still starting"
+	} else {
+		channelPeers, closeAll, err := c.channelPeers()
+		defer closeAll()
+		if err != nil {
+			http.Error(rw, err.Error(), 500)
+			return
+		}
+		for _, channel := range c.channels {
+			joined := map[string]bool{}
+			for _, p := range channelPeers[channel.name] {
+				joined[p.peer.Organization().Name()] = true
+			}
+			channelReady := &jsonChannelReady{Name: channel.name, Joined: true, Organizations: []string{}}
+			for _, organization := range channel.organizations {
+				if !c.hasPeer(organization.Name()) {
+					continue
+				}
+				if joined[organization.Name()] {
+					channelReady.Organizations = append(channelReady.Organizations, organization.Name())
+				} else {
+					channelReady.Joined = false
+				}
+			}
+			sort.Strings(channelReady.Organizations)
+			if !channelReady.Joined && result.Ready {
+				result.Ready = false
+				result.Reason = fmt.Sprintf("Channel %s has not been joined by every member organization", channel.name)
+			}
+			result.Channels = append(result.Channels, channelReady)
+		}
+	}
+	status := 200
+	if !result.Ready {
+		status = 503
+	}
+	rw.Header().Add("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(result)
+}
+
+func (c *Console) hasPeer(organization string) bool {
+	for _, peer := range c.peers {
+		if peer.Organization().Name() == organization {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
+)
+
+// followInterval is how often the log file of a component is checked for new lines in follow mode.
+const followInterval = 250 * time.Millisecond
+
+type logFilter struct {
+	since time.Time
+	grep  *regexp.Regexp
+}
+
+func (f *logFilter) matches(line string) bool {
+	if !f.since.IsZero() {
+		logged, ok := logging.LineTime(line)
+		if ok && logged.Before(f.since) {
+			return false
+		}
+	}
+	return f.grep == nil || f.grep.MatchString(line)
+}
+
+type componentLog struct {
+	file         string
+	organization string
+}
+
+// logFiles returns the log file, and the organization, of every component that runs inside Microfab, keyed by
+// component ID.
+func (c *Console) logFiles() map[string]*componentLog {
+	result := map[string]*componentLog{}
+	if c.orderer != nil && !c.orderer.Remote() {
+		result["orderer"] = &componentLog{c.orderer.LogFile(), c.orderer.Organization().Name()}
+	}
+	for _, peer := range c.peers {
+		if peer.External() {
+			continue
+		}
+		organization := peer.Organization().Name()
+		result[fmt.Sprintf("%speer", strings.ToLower(organization))] = &componentLog{peer.LogFile(), organization}
+	}
+	for _, ca := range c.cas {
+		organization := ca.Organization().Name()
+		result[fmt.Sprintf("%sca", strings.ToLower(organization))] = &componentLog{ca.LogFile(), organization}
+	}
+	return result
+}
+
+// getLogs returns the log of a component. The tail query parameter limits the response to the last lines, the since
+// query parameter (a timestamp or a duration) skips lines logged before that time, and the grep query parameter only
+// returns the lines that match a regular expression. If the follow query parameter is true, new lines are streamed as
+// they are logged until the client disconnects.
+func (c *Console) getLogs(rw http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	query := req.URL.Query()
+	entry, ok := c.logFiles()[id]
+	if !ok {
+		http.Error(rw, fmt.Sprintf("No logs found for component %s", id), 404)
+		return
+	} else if !checkAccess(rw, req, entry.organization) {
+		return
+	}
+	logFile := entry.file
+	tail := -1
+	if value := query.Get("tail"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(rw, fmt.Sprintf("Invalid tail %s, must be a number of lines", value), 400)
+			return
+		}
+		tail = parsed
+	}
+	filter := &logFilter{}
+	if value := query.Get("since"); value != "" {
+		since, err := parseSince(value)
+		if err != nil {
+			http.Error(rw, err.Error(), 400)
+			return
+		}
+		filter.since = since
+	}
+	if value := query.Get("grep"); value != "" {
+		grep, err := regexp.Compile(value)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("Invalid grep %s: %v", value, err), 400)
+			return
+		}
+		filter.grep = grep
+	}
+	follow := false
+	if value := query.Get("follow"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("Invalid follow %s, must be true or false", value), 400)
+			return
+		}
+		follow = parsed
+	}
+	lines := []string{}
+	offset, err := readLogLines(logFile, 0, func(line string) {
+		if !filter.matches(line) {
+			return
+		}
+		lines = append(lines, line)
+		if tail >= 0 && len(lines) > tail {
+			lines = lines[1:]
+		}
+	})
+	if os.IsNotExist(err) {
+		http.Error(rw, fmt.Sprintf("No logs found for component %s", id), 404)
+		return
+	} else if err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	rw.Header().Add("Content-Type", "text/plain; charset=utf-8")
+	if follow {
+		rw.Header().Add("Cache-Control", "no-cache")
+	}
+	rw.WriteHeader(200)
+	for _, line := range lines {
+		fmt.Fprintln(rw, line)
+	}
+	if !follow {
+		return
+	}
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		return
+	}
+	flusher.Flush()
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+			if info, err := os.Stat(logFile); err == nil && info.Size() < offset {
+				// The log file is truncated when the component is restarted.
+				offset = 0
+			}
+			offset, err = readLogLines(logFile, offset, func(line string) {
+				if filter.matches(line) {
+					fmt.Fprintln(rw, line)
+				}
+			})
+			if err != nil && !os.IsNotExist(err) {
+				logger.Printf("Stopped following logs for component %s: %v", id, err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// readLogLines calls the callback for every complete line in the specified file after the specified offset, and
+// returns the offset of the end of the last complete line. A partially written line is left for the next call.
+func readLogLines(logFile string, offset int64, callback func(line string)) (int64, error) {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return offset, nil
+		} else if err != nil {
+			return offset, err
+		}
+		offset += int64(len(line))
+		callback(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+// parseSince parses a since query parameter, which is either an RFC 3339 timestamp or a duration before now.
+func parseSince(value string) (time.Time, error) {
+	if since, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return since, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid since %s, must be a timestamp or a duration", value)
+	}
+	return time.Now().Add(-duration), nil
+}
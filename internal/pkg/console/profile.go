@@ -0,0 +1,119 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+)
+
+func (c *Console) getConnectionProfile(rw http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["org"]
+	var client *organization.Organization
+	for _, temp := range c.organizations {
+		if strings.EqualFold(temp.Name(), name) {
+			client = temp
+			break
+		}
+	}
+	if client == nil {
+		http.Error(rw, fmt.Sprintf("Organization %s not found", name), 404)
+		return
+	} else if !checkAccess(rw, req, client.Name()) {
+		return
+	}
+	c.writeConfig(rw, req, c.buildConnectionProfile(req, client))
+}
+
+// buildConnectionProfile builds a connection profile for the specified client organization that includes every
+// peer, orderer and CA in the network, and every channel with the roles of the peers in that channel, so that
+// clients can endorse transactions across organizations without using service discovery.
+func (c *Console) buildConnectionProfile(req *http.Request, client *organization.Organization) map[string]interface{} {
+	organizations := map[string]interface{}{}
+	for _, organization := range c.organizations {
+		organizations[organization.Name()] = map[string]interface{}{
+			"mspid":                  organization.MSPID(),
+			"peers":                  []interface{}{},
+			"orderers":               []interface{}{},
+			"certificateAuthorities": []interface{}{},
+		}
+	}
+	addToOrganization := func(organization *organization.Organization, key, host string) {
+		if temp, ok := organizations[organization.Name()]; ok {
+			entry := temp.(map[string]interface{})
+			entry[key] = append(entry[key].([]interface{}), host)
+		}
+	}
+	ordererHost := c.orderer.APIHost(false)
+	orderer := map[string]interface{}{
+		"url": c.getOrderer(req).APIURL,
+		"grpcOptions": map[string]interface{}{
+			"grpc.default_authority":        ordererHost,
+			"grpc.ssl_target_name_override": c.orderer.APIHostname(false),
+		},
+	}
+	if tls := c.orderer.TLS(); tls != nil {
+		orderer["tlsCACerts"] = map[string]string{
+			"pem": string(tls.CA().Bytes()),
+		}
+	}
+	addToOrganization(c.orderer.Organization(), "orderers", ordererHost)
+	peers := map[string]interface{}{}
+	for _, peer := range c.peers {
+		peers[peer.APIHost(false)] = c.getPeerProfile(req, peer)
+		addToOrganization(peer.Organization(), "peers", peer.APIHost(false))
+	}
+	cas := map[string]interface{}{}
+	for _, ca := range c.cas {
+		profile := c.getCAProfile(req, ca)
+		profile["caName"] = c.getCA(req, ca).ID
+		cas[ca.APIHost(false)] = profile
+		addToOrganization(ca.Organization(), "certificateAuthorities", ca.APIHost(false))
+	}
+	channels := map[string]interface{}{}
+	for _, channel := range c.channels {
+		channelPeers := map[string]interface{}{}
+		for _, peer := range c.peers {
+			for _, organization := range channel.organizations {
+				if peer.Organization() == organization {
+					channelPeers[peer.APIHost(false)] = map[string]interface{}{
+						"endorsingPeer":  true,
+						"chaincodeQuery": true,
+						"ledgerQuery":    true,
+						"eventSource":    true,
+					}
+				}
+			}
+		}
+		channels[channel.name] = map[string]interface{}{
+			"orderers": []interface{}{ordererHost},
+			"peers":    channelPeers,
+		}
+	}
+	return map[string]interface{}{
+		"name":    fmt.Sprintf("microfab-%s", strings.ToLower(client.Name())),
+		"version": "1.0.0",
+		"client": map[string]interface{}{
+			"organization": client.Name(),
+			"connection": map[string]interface{}{
+				"timeout": map[string]interface{}{
+					"peer": map[string]interface{}{
+						"endorser": "300",
+					},
+					"orderer": "300",
+				},
+			},
+		},
+		"organizations":          organizations,
+		"orderers":               map[string]interface{}{ordererHost: orderer},
+		"peers":                  peers,
+		"certificateAuthorities": cas,
+		"channels":               channels,
+	}
+}
@@ -0,0 +1,255 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"gopkg.in/yaml.v2"
+)
+
+type channel struct {
+	name          string
+	organizations []*organization.Organization
+}
+
+// RegisterChannel registers the specified channel, and the organizations that are members of it, with the console.
+func (c *Console) RegisterChannel(name string, organizations []*organization.Organization) {
+	c.channels = append(c.channels, &channel{name, organizations})
+}
+
+func (c *Console) getCaliperNetworkConfig(rw http.ResponseWriter, req *http.Request) {
+	c.writeConfig(rw, req, c.buildCaliperNetworkConfig(req))
+}
+
+func (c *Console) getExplorerConnectionProfile(rw http.ResponseWriter, req *http.Request) {
+	organizationName := req.URL.Query().Get("organization")
+	if organizationName != "" && !checkAccess(rw, req, organizationName) {
+		return
+	}
+	result, err := c.buildExplorerConnectionProfile(req, organizationName)
+	if err != nil {
+		rw.WriteHeader(404)
+		return
+	}
+	c.writeConfig(rw, req, result)
+}
+
+// writeConfig writes the configuration as JSON, or as YAML if the format query parameter is set to yaml. If the
+// format query parameter is not set, the Accept header chooses between JSON and YAML.
+func (c *Console) writeConfig(rw http.ResponseWriter, req *http.Request, config map[string]interface{}) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = acceptedFormat(req)
+	}
+	switch format {
+	case "", "json":
+		rw.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(config)
+	case "yaml":
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			rw.WriteHeader(500)
+			return
+		}
+		rw.Header().Add("Content-Type", "application/x-yaml")
+		rw.Write(data)
+	default:
+		rw.WriteHeader(400)
+	}
+}
+
+// acceptedFormat returns the first of json or yaml that is listed in the Accept header, or an empty string if the
+// Accept header lists neither.
+func acceptedFormat(req *http.Request) string {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return "json"
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			return "yaml"
+		}
+	}
+	return ""
+}
+
+// caliperContracts returns the chaincode definitions committed on the specified channel as Caliper contracts, or no
+// contracts if no peer has joined the channel.
+func caliperContracts(peers []*channelPeer, channel string) []interface{} {
+	result := []interface{}{}
+	if len(peers) == 0 {
+		return result
+	}
+	definitions, err := peers[0].connection.QueryChaincodeDefinitions(channel)
+	if err != nil {
+		logger.Printf("Failed to query the chaincode definitions on channel %s: %v", channel, err)
+		return result
+	}
+	for _, definition := range definitions {
+		result = append(result, map[string]interface{}{
+			"id": definition.Name,
+		})
+	}
+	return result
+}
+
+// caliperConnectionProfile returns the name of the connection profile file for the specified organization that
+// is referenced by the Caliper network configuration. The connection profile is the gateway component for the
+// organization.
+func caliperConnectionProfile(organization string) string {
+	return fmt.Sprintf("connection-%s.json", strings.ToLower(organization))
+}
+
+// buildCaliperNetworkConfig builds a Caliper network configuration for every organization with a peer that the client
+// that sent the request can access. The contracts in each channel are the chaincode definitions committed on it.
+func (c *Console) buildCaliperNetworkConfig(req *http.Request) map[string]interface{} {
+	channelPeers, closeAll, err := c.channelPeers()
+	defer closeAll()
+	if err != nil {
+		logger.Printf("Failed to find the contracts in each channel: %v", err)
+	}
+	channels := []interface{}{}
+	for _, channel := range c.channels {
+		channels = append(channels, map[string]interface{}{
+			"channelName": channel.name,
+			"contracts":   caliperContracts(channelPeers[channel.name], channel.name),
+		})
+	}
+	organizations := []interface{}{}
+	for _, peer := range c.peers {
+		organization := peer.Organization()
+		if !canAccess(req, organization.Name()) {
+			continue
+		}
+		admin := organization.Admin()
+		organizations = append(organizations, map[string]interface{}{
+			"mspid": organization.MSPID(),
+			"identities": map[string]interface{}{
+				"certificates": []interface{}{
+					map[string]interface{}{
+						"name":  admin.Name(),
+						"admin": true,
+						"clientPrivateKey": map[string]string{
+							"pem": string(admin.PrivateKey().Bytes()),
+						},
+						"clientSignedCert": map[string]string{
+							"pem": string(admin.Certificate().Bytes()),
+						},
+					},
+				},
+			},
+			"connectionProfile": map[string]interface{}{
+				"path":     caliperConnectionProfile(organization.Name()),
+				"discover": true,
+			},
+		})
+	}
+	return map[string]interface{}{
+		"name":    "Microfab",
+		"version": "2.0.0",
+		"caliper": map[string]interface{}{
+			"blockchain": "fabric",
+		},
+		"channels":      channels,
+		"organizations": organizations,
+	}
+}
+
+func (c *Console) buildExplorerConnectionProfile(req *http.Request, organizationName string) (map[string]interface{}, error) {
+	var client *peer.Peer
+	for _, peer := range c.peers {
+		if organizationName == "" && canAccess(req, peer.Organization().Name()) || peer.Organization().Name() == organizationName {
+			client = peer
+			break
+		}
+	}
+	if client == nil {
+		return nil, fmt.Errorf("Organization %s does not have a peer", organizationName)
+	}
+	channels := map[string]interface{}{}
+	for _, channel := range c.channels {
+		peers := map[string]interface{}{}
+		for _, peer := range c.peers {
+			for _, organization := range channel.organizations {
+				if peer.Organization() == organization {
+					peers[peer.APIHost(false)] = map[string]interface{}{}
+				}
+			}
+		}
+		channels[channel.name] = map[string]interface{}{
+			"peers": peers,
+		}
+	}
+	organizations := map[string]interface{}{}
+	peers := map[string]interface{}{}
+	for _, peer := range c.peers {
+		organization := peer.Organization()
+		admin := organization.Admin()
+		o := map[string]interface{}{
+			"mspid": organization.MSPID(),
+			"adminPrivateKey": map[string]string{
+				"pem": string(admin.PrivateKey().Bytes()),
+			},
+			"signedCert": map[string]string{
+				"pem": string(admin.Certificate().Bytes()),
+			},
+			"peers": []string{
+				peer.APIHost(false),
+			},
+		}
+		if !canAccess(req, organization.Name()) {
+			// The other organizations are still listed so that the channels can refer to their peers.
+			delete(o, "adminPrivateKey")
+		}
+		organizations[organization.MSPID()] = o
+		p := map[string]interface{}{
+			"url": c.getDynamicURL(req, peer.APIURL(false)),
+			"grpcOptions": map[string]interface{}{
+				"grpc.default_authority":   peer.APIHost(false),
+				"ssl-target-name-override": peer.APIHostname(false),
+			},
+		}
+		if tls := peer.TLS(); tls != nil {
+			p["tlsCACerts"] = map[string]string{
+				"pem": string(tls.CA().Bytes()),
+			}
+		}
+		peers[peer.APIHost(false)] = p
+	}
+	return map[string]interface{}{
+		"name":    "microfab",
+		"version": "1.0.0",
+		"client": map[string]interface{}{
+			"tlsEnable": client.TLS() != nil,
+			"adminCredential": map[string]string{
+				"id":       "exploreradmin",
+				"password": "exploreradminpw",
+			},
+			"enableAuthentication": true,
+			"organization":         client.Organization().MSPID(),
+			"connection": map[string]interface{}{
+				"timeout": map[string]interface{}{
+					"peer": map[string]interface{}{
+						"endorser": "300",
+					},
+					"orderer": "300",
+				},
+			},
+		},
+		"channels":      channels,
+		"organizations": organizations,
+		"peers":         peers,
+	}, nil
+}
@@ -0,0 +1,203 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/couchdb"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+)
+
+type topologyNode struct {
+	id       string
+	label    string
+	endpoint string
+}
+
+type topologyOrganization struct {
+	name  string
+	mspID string
+	nodes []*topologyNode
+}
+
+type topologyLink struct {
+	from   string
+	to     string
+	dashed bool
+}
+
+type topology struct {
+	organizations []*topologyOrganization
+	couchDB       *topologyNode
+	channels      []string
+	links         []topologyLink
+}
+
+var invalidNodeIDCharacters = regexp.MustCompile("[^A-Za-z0-9_]")
+
+// RegisterCouchDB registers the CouchDB instance used by the peers with the console.
+func (c *Console) RegisterCouchDB(couchDB *couchdb.CouchDB) {
+	c.couchDB = couchDB
+}
+
+func (c *Console) getTopology(rw http.ResponseWriter, req *http.Request) {
+	var render func(*topology) string
+	var contentType string
+	switch req.URL.Query().Get("format") {
+	case "", "mermaid":
+		render = renderMermaid
+		contentType = "text/plain; charset=utf-8"
+	case "dot":
+		render = renderDOT
+		contentType = "text/vnd.graphviz; charset=utf-8"
+	default:
+		rw.WriteHeader(400)
+		return
+	}
+	rw.Header().Add("Content-Type", contentType)
+	rw.Write([]byte(render(c.buildTopology(req))))
+}
+
+// buildTopology builds the topology of the network from the registered components. The channels that each peer
+// has joined are retrieved from the peer, so peers that cannot be reached are shown without any channels.
+func (c *Console) buildTopology(req *http.Request) *topology {
+	result := &topology{}
+	organizations := map[string]*topologyOrganization{}
+	getOrganization := func(name, mspID string) *topologyOrganization {
+		organization, ok := organizations[name]
+		if !ok {
+			organization = &topologyOrganization{name: name, mspID: mspID}
+			organizations[name] = organization
+			result.organizations = append(result.organizations, organization)
+		}
+		return organization
+	}
+	orderer := c.getOrderer(req)
+	ordererOrganization := getOrganization(c.orderer.Organization().Name(), orderer.MSPID)
+	ordererOrganization.nodes = append(ordererOrganization.nodes, &topologyNode{orderer.ID, orderer.DisplayName, orderer.APIURL})
+	if c.couchDB != nil {
+		result.couchDB = &topologyNode{"couchdb", "CouchDB", c.couchDB.URL(false).String()}
+	}
+	channels := map[string]bool{}
+	for _, p := range c.peers {
+		jsonPeer := c.getPeer(req, p)
+		organization := getOrganization(p.Organization().Name(), p.MSPID())
+		organization.nodes = append(organization.nodes, &topologyNode{jsonPeer.ID, jsonPeer.DisplayName, jsonPeer.APIURL})
+		joined, err := c.listChannels(p)
+		if err != nil {
+			logger.Printf("Failed to list channels for peer %s: %v", jsonPeer.DisplayName, err)
+		}
+		for _, name := range joined {
+			channels[name] = true
+			result.links = append(result.links, topologyLink{jsonPeer.ID, channelNodeID(name), false})
+		}
+		if p.CouchDB() && result.couchDB != nil {
+			result.links = append(result.links, topologyLink{jsonPeer.ID, result.couchDB.id, true})
+		}
+	}
+	for _, ca := range c.cas {
+		jsonCA := c.getCA(req, ca)
+		organization := getOrganization(ca.Organization().Name(), jsonCA.MSPID)
+		organization.nodes = append(organization.nodes, &topologyNode{jsonCA.ID, jsonCA.DisplayName, jsonCA.APIURL})
+	}
+	for name := range channels {
+		result.channels = append(result.channels, name)
+	}
+	sort.Strings(result.channels)
+	for _, name := range result.channels {
+		result.links = append(result.links, topologyLink{channelNodeID(name), orderer.ID, false})
+	}
+	return result
+}
+
+func (c *Console) listChannels(p *peer.Peer) ([]string, error) {
+	organization := p.Organization()
+	connection, err := peer.Connect(p, organization.MSPID(), organization.Admin())
+	if err != nil {
+		return nil, err
+	}
+	defer connection.Close()
+	return connection.ListChannels()
+}
+
+func channelNodeID(name string) string {
+	return "channel_" + name
+}
+
+func nodeID(id string) string {
+	return invalidNodeIDCharacters.ReplaceAllString(id, "_")
+}
+
+func mermaidLabel(lines ...string) string {
+	return strings.ReplaceAll(strings.Join(lines, "<br/>"), "\"", "#quot;")
+}
+
+func renderMermaid(t *topology) string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, organization := range t.organizations {
+		fmt.Fprintf(&sb, "  subgraph org_%s[\"%s\"]\n", nodeID(organization.name), mermaidLabel(fmt.Sprintf("%s (%s)", organization.name, organization.mspID)))
+		for _, node := range organization.nodes {
+			fmt.Fprintf(&sb, "    %s[\"%s\"]\n", nodeID(node.id), mermaidLabel(node.label, node.endpoint))
+		}
+		sb.WriteString("  end\n")
+	}
+	if t.couchDB != nil {
+		fmt.Fprintf(&sb, "  %s[(\"%s\")]\n", nodeID(t.couchDB.id), mermaidLabel(t.couchDB.label, t.couchDB.endpoint))
+	}
+	for _, name := range t.channels {
+		fmt.Fprintf(&sb, "  %s{{\"%s\"}}\n", nodeID(channelNodeID(name)), mermaidLabel(name))
+	}
+	for _, link := range t.links {
+		arrow := "---"
+		if link.dashed {
+			arrow = "-.-"
+		}
+		fmt.Fprintf(&sb, "  %s %s %s\n", nodeID(link.from), arrow, nodeID(link.to))
+	}
+	return sb.String()
+}
+
+func dotQuote(lines ...string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
+	for i, line := range lines {
+		lines[i] = replacer.Replace(line)
+	}
+	return "\"" + strings.Join(lines, "\\n") + "\""
+}
+
+func renderDOT(t *topology) string {
+	var sb strings.Builder
+	sb.WriteString("graph microfab {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	for _, organization := range t.organizations {
+		fmt.Fprintf(&sb, "  subgraph %s {\n", dotQuote("cluster_"+organization.name))
+		fmt.Fprintf(&sb, "    label=%s;\n", dotQuote(fmt.Sprintf("%s (%s)", organization.name, organization.mspID)))
+		for _, node := range organization.nodes {
+			fmt.Fprintf(&sb, "    %s [label=%s, shape=box];\n", dotQuote(node.id), dotQuote(node.label, node.endpoint))
+		}
+		sb.WriteString("  }\n")
+	}
+	if t.couchDB != nil {
+		fmt.Fprintf(&sb, "  %s [label=%s, shape=cylinder];\n", dotQuote(t.couchDB.id), dotQuote(t.couchDB.label, t.couchDB.endpoint))
+	}
+	for _, name := range t.channels {
+		fmt.Fprintf(&sb, "  %s [label=%s, shape=hexagon];\n", dotQuote(channelNodeID(name)), dotQuote(name))
+	}
+	for _, link := range t.links {
+		style := ""
+		if link.dashed {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&sb, "  %s -- %s%s;\n", dotQuote(link.from), dotQuote(link.to), style)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
@@ -0,0 +1,328 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package console
+
+import (
+	_ "embed" // Required for the OpenAPI document.
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+type jsonOrganizationV2 struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	MSPID      string   `json:"msp_id"`
+	Admin      string   `json:"admin"`
+	Identities []string `json:"identities"`
+	Peers      []string `json:"peers"`
+	Orderers   []string `json:"orderers"`
+	CAs        []string `json:"cas"`
+	Gateways   []string `json:"gateways"`
+}
+
+type jsonPeerV2 struct {
+	ID                string       `json:"id"`
+	Organization      string       `json:"organization"`
+	MSPID             string       `json:"msp_id"`
+	APIURL            string       `json:"api_url"`
+	APIOptions        *jsonOptions `json:"api_options"`
+	ChaincodeURL      string       `json:"chaincode_url"`
+	ChaincodeOptions  *jsonOptions `json:"chaincode_options"`
+	OperationsURL     string       `json:"operations_url"`
+	OperationsOptions *jsonOptions `json:"operations_options"`
+	Identity          string       `json:"identity"`
+	TLSCACertificate  []byte       `json:"tls_ca_cert,omitempty"`
+}
+
+type jsonOrdererV2 struct {
+	ID                string       `json:"id"`
+	Organization      string       `json:"organization"`
+	MSPID             string       `json:"msp_id"`
+	APIURL            string       `json:"api_url"`
+	APIOptions        *jsonOptions `json:"api_options"`
+	OperationsURL     string       `json:"operations_url,omitempty"`
+	OperationsOptions *jsonOptions `json:"operations_options,omitempty"`
+	Identity          string       `json:"identity"`
+	Remote            bool         `json:"remote"`
+	TLSCACertificate  []byte       `json:"tls_ca_cert,omitempty"`
+}
+
+type jsonCAV2 struct {
+	ID                string       `json:"id"`
+	Organization      string       `json:"organization"`
+	MSPID             string       `json:"msp_id"`
+	APIURL            string       `json:"api_url"`
+	APIOptions        *jsonOptions `json:"api_options"`
+	OperationsURL     string       `json:"operations_url"`
+	OperationsOptions *jsonOptions `json:"operations_options"`
+	Identity          string       `json:"identity"`
+	TLSCACertificate  []byte       `json:"tls_ca_cert,omitempty"`
+}
+
+type jsonIdentityV2 struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+	MSPID        string `json:"msp_id"`
+	Admin        bool   `json:"admin"`
+	Certificate  []byte `json:"cert"`
+	PrivateKey   []byte `json:"private_key"`
+	CA           []byte `json:"ca"`
+}
+
+type jsonGatewayV2 struct {
+	ID                string                 `json:"id"`
+	Organization      string                 `json:"organization"`
+	Peer              string                 `json:"peer"`
+	ConnectionProfile map[string]interface{} `json:"connection_profile"`
+}
+
+// resource is a single resource in a collection, with an ID that does not change when Microfab is restarted.
+type resource struct {
+	id    string
+	value interface{}
+}
+
+// collection returns the resources in a collection that the client that sent the request can access, sorted by ID.
+type collection func(req *http.Request) []*resource
+
+func organizationID(organization *organization.Organization) string {
+	return strings.ToLower(organization.Name())
+}
+
+func identityID(identity *identity.Identity) string {
+	return strings.ReplaceAll(strings.ToLower(identity.Name()), " ", "")
+}
+
+func sortResources(resources []*resource) []*resource {
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].id < resources[j].id
+	})
+	return resources
+}
+
+// registerV2 registers the typed resource collections of version 2 of the API, and the OpenAPI document that
+// describes them.
+func (c *Console) registerV2(router *mux.Router) {
+	router.HandleFunc("/api/v2/openapi.json", c.getOpenAPIDocument).Methods("GET")
+	collections := []struct {
+		path       string
+		kind       string
+		collection collection
+	}{
+		{"organizations", "Organization", c.organizationsV2},
+		{"peers", "Peer", c.peersV2},
+		{"orderers", "Orderer", c.orderersV2},
+		{"cas", "CA", c.casV2},
+		{"identities", "Identity", c.identitiesV2},
+		{"gateways", "Gateway", c.gatewaysV2},
+	}
+	for _, entry := range collections {
+		router.HandleFunc(fmt.Sprintf("/api/v2/%s", entry.path), listResources(entry.collection)).Methods("GET")
+		router.HandleFunc(fmt.Sprintf("/api/v2/%s/{id}", entry.path), getResource(entry.kind, entry.collection)).Methods("GET")
+	}
+}
+
+func listResources(collection collection) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		result := []interface{}{}
+		for _, resource := range collection(req) {
+			result = append(result, resource.value)
+		}
+		rw.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(result)
+	}
+}
+
+func getResource(kind string, collection collection) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		id := mux.Vars(req)["id"]
+		for _, resource := range collection(req) {
+			if resource.id == id {
+				rw.Header().Add("Content-Type", "application/json")
+				json.NewEncoder(rw).Encode(resource.value)
+				return
+			}
+		}
+		http.Error(rw, fmt.Sprintf("%s %s not found", kind, id), 404)
+	}
+}
+
+// getOpenAPIDocument returns the OpenAPI document for version 2 of the API, with the URL of the console as the server.
+func (c *Console) getOpenAPIDocument(rw http.ResponseWriter, req *http.Request) {
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(openAPIDocument, &document); err != nil {
+		http.Error(rw, err.Error(), 500)
+		return
+	}
+	document["servers"] = []interface{}{
+		map[string]interface{}{"url": c.getDynamicURL(req, c.url)},
+	}
+	c.writeConfig(rw, req, document)
+}
+
+func (c *Console) organizationsV2(req *http.Request) []*resource {
+	result := []*resource{}
+	for _, organization := range c.organizations {
+		if !canAccess(req, organization.Name()) {
+			continue
+		}
+		id := organizationID(organization)
+		value := &jsonOrganizationV2{
+			ID:         id,
+			Name:       organization.Name(),
+			MSPID:      organization.MSPID(),
+			Admin:      identityID(organization.Admin()),
+			Identities: []string{},
+			Peers:      []string{},
+			Orderers:   []string{},
+			CAs:        []string{},
+			Gateways:   []string{},
+		}
+		for _, identity := range organization.GetIdentities() {
+			value.Identities = append(value.Identities, identityID(identity))
+		}
+		for _, peer := range c.peers {
+			if peer.Organization() == organization {
+				value.Peers = append(value.Peers, fmt.Sprintf("%speer", id))
+				value.Gateways = append(value.Gateways, fmt.Sprintf("%sgateway", id))
+			}
+		}
+		if c.orderer != nil && c.orderer.Organization() == organization {
+			value.Orderers = append(value.Orderers, "orderer")
+		}
+		for _, ca := range c.cas {
+			if ca.Organization() == organization {
+				value.CAs = append(value.CAs, fmt.Sprintf("%sca", id))
+			}
+		}
+		result = append(result, &resource{id, value})
+	}
+	return sortResources(result)
+}
+
+func (c *Console) peersV2(req *http.Request) []*resource {
+	result := []*resource{}
+	for _, peer := range c.peers {
+		organization := peer.Organization()
+		if !canAccess(req, organization.Name()) {
+			continue
+		}
+		v1 := c.getPeer(req, peer)
+		value := &jsonPeerV2{
+			ID:                v1.ID,
+			Organization:      organizationID(organization),
+			MSPID:             v1.MSPID,
+			APIURL:            v1.APIURL,
+			APIOptions:        v1.APIOptions,
+			ChaincodeURL:      v1.ChaincodeURL,
+			ChaincodeOptions:  v1.ChaincodeOptions,
+			OperationsURL:     v1.OperationsURL,
+			OperationsOptions: v1.OperationsOptions,
+			Identity:          identityID(organization.Admin()),
+			TLSCACertificate:  v1.TLSCARootCert,
+		}
+		result = append(result, &resource{value.ID, value})
+	}
+	return sortResources(result)
+}
+
+func (c *Console) orderersV2(req *http.Request) []*resource {
+	result := []*resource{}
+	if c.orderer == nil || !canAccess(req, c.orderer.Organization().Name()) {
+		return result
+	}
+	organization := c.orderer.Organization()
+	v1 := c.getOrderer(req)
+	value := &jsonOrdererV2{
+		ID:                v1.ID,
+		Organization:      organizationID(organization),
+		MSPID:             v1.MSPID,
+		APIURL:            v1.APIURL,
+		APIOptions:        v1.APIOptions,
+		OperationsURL:     v1.OperationsURL,
+		OperationsOptions: v1.OperationsOptions,
+		Identity:          identityID(organization.Admin()),
+		Remote:            c.orderer.Remote(),
+		TLSCACertificate:  v1.TLSCARootCert,
+	}
+	return append(result, &resource{value.ID, value})
+}
+
+func (c *Console) casV2(req *http.Request) []*resource {
+	result := []*resource{}
+	for _, ca := range c.cas {
+		organization := ca.Organization()
+		if !canAccess(req, organization.Name()) {
+			continue
+		}
+		v1 := c.getCA(req, ca)
+		value := &jsonCAV2{
+			ID:                v1.ID,
+			Organization:      organizationID(organization),
+			MSPID:             v1.MSPID,
+			APIURL:            v1.APIURL,
+			APIOptions:        v1.APIOptions,
+			OperationsURL:     v1.OperationsURL,
+			OperationsOptions: v1.OperationsOptions,
+			Identity:          identityID(organization.CAAdmin()),
+			TLSCACertificate:  v1.TLSCert,
+		}
+		result = append(result, &resource{value.ID, value})
+	}
+	return sortResources(result)
+}
+
+func (c *Console) identitiesV2(req *http.Request) []*resource {
+	result := []*resource{}
+	for _, organization := range c.organizations {
+		if !canAccess(req, organization.Name()) {
+			continue
+		}
+		for _, identity := range organization.GetIdentities() {
+			value := &jsonIdentityV2{
+				ID:           identityID(identity),
+				Name:         identity.Name(),
+				Organization: organizationID(organization),
+				MSPID:        organization.MSPID(),
+				Admin:        identity == organization.Admin(),
+				Certificate:  identity.Certificate().Bytes(),
+				PrivateKey:   identity.PrivateKey().Bytes(),
+				CA:           identity.CA().Bytes(),
+			}
+			result = append(result, &resource{value.ID, value})
+		}
+	}
+	return sortResources(result)
+}
+
+func (c *Console) gatewaysV2(req *http.Request) []*resource {
+	result := []*resource{}
+	for _, peer := range c.peers {
+		organization := peer.Organization()
+		if !canAccess(req, organization.Name()) {
+			continue
+		}
+		id := organizationID(organization)
+		value := &jsonGatewayV2{
+			ID:                fmt.Sprintf("%sgateway", id),
+			Organization:      id,
+			Peer:              fmt.Sprintf("%speer", id),
+			ConnectionProfile: c.getGatewayProfile(req, peer),
+		}
+		result = append(result, &resource{value.ID, value})
+	}
+	return sortResources(result)
+}
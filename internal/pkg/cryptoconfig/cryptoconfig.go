@@ -0,0 +1,187 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cryptoconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// Network represents the network to be exported.
+type Network struct {
+	Domain        string
+	Orderer       *orderer.Orderer
+	Organizations []*organization.Organization
+	Peers         []*peer.Peer
+	TLS           *identity.Identity
+}
+
+// OrganizationDomain returns the domain used for the specified organization in the exported crypto material.
+func OrganizationDomain(organization *organization.Organization, domain string) string {
+	name := strings.ToLower(strings.TrimSuffix(organization.MSPID(), "MSP"))
+	return fmt.Sprintf("%s.%s", name, domain)
+}
+
+// PeerName returns the name used for the specified peer in the exported crypto material.
+func PeerName(p *peer.Peer, domain string) string {
+	return fmt.Sprintf("peer0.%s", OrganizationDomain(p.Organization(), domain))
+}
+
+// OrdererName returns the name used for the specified orderer in the exported crypto material.
+func OrdererName(o *orderer.Orderer, domain string) string {
+	return fmt.Sprintf("orderer.%s", OrganizationDomain(o.Organization(), domain))
+}
+
+// AdminName returns the name used for the admin user of the specified organization in the exported crypto material.
+func AdminName(organization *organization.Organization, domain string) string {
+	return fmt.Sprintf("Admin@%s", OrganizationDomain(organization, domain))
+}
+
+// Export writes the crypto material for the specified network into the specified directory, using the same
+// layout as the cryptogen tool.
+//
+// TLS material is only written if TLS is enabled for the network. It is issued by the TLS CA of each organization,
+// or by the CA of the network TLS identity for organizations without one.
+func Export(directory string, network *Network) error {
+	if network.Domain == "" {
+		return fmt.Errorf("Domain must be specified")
+	}
+	if network.Orderer != nil {
+		ordererOrganization := network.Orderer.Organization()
+		organizationDirectory := path.Join(directory, "ordererOrganizations", OrganizationDomain(ordererOrganization, network.Domain))
+		err := exportOrganization(organizationDirectory, network, ordererOrganization)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to export organization %s", ordererOrganization.Name())
+		}
+		name := OrdererName(network.Orderer, network.Domain)
+		err = exportNode(path.Join(organizationDirectory, "orderers", name), network, ordererOrganization, name, network.Orderer.Identity(), network.Orderer.TLS())
+		if err != nil {
+			return errors.WithMessagef(err, "failed to export orderer %s", name)
+		}
+	}
+	for _, organization := range network.Organizations {
+		organizationDirectory := path.Join(directory, "peerOrganizations", OrganizationDomain(organization, network.Domain))
+		err := exportOrganization(organizationDirectory, network, organization)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to export organization %s", organization.Name())
+		}
+	}
+	for _, p := range network.Peers {
+		organizationDirectory := path.Join(directory, "peerOrganizations", OrganizationDomain(p.Organization(), network.Domain))
+		name := PeerName(p, network.Domain)
+		err := exportNode(path.Join(organizationDirectory, "peers", name), network, p.Organization(), name, p.Identity(), p.TLS())
+		if err != nil {
+			return errors.WithMessagef(err, "failed to export peer %s", name)
+		}
+	}
+	return nil
+}
+
+func exportOrganization(directory string, network *Network, organization *organization.Organization) error {
+	domain := OrganizationDomain(organization, network.Domain)
+	ca := organization.CA()
+	err := writeCA(path.Join(directory, "ca"), fmt.Sprintf("ca.%s-cert.pem", domain), ca)
+	if err != nil {
+		return err
+	}
+	if network.TLS != nil {
+		err = writeTLSCA(path.Join(directory, "tlsca"), domain, network.TLS, organization.TLSCA())
+		if err != nil {
+			return err
+		}
+	}
+	err = util.CreateVerifyingMSPDirectory(path.Join(directory, "msp"), ca.Certificate(), mspOptions(network, organization, domain, "")...)
+	if err != nil {
+		return err
+	}
+	name := AdminName(organization, network.Domain)
+	userDirectory := path.Join(directory, "users", name)
+	err = util.CreateMSPDirectory(path.Join(userDirectory, "msp"), organization.Admin(), mspOptions(network, organization, domain, name)...)
+	if err != nil {
+		return err
+	}
+	if network.TLS == nil {
+		return nil
+	} else if organization.TLSCA() == nil {
+		return writeTLS(path.Join(userDirectory, "tls"), "client", network.TLS)
+	}
+	tls, err := identity.New(fmt.Sprintf("%s Admin TLS", organization.Name()), identity.UsingSigner(organization.TLSCA()))
+	if err != nil {
+		return err
+	}
+	return writeTLS(path.Join(userDirectory, "tls"), "client", tls)
+}
+
+func exportNode(directory string, network *Network, organization *organization.Organization, name string, id *identity.Identity, tls *identity.Identity) error {
+	domain := OrganizationDomain(organization, network.Domain)
+	err := util.CreateMSPDirectory(path.Join(directory, "msp"), id, mspOptions(network, organization, domain, name)...)
+	if err != nil {
+		return err
+	}
+	if tls != nil {
+		return writeTLS(path.Join(directory, "tls"), "server", tls)
+	}
+	return nil
+}
+
+func mspOptions(network *Network, organization *organization.Organization, domain, name string) []util.MSPOption {
+	opts := []util.MSPOption{
+		util.WithFileNames(fmt.Sprintf("ca.%s-cert.pem", domain), fmt.Sprintf("%s-cert.pem", name), "priv_sk"),
+	}
+	if network.TLS != nil && organization.TLSCA() != nil {
+		opts = append(opts, util.WithTLSCA(organization.TLSCA().Certificate(), fmt.Sprintf("tlsca.%s-cert.pem", domain)))
+	} else if network.TLS != nil {
+		opts = append(opts, util.WithTLSCA(network.TLS.CA(), fmt.Sprintf("tlsca.%s-cert.pem", domain)))
+	}
+	return opts
+}
+
+func writeCA(directory, name string, ca *identity.Identity) error {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path.Join(directory, name), ca.Certificate().Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(directory, "priv_sk"), ca.PrivateKey().Bytes(), 0644)
+}
+
+func writeTLSCA(directory, domain string, tls *identity.Identity, tlsCA *identity.Identity) error {
+	if tlsCA != nil {
+		return writeCA(directory, fmt.Sprintf("tlsca.%s-cert.pem", domain), tlsCA)
+	}
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+	// Without a TLS CA for the organization, Microfab does not have the private key, so only the certificate is written.
+	return ioutil.WriteFile(path.Join(directory, fmt.Sprintf("tlsca.%s-cert.pem", domain)), tls.CA().Bytes(), 0644)
+}
+
+func writeTLS(directory, kind string, tls *identity.Identity) error {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(directory, "ca.crt"), tls.CA().Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(directory, fmt.Sprintf("%s.crt", kind)), tls.Certificate().Bytes(), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(directory, fmt.Sprintf("%s.key", kind)), tls.PrivateKey().Bytes(), 0644)
+}
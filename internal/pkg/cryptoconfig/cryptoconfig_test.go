@@ -0,0 +1,176 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package cryptoconfig_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/cryptoconfig"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const domain = "127-0-0-1.nip.io"
+
+var _ = Describe("the cryptoconfig package", func() {
+
+	var testDirectory string
+	var ordererOrganization, org1 *organization.Organization
+	var testOrderer *orderer.Orderer
+	var testPeer *peer.Peer
+	var tls *identity.Identity
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-cryptoconfig")
+		Expect(err).NotTo(HaveOccurred())
+		ordererOrganization, err = organization.New("Orderer", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		org1, err = organization.New("Org1", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		testOrderer, err = orderer.New(ordererOrganization, path.Join(testDirectory, "orderer"), 8080, 2000, "grpc://orderer-api.127-0-0-1.nip.io:8080", 2001, "http://orderer-operations.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		testPeer, err = peer.New(org1, path.Join(testDirectory, "peer-org1"), 8080, 2002, "grpc://org1peer-api.127-0-0-1.nip.io:8080", 2003, "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", 2004, "http://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, 4000, "http://org1peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		tlsCA, err := identity.New("TLS CA", identity.WithIsCA(true))
+		Expect(err).NotTo(HaveOccurred())
+		tls, err = identity.New("TLS", identity.UsingSigner(tlsCA))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDirectory)
+	})
+
+	readFile := func(parts ...string) []byte {
+		data, err := ioutil.ReadFile(path.Join(append([]string{testDirectory, "crypto-config"}, parts...)...))
+		Expect(err).NotTo(HaveOccurred())
+		return data
+	}
+
+	Context("cryptoconfig.OrganizationDomain()", func() {
+
+		When("called", func() {
+			It("returns a domain based on the MSP ID", func() {
+				org, err := organization.New("My Org", nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(cryptoconfig.OrganizationDomain(org, domain)).To(Equal("myorg.127-0-0-1.nip.io"))
+			})
+		})
+
+	})
+
+	Context("cryptoconfig.Export()", func() {
+
+		When("TLS is disabled", func() {
+			It("writes the crypto material in the cryptogen layout", func() {
+				err := cryptoconfig.Export(path.Join(testDirectory, "crypto-config"), &cryptoconfig.Network{
+					Domain:        domain,
+					Orderer:       testOrderer,
+					Organizations: []*organization.Organization{org1},
+					Peers:         []*peer.Peer{testPeer},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				orgDirectory := []string{"peerOrganizations", "org1.127-0-0-1.nip.io"}
+				Expect(readFile(append(orgDirectory, "ca", "ca.org1.127-0-0-1.nip.io-cert.pem")...)).To(Equal(org1.CA().Certificate().Bytes()))
+				Expect(readFile(append(orgDirectory, "ca", "priv_sk")...)).To(Equal(org1.CA().PrivateKey().Bytes()))
+				Expect(readFile(append(orgDirectory, "msp", "cacerts", "ca.org1.127-0-0-1.nip.io-cert.pem")...)).To(Equal(org1.CA().Certificate().Bytes()))
+				Expect(string(readFile(append(orgDirectory, "msp", "config.yaml")...))).To(ContainSubstring("Certificate: cacerts/ca.org1.127-0-0-1.nip.io-cert.pem"))
+				Expect(path.Join(append([]string{testDirectory, "crypto-config"}, append(orgDirectory, "msp", "keystore")...)...)).NotTo(BeADirectory())
+				userDirectory := append(orgDirectory, "users", "Admin@org1.127-0-0-1.nip.io", "msp")
+				Expect(readFile(append(userDirectory, "signcerts", "Admin@org1.127-0-0-1.nip.io-cert.pem")...)).To(Equal(org1.Admin().Certificate().Bytes()))
+				Expect(readFile(append(userDirectory, "keystore", "priv_sk")...)).To(Equal(org1.Admin().PrivateKey().Bytes()))
+				peerDirectory := append(orgDirectory, "peers", "peer0.org1.127-0-0-1.nip.io", "msp")
+				Expect(readFile(append(peerDirectory, "signcerts", "peer0.org1.127-0-0-1.nip.io-cert.pem")...)).To(Equal(testPeer.Identity().Certificate().Bytes()))
+				Expect(readFile(append(peerDirectory, "config.yaml")...)).NotTo(BeEmpty())
+				ordererDirectory := []string{"ordererOrganizations", "orderer.127-0-0-1.nip.io", "orderers", "orderer.orderer.127-0-0-1.nip.io", "msp"}
+				Expect(readFile(append(ordererDirectory, "signcerts", "orderer.orderer.127-0-0-1.nip.io-cert.pem")...)).To(Equal(testOrderer.Identity().Certificate().Bytes()))
+				Expect(path.Join(testDirectory, "crypto-config", "peerOrganizations", "org1.127-0-0-1.nip.io", "tlsca")).NotTo(BeADirectory())
+				Expect(path.Join(testDirectory, "crypto-config", "peerOrganizations", "org1.127-0-0-1.nip.io", "peers", "peer0.org1.127-0-0-1.nip.io", "tls")).NotTo(BeADirectory())
+			})
+		})
+
+		When("TLS is enabled", func() {
+			It("writes the TLS material in the cryptogen layout", func() {
+				testOrderer.EnableTLS(tls)
+				testPeer.EnableTLS(tls)
+				err := cryptoconfig.Export(path.Join(testDirectory, "crypto-config"), &cryptoconfig.Network{
+					Domain:        domain,
+					Orderer:       testOrderer,
+					Organizations: []*organization.Organization{org1},
+					Peers:         []*peer.Peer{testPeer},
+					TLS:           tls,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				for _, orgDirectory := range [][]string{{"peerOrganizations", "org1.127-0-0-1.nip.io"}, {"ordererOrganizations", "orderer.127-0-0-1.nip.io"}} {
+					tlsCAName := fmt.Sprintf("tlsca.%s-cert.pem", orgDirectory[1])
+					Expect(readFile(append(orgDirectory, "tlsca", tlsCAName)...)).To(Equal(tls.CA().Bytes()))
+					Expect(readFile(append(orgDirectory, "msp", "tlscacerts", tlsCAName)...)).To(Equal(tls.CA().Bytes()))
+					tlsDirectory := append(orgDirectory, "users", fmt.Sprintf("Admin@%s", orgDirectory[1]), "tls")
+					Expect(readFile(append(tlsDirectory, "ca.crt")...)).To(Equal(tls.CA().Bytes()))
+					Expect(readFile(append(tlsDirectory, "client.crt")...)).To(Equal(tls.Certificate().Bytes()))
+					Expect(readFile(append(tlsDirectory, "client.key")...)).To(Equal(tls.PrivateKey().Bytes()))
+				}
+				tlsDirectory := []string{"peerOrganizations", "org1.127-0-0-1.nip.io", "peers", "peer0.org1.127-0-0-1.nip.io", "tls"}
+				Expect(readFile(append(tlsDirectory, "ca.crt")...)).To(Equal(tls.CA().Bytes()))
+				Expect(readFile(append(tlsDirectory, "server.crt")...)).To(Equal(tls.Certificate().Bytes()))
+				Expect(readFile(append(tlsDirectory, "server.key")...)).To(Equal(tls.PrivateKey().Bytes()))
+				tlsDirectory = []string{"ordererOrganizations", "orderer.127-0-0-1.nip.io", "orderers", "orderer.orderer.127-0-0-1.nip.io", "tls"}
+				Expect(readFile(append(tlsDirectory, "server.crt")...)).To(Equal(tls.Certificate().Bytes()))
+			})
+		})
+
+		When("TLS is enabled and the organizations have TLS CAs", func() {
+			It("writes the TLS material issued by each TLS CA", func() {
+				org1TLSCA, err := identity.New("Org1 TLS CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				org1, err = organization.New("Org1", nil, org1TLSCA)
+				Expect(err).NotTo(HaveOccurred())
+				org1TLS, err := identity.New("Org1 Peer TLS", identity.UsingSigner(org1TLSCA))
+				Expect(err).NotTo(HaveOccurred())
+				testPeer, err = peer.New(org1, path.Join(testDirectory, "peer-org1"), 8080, 2002, "grpc://org1peer-api.127-0-0-1.nip.io:8080", 2003, "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", 2004, "http://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, 4000, "http://org1peer-gossip.127-0-0-1.nip.io:8080")
+				Expect(err).NotTo(HaveOccurred())
+				testPeer.EnableTLS(org1TLS)
+				err = cryptoconfig.Export(path.Join(testDirectory, "crypto-config"), &cryptoconfig.Network{
+					Domain:        domain,
+					Organizations: []*organization.Organization{org1},
+					Peers:         []*peer.Peer{testPeer},
+					TLS:           tls,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				orgDirectory := []string{"peerOrganizations", "org1.127-0-0-1.nip.io"}
+				tlsCAName := "tlsca.org1.127-0-0-1.nip.io-cert.pem"
+				Expect(readFile(append(orgDirectory, "tlsca", tlsCAName)...)).To(Equal(org1TLSCA.Certificate().Bytes()))
+				Expect(readFile(append(orgDirectory, "tlsca", "priv_sk")...)).To(Equal(org1TLSCA.PrivateKey().Bytes()))
+				Expect(readFile(append(orgDirectory, "msp", "tlscacerts", tlsCAName)...)).To(Equal(org1TLSCA.Certificate().Bytes()))
+				tlsDirectory := append(orgDirectory, "users", "Admin@org1.127-0-0-1.nip.io", "tls")
+				Expect(readFile(append(tlsDirectory, "ca.crt")...)).To(Equal(org1TLSCA.Certificate().Bytes()))
+				clientCert, err := certificate.FromBytes(readFile(append(tlsDirectory, "client.crt")...))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(clientCert.Certificate().CheckSignatureFrom(org1TLSCA.Certificate().Certificate())).To(Succeed())
+				tlsDirectory = []string{"peerOrganizations", "org1.127-0-0-1.nip.io", "peers", "peer0.org1.127-0-0-1.nip.io", "tls"}
+				Expect(readFile(append(tlsDirectory, "ca.crt")...)).To(Equal(org1TLSCA.Certificate().Bytes()))
+				Expect(readFile(append(tlsDirectory, "server.crt")...)).To(Equal(org1TLS.Certificate().Bytes()))
+			})
+		})
+
+		When("the domain is not specified", func() {
+			It("returns an error", func() {
+				err := cryptoconfig.Export(path.Join(testDirectory, "crypto-config"), &cryptoconfig.Network{})
+				Expect(err).To(MatchError("Domain must be specified"))
+			})
+		})
+
+	})
+
+})
@@ -0,0 +1,312 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package explorer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Block represents a decoded block.
+type Block struct {
+	Number       uint64         `json:"number"`
+	DataHash     string         `json:"data_hash"`
+	PreviousHash string         `json:"previous_hash"`
+	Transactions []*Transaction `json:"transactions"`
+}
+
+// Identity represents a decoded serialized identity.
+type Identity struct {
+	MSPID   string `json:"msp_id"`
+	Subject string `json:"subject"`
+}
+
+// Transaction represents a decoded transaction.
+type Transaction struct {
+	TxID           string    `json:"tx_id"`
+	Type           string    `json:"type"`
+	ChannelID      string    `json:"channel_id"`
+	Timestamp      string    `json:"timestamp"`
+	Creator        *Identity `json:"creator"`
+	BlockNumber    uint64    `json:"block_number"`
+	ValidationCode string    `json:"validation_code"`
+	Actions        []*Action `json:"actions"`
+}
+
+// Action represents a decoded chaincode action in an endorser transaction.
+type Action struct {
+	Chaincode    string         `json:"chaincode"`
+	Version      string         `json:"version"`
+	Args         []string       `json:"args"`
+	Response     *Response      `json:"response"`
+	ReadWriteSet []*NamespaceRW `json:"read_write_set"`
+	Endorsers    []*Identity    `json:"endorsers"`
+	Event        *Event         `json:"event,omitempty"`
+}
+
+// Response represents the response of a chaincode action.
+type Response struct {
+	Status  int32  `json:"status"`
+	Message string `json:"message"`
+	Payload string `json:"payload"`
+}
+
+// NamespaceRW represents the reads and writes of a chaincode action in a single namespace.
+type NamespaceRW struct {
+	Namespace string   `json:"namespace"`
+	Reads     []*Read  `json:"reads"`
+	Writes    []*Write `json:"writes"`
+}
+
+// Read represents a key that was read, and the version of the key that was read.
+type Read struct {
+	Key         string `json:"key"`
+	BlockNumber uint64 `json:"block_number"`
+	TxNumber    uint64 `json:"tx_number"`
+}
+
+// Write represents a key that was written or deleted.
+type Write struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	IsDelete bool   `json:"is_delete"`
+}
+
+// Event represents a chaincode event.
+type Event struct {
+	Name    string `json:"name"`
+	Payload string `json:"payload"`
+}
+
+// DecodeBlock decodes the specified block, and all of the transactions in it.
+func DecodeBlock(block *common.Block) (*Block, error) {
+	result := &Block{
+		Number:       block.GetHeader().GetNumber(),
+		DataHash:     hex.EncodeToString(block.GetHeader().GetDataHash()),
+		PreviousHash: hex.EncodeToString(block.GetHeader().GetPreviousHash()),
+		Transactions: []*Transaction{},
+	}
+	var validationCodes []byte
+	if metadata := block.GetMetadata().GetMetadata(); len(metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		validationCodes = metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+	for i, data := range block.GetData().GetData() {
+		envelope := &common.Envelope{}
+		if err := proto.Unmarshal(data, envelope); err != nil {
+			return nil, err
+		}
+		validationCode := peer.TxValidationCode_VALID
+		if i < len(validationCodes) {
+			validationCode = peer.TxValidationCode(validationCodes[i])
+		}
+		transaction, err := DecodeTransaction(envelope, result.Number, validationCode)
+		if err != nil {
+			return nil, err
+		}
+		result.Transactions = append(result.Transactions, transaction)
+	}
+	return result, nil
+}
+
+// FindTransaction decodes the transaction with the specified ID in the specified block, or returns nil if the block
+// does not contain that transaction.
+func FindTransaction(block *common.Block, txID string) (*Transaction, error) {
+	decoded, err := DecodeBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range decoded.Transactions {
+		if transaction.TxID == txID {
+			return transaction, nil
+		}
+	}
+	return nil, nil
+}
+
+// DecodeTransaction decodes the specified transaction envelope, which was committed in the specified block with the
+// specified validation code.
+func DecodeTransaction(envelope *common.Envelope, blockNumber uint64, validationCode peer.TxValidationCode) (*Transaction, error) {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, err
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return nil, err
+	}
+	signatureHeader := &common.SignatureHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetSignatureHeader(), signatureHeader); err != nil {
+		return nil, err
+	}
+	creator, err := decodeIdentity(signatureHeader.Creator)
+	if err != nil {
+		return nil, err
+	}
+	result := &Transaction{
+		TxID:           channelHeader.TxId,
+		Type:           common.HeaderType_name[channelHeader.Type],
+		ChannelID:      channelHeader.ChannelId,
+		Creator:        creator,
+		BlockNumber:    blockNumber,
+		ValidationCode: validationCode.String(),
+		Actions:        []*Action{},
+	}
+	if timestamp := channelHeader.GetTimestamp(); timestamp != nil {
+		result.Timestamp = time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC().Format(time.RFC3339Nano)
+	}
+	if common.HeaderType(channelHeader.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return result, nil
+	}
+	transaction := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.Data, transaction); err != nil {
+		return nil, err
+	}
+	for _, transactionAction := range transaction.Actions {
+		action, err := decodeAction(transactionAction)
+		if err != nil {
+			return nil, err
+		}
+		result.Actions = append(result.Actions, action)
+	}
+	return result, nil
+}
+
+func decodeAction(transactionAction *peer.TransactionAction) (*Action, error) {
+	chaincodeActionPayload := &peer.ChaincodeActionPayload{}
+	if err := proto.Unmarshal(transactionAction.Payload, chaincodeActionPayload); err != nil {
+		return nil, err
+	}
+	chaincodeProposalPayload := &peer.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(chaincodeActionPayload.ChaincodeProposalPayload, chaincodeProposalPayload); err != nil {
+		return nil, err
+	}
+	cciSpec := &peer.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(chaincodeProposalPayload.Input, cciSpec); err != nil {
+		return nil, err
+	}
+	result := &Action{
+		Chaincode:    cciSpec.GetChaincodeSpec().GetChaincodeId().GetName(),
+		Args:         []string{},
+		ReadWriteSet: []*NamespaceRW{},
+		Endorsers:    []*Identity{},
+	}
+	for _, arg := range cciSpec.GetChaincodeSpec().GetInput().GetArgs() {
+		result.Args = append(result.Args, decodeBytes(arg))
+	}
+	endorsedAction := chaincodeActionPayload.GetAction()
+	for _, endorsement := range endorsedAction.GetEndorsements() {
+		endorser, err := decodeIdentity(endorsement.Endorser)
+		if err != nil {
+			return nil, err
+		}
+		result.Endorsers = append(result.Endorsers, endorser)
+	}
+	proposalResponsePayload := &peer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(endorsedAction.GetProposalResponsePayload(), proposalResponsePayload); err != nil {
+		return nil, err
+	}
+	chaincodeAction := &peer.ChaincodeAction{}
+	if err := proto.Unmarshal(proposalResponsePayload.Extension, chaincodeAction); err != nil {
+		return nil, err
+	}
+	if chaincodeID := chaincodeAction.GetChaincodeId(); chaincodeID != nil {
+		result.Chaincode = chaincodeID.Name
+		result.Version = chaincodeID.Version
+	}
+	if response := chaincodeAction.GetResponse(); response != nil {
+		result.Response = &Response{
+			Status:  response.Status,
+			Message: response.Message,
+			Payload: decodeBytes(response.Payload),
+		}
+	}
+	readWriteSet, err := decodeReadWriteSet(chaincodeAction.Results)
+	if err != nil {
+		return nil, err
+	}
+	result.ReadWriteSet = readWriteSet
+	if len(chaincodeAction.Events) > 0 {
+		event := &peer.ChaincodeEvent{}
+		if err := proto.Unmarshal(chaincodeAction.Events, event); err != nil {
+			return nil, err
+		}
+		result.Event = &Event{
+			Name:    event.EventName,
+			Payload: decodeBytes(event.Payload),
+		}
+	}
+	return result, nil
+}
+
+func decodeReadWriteSet(data []byte) ([]*NamespaceRW, error) {
+	result := []*NamespaceRW{}
+	txReadWriteSet := &rwset.TxReadWriteSet{}
+	if err := proto.Unmarshal(data, txReadWriteSet); err != nil {
+		return nil, err
+	}
+	for _, nsReadWriteSet := range txReadWriteSet.NsRwset {
+		kvReadWriteSet := &kvrwset.KVRWSet{}
+		if err := proto.Unmarshal(nsReadWriteSet.Rwset, kvReadWriteSet); err != nil {
+			return nil, err
+		}
+		namespace := &NamespaceRW{
+			Namespace: nsReadWriteSet.Namespace,
+			Reads:     []*Read{},
+			Writes:    []*Write{},
+		}
+		for _, read := range kvReadWriteSet.Reads {
+			namespace.Reads = append(namespace.Reads, &Read{
+				Key:         read.Key,
+				BlockNumber: read.GetVersion().GetBlockNum(),
+				TxNumber:    read.GetVersion().GetTxNum(),
+			})
+		}
+		for _, write := range kvReadWriteSet.Writes {
+			namespace.Writes = append(namespace.Writes, &Write{
+				Key:      write.Key,
+				Value:    decodeBytes(write.Value),
+				IsDelete: write.IsDelete,
+			})
+		}
+		result = append(result, namespace)
+	}
+	return result, nil
+}
+
+func decodeIdentity(data []byte) (*Identity, error) {
+	serializedIdentity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(data, serializedIdentity); err != nil {
+		return nil, err
+	}
+	result := &Identity{
+		MSPID: serializedIdentity.Mspid,
+	}
+	if len(serializedIdentity.IdBytes) > 0 {
+		cert, err := certificate.FromBytes(serializedIdentity.IdBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid certificate for identity in MSP %s: %v", serializedIdentity.Mspid, err)
+		}
+		result.Subject = cert.Certificate().Subject.String()
+	}
+	return result, nil
+}
+
+// decodeBytes returns the specified bytes as a string if they are valid UTF-8, or as hex if they are not.
+func decodeBytes(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return hex.EncodeToString(data)
+}
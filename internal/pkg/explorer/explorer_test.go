@@ -0,0 +1,194 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package explorer_test
+
+import (
+	"github.com/hyperledger-labs/microfab/internal/pkg/explorer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/protoutil"
+	"github.com/hyperledger-labs/microfab/internal/pkg/txid"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the explorer package", func() {
+
+	var client, endorser *identity.Identity
+	var clientTxID *txid.TransactionID
+
+	BeforeEach(func() {
+		ca, err := identity.New("Org1 CA", identity.WithIsCA(true))
+		Expect(err).NotTo(HaveOccurred())
+		client, err = identity.New("Org1 Admin", identity.UsingSigner(ca))
+		Expect(err).NotTo(HaveOccurred())
+		endorser, err = identity.New("Org1 Peer", identity.UsingSigner(ca))
+		Expect(err).NotTo(HaveOccurred())
+		clientTxID = txid.New("Org1MSP", client)
+	})
+
+	buildEndorserTransaction := func() *common.Envelope {
+		header := protoutil.BuildHeader(common.HeaderType_ENDORSER_TRANSACTION, "channel1", clientTxID)
+		proposalPayload := &peer.ChaincodeProposalPayload{
+			Input: util.MarshalOrPanic(&peer.ChaincodeInvocationSpec{
+				ChaincodeSpec: &peer.ChaincodeSpec{
+					ChaincodeId: &peer.ChaincodeID{Name: "asset"},
+					Input: &peer.ChaincodeInput{
+						Args: [][]byte{[]byte("CreateAsset"), []byte("asset1"), {0xff, 0xfe}},
+					},
+				},
+			}),
+		}
+		results := &rwset.TxReadWriteSet{
+			NsRwset: []*rwset.NsReadWriteSet{
+				{
+					Namespace: "asset",
+					Rwset: util.MarshalOrPanic(&kvrwset.KVRWSet{
+						Reads:  []*kvrwset.KVRead{{Key: "asset0", Version: &kvrwset.Version{BlockNum: 4, TxNum: 1}}},
+						Writes: []*kvrwset.KVWrite{{Key: "asset1", Value: []byte("blue")}},
+					}),
+				},
+			},
+		}
+		action := &peer.ChaincodeAction{
+			Results:     util.MarshalOrPanic(results),
+			Events:      util.MarshalOrPanic(&peer.ChaincodeEvent{EventName: "AssetCreated", Payload: []byte("asset1")}),
+			Response:    &peer.Response{Status: 200, Payload: []byte("ok")},
+			ChaincodeId: &peer.ChaincodeID{Name: "asset", Version: "1.0"},
+		}
+		actionPayload := &peer.ChaincodeActionPayload{
+			ChaincodeProposalPayload: util.MarshalOrPanic(proposalPayload),
+			Action: &peer.ChaincodeEndorsedAction{
+				ProposalResponsePayload: util.MarshalOrPanic(&peer.ProposalResponsePayload{
+					Extension: util.MarshalOrPanic(action),
+				}),
+				Endorsements: []*peer.Endorsement{
+					{
+						Endorser: util.MarshalOrPanic(&msp.SerializedIdentity{
+							Mspid:   "Org1MSP",
+							IdBytes: endorser.Certificate().Bytes(),
+						}),
+					},
+				},
+			},
+		}
+		transaction := &peer.Transaction{
+			Actions: []*peer.TransactionAction{
+				{
+					Header:  header.SignatureHeader,
+					Payload: util.MarshalOrPanic(actionPayload),
+				},
+			},
+		}
+		return protoutil.BuildEnvelope(protoutil.BuildPayload(header, transaction), client)
+	}
+
+	buildBlock := func(number uint64, validationCodes []byte, envelopes ...*common.Envelope) *common.Block {
+		data := [][]byte{}
+		for _, envelope := range envelopes {
+			data = append(data, util.MarshalOrPanic(envelope))
+		}
+		return &common.Block{
+			Header: &common.BlockHeader{
+				Number:       number,
+				PreviousHash: []byte{0x01, 0x02},
+				DataHash:     []byte{0x03, 0x04},
+			},
+			Data: &common.BlockData{
+				Data: data,
+			},
+			Metadata: &common.BlockMetadata{
+				Metadata: [][]byte{{}, {}, validationCodes, {}, {}},
+			},
+		}
+	}
+
+	Context("explorer.DecodeBlock()", func() {
+
+		When("called with a block containing an endorser transaction", func() {
+			It("decodes the block and the transaction", func() {
+				block := buildBlock(5, []byte{byte(peer.TxValidationCode_MVCC_READ_CONFLICT)}, buildEndorserTransaction())
+				decoded, err := explorer.DecodeBlock(block)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decoded.Number).To(Equal(uint64(5)))
+				Expect(decoded.PreviousHash).To(Equal("0102"))
+				Expect(decoded.DataHash).To(Equal("0304"))
+				Expect(decoded.Transactions).To(HaveLen(1))
+				transaction := decoded.Transactions[0]
+				Expect(transaction.TxID).To(Equal(clientTxID.String()))
+				Expect(transaction.Type).To(Equal("ENDORSER_TRANSACTION"))
+				Expect(transaction.ChannelID).To(Equal("channel1"))
+				Expect(transaction.Timestamp).NotTo(BeEmpty())
+				Expect(transaction.BlockNumber).To(Equal(uint64(5)))
+				Expect(transaction.ValidationCode).To(Equal("MVCC_READ_CONFLICT"))
+				Expect(transaction.Creator).To(Equal(&explorer.Identity{MSPID: "Org1MSP", Subject: client.Certificate().Certificate().Subject.String()}))
+				Expect(transaction.Actions).To(HaveLen(1))
+				action := transaction.Actions[0]
+				Expect(action.Chaincode).To(Equal("asset"))
+				Expect(action.Version).To(Equal("1.0"))
+				Expect(action.Args).To(Equal([]string{"CreateAsset", "asset1", "fffe"}))
+				Expect(action.Response).To(Equal(&explorer.Response{Status: 200, Payload: "ok"}))
+				Expect(action.ReadWriteSet).To(Equal([]*explorer.NamespaceRW{
+					{
+						Namespace: "asset",
+						Reads:     []*explorer.Read{{Key: "asset0", BlockNumber: 4, TxNumber: 1}},
+						Writes:    []*explorer.Write{{Key: "asset1", Value: "blue"}},
+					},
+				}))
+				Expect(action.Endorsers).To(Equal([]*explorer.Identity{{MSPID: "Org1MSP", Subject: endorser.Certificate().Certificate().Subject.String()}}))
+				Expect(action.Event).To(Equal(&explorer.Event{Name: "AssetCreated", Payload: "asset1"}))
+			})
+		})
+
+		When("called with a block containing a config transaction", func() {
+			It("decodes the transaction without any actions", func() {
+				header := protoutil.BuildHeader(common.HeaderType_CONFIG, "channel1", clientTxID)
+				envelope := protoutil.BuildEnvelope(protoutil.BuildPayload(header, &common.ConfigEnvelope{}), client)
+				decoded, err := explorer.DecodeBlock(buildBlock(0, []byte{0}, envelope))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decoded.Transactions).To(HaveLen(1))
+				Expect(decoded.Transactions[0].Type).To(Equal("CONFIG"))
+				Expect(decoded.Transactions[0].ValidationCode).To(Equal("VALID"))
+				Expect(decoded.Transactions[0].Actions).To(BeEmpty())
+			})
+		})
+
+		When("called with a block containing an invalid transaction", func() {
+			It("returns an error", func() {
+				block := buildBlock(0, []byte{0})
+				block.Data.Data = [][]byte{{0xff}}
+				_, err := explorer.DecodeBlock(block)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+	})
+
+	Context("explorer.FindTransaction()", func() {
+
+		When("called with the ID of a transaction in the block", func() {
+			It("returns the decoded transaction", func() {
+				transaction, err := explorer.FindTransaction(buildBlock(5, []byte{0}, buildEndorserTransaction()), clientTxID.String())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(transaction.TxID).To(Equal(clientTxID.String()))
+			})
+		})
+
+		When("called with the ID of a transaction that is not in the block", func() {
+			It("returns nil", func() {
+				transaction, err := explorer.FindTransaction(buildBlock(5, []byte{0}, buildEndorserTransaction()), "abc")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(transaction).To(BeNil())
+			})
+		})
+
+	})
+
+})
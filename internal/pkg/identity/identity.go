@@ -14,19 +14,18 @@ import (
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/pem"
-	"fmt"
-	"log"
 	"math/big"
-	"os"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity/privatekey"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 	"github.com/hyperledger-labs/microfab/pkg/client"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "console"), log.LstdFlags)
+var logger = logging.New("identity")
 
 // Identity represents a loaded identity (X509 certificate and ECDSA private key pair).
 type Identity struct {
@@ -73,21 +72,42 @@ func WithOrganizationalUnit(organizationalUnit string) Option {
 	}
 }
 
+// WithHostnames sets the subject alternative names in the new identity, replacing the default names. Hostnames
+// that are IP addresses are added as IP address SANs.
+func WithHostnames(hostnames ...string) Option {
+	return func(o *newIdentity) {
+		o.Template.DNSNames = []string{}
+		o.Template.IPAddresses = []net.IP{}
+		for _, hostname := range hostnames {
+			if ip := net.ParseIP(hostname); ip != nil {
+				o.Template.IPAddresses = append(o.Template.IPAddresses, ip)
+			} else {
+				o.Template.DNSNames = append(o.Template.DNSNames, hostname)
+			}
+		}
+	}
+}
+
 // New creates a new identity.
 func New(name string, opts ...Option) (*Identity, error) {
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-	if err != nil {
-		return nil, err
-	}
-	notBefore := time.Now().Add(-5 * time.Minute).UTC()
-	notAfter := notBefore.Add(10 * 365 * 24 * time.Hour)
-	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
-	if err != nil {
-		return nil, err
+	privateKey, serialNumber, isSeeded := seeded(name)
+	notBefore, notAfter := seededNotBefore, seededNotAfter
+	if !isSeeded {
+		var err error
+		privateKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		notBefore = time.Now().Add(-5 * time.Minute).UTC()
+		notAfter = notBefore.Add(10 * 365 * 24 * time.Hour)
+		serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+		serialNumber, err = rand.Int(rand.Reader, serialNumberLimit)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	logger.Printf("Creating new x509 cert '%s'", name)
+	logger.Debugf("Creating new x509 cert '%s'", name)
 
 	identity := &newIdentity{
 		Template: &x509.Certificate{
@@ -113,7 +133,11 @@ func New(name string, opts ...Option) (*Identity, error) {
 	publicKeyBytes := elliptic.Marshal(identity.Signee.Curve, identity.Signee.X, identity.Signee.Y)
 	subjectKeyID := sha256.Sum256(publicKeyBytes)
 	identity.Template.SubjectKeyId = subjectKeyID[:]
-	bytes, err := x509.CreateCertificate(rand.Reader, identity.Template, identity.Parent, identity.Signee, identity.Signer)
+	var signer crypto.Signer = identity.Signer
+	if isSeeded {
+		signer = &deterministicSigner{identity.Signer}
+	}
+	bytes, err := x509.CreateCertificate(rand.Reader, identity.Template, identity.Parent, identity.Signee, signer)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,95 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package identity_test
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newIdentities(seed string) (*identity.Identity, *identity.Identity) {
+	identity.UseSeed(seed)
+	ca, err := identity.New("Org1 CA", identity.WithIsCA(true))
+	Expect(err).NotTo(HaveOccurred())
+	admin, err := identity.New("Org1 Admin", identity.UsingSigner(ca))
+	Expect(err).NotTo(HaveOccurred())
+	return ca, admin
+}
+
+var _ = Describe("the identity package", func() {
+
+	AfterEach(func() {
+		identity.UseSeed("")
+	})
+
+	Context("identity.New()", func() {
+
+		When("called without a seed", func() {
+			It("creates a different identity every time", func() {
+				ca1, admin1 := newIdentities("")
+				ca2, admin2 := newIdentities("")
+				Expect(ca1.Certificate().Bytes()).NotTo(Equal(ca2.Certificate().Bytes()))
+				Expect(admin1.PrivateKey().Bytes()).NotTo(Equal(admin2.PrivateKey().Bytes()))
+				Expect(admin1.Certificate().Certificate().CheckSignatureFrom(ca1.Certificate().Certificate())).To(Succeed())
+			})
+		})
+
+		When("called with hostnames", func() {
+			It("uses the hostnames as the subject alternative names", func() {
+				ca, _ := newIdentities("")
+				id, err := identity.New("Org1 Peer TLS", identity.WithHostnames("org1peer-api.example.org", "localhost", "127.0.0.1"), identity.UsingSigner(ca))
+				Expect(err).NotTo(HaveOccurred())
+				cert := id.Certificate().Certificate()
+				Expect(cert.DNSNames).To(Equal([]string{"org1peer-api.example.org", "localhost"}))
+				Expect(cert.IPAddresses).To(HaveLen(1))
+				Expect(cert.IPAddresses[0].String()).To(Equal("127.0.0.1"))
+				Expect(cert.VerifyHostname("org1peer-api.example.org")).To(Succeed())
+				Expect(cert.VerifyHostname("org2peer-api.example.org")).NotTo(Succeed())
+			})
+		})
+
+		When("called with a seed", func() {
+			It("creates byte-identical identities every time", func() {
+				ca1, admin1 := newIdentities("microfab")
+				ca2, admin2 := newIdentities("microfab")
+				Expect(ca1.Certificate().Bytes()).To(Equal(ca2.Certificate().Bytes()))
+				Expect(ca1.PrivateKey().Bytes()).To(Equal(ca2.PrivateKey().Bytes()))
+				Expect(admin1.Certificate().Bytes()).To(Equal(admin2.Certificate().Bytes()))
+				Expect(admin1.PrivateKey().Bytes()).To(Equal(admin2.PrivateKey().Bytes()))
+			})
+
+			It("creates valid identities with a fixed validity window", func() {
+				ca, admin := newIdentities("microfab")
+				Expect(admin.Certificate().Certificate().CheckSignatureFrom(ca.Certificate().Certificate())).To(Succeed())
+				Expect(ca.Certificate().Certificate().CheckSignatureFrom(ca.Certificate().Certificate())).To(Succeed())
+				Expect(admin.Certificate().Certificate().NotBefore).To(Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)))
+				Expect(admin.Certificate().Certificate().NotAfter).To(Equal(time.Date(2099, time.December, 31, 23, 59, 59, 0, time.UTC)))
+				signature := admin.Sign([]byte("hello world"))
+				Expect(signature).NotTo(BeEmpty())
+			})
+
+			It("creates different identities for different seeds", func() {
+				ca1, _ := newIdentities("microfab")
+				ca2, _ := newIdentities("microfab2")
+				Expect(ca1.PrivateKey().Bytes()).NotTo(Equal(ca2.PrivateKey().Bytes()))
+				Expect(ca1.Certificate().Certificate().SerialNumber).NotTo(Equal(ca2.Certificate().Certificate().SerialNumber))
+			})
+
+			It("creates different identities with the same name", func() {
+				identity.UseSeed("microfab")
+				first, err := identity.New("Microfab TLS")
+				Expect(err).NotTo(HaveOccurred())
+				second, err := identity.New("Microfab TLS")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(first.PrivateKey().Bytes()).NotTo(Equal(second.PrivateKey().Bytes()))
+			})
+		})
+
+	})
+
+})
@@ -0,0 +1,148 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package identity
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// The validity window of every identity created from a seed, so that the certificates do not depend on the time
+// that they were created.
+var (
+	seededNotBefore = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	seededNotAfter  = time.Date(2099, time.December, 31, 23, 59, 59, 0, time.UTC)
+)
+
+var seedLock sync.Mutex
+var seed []byte
+var seedCounters map[string]int
+
+// UseSeed derives the private keys, serial numbers and validity windows of all new identities from the specified
+// seed, instead of generating them randomly. The same seed and identity names always produce byte-identical keys
+// and certificates. Anyone who knows the seed can recreate every private key, so this is insecure and must only be
+// used for development and testing. An empty seed restores random generation.
+func UseSeed(s string) {
+	seedLock.Lock()
+	defer seedLock.Unlock()
+	if s == "" {
+		seed = nil
+		seedCounters = nil
+		return
+	}
+	logger.Printf("WARNING: deriving all private keys from a seed, this is INSECURE and only for development")
+	seed = []byte(s)
+	seedCounters = map[string]int{}
+}
+
+// seeded returns the material for a new identity derived from the seed, or false if no seed is in use. Each
+// identity is derived from its name and the number of identities with that name created so far.
+func seeded(name string) (*ecdsa.PrivateKey, *big.Int, bool) {
+	seedLock.Lock()
+	defer seedLock.Unlock()
+	if seed == nil {
+		return nil, nil, false
+	}
+	label := fmt.Sprintf("%s/%d", name, seedCounters[name])
+	seedCounters[name]++
+	curve := elliptic.P256()
+	n := curve.Params().N
+	// Reduce 48 bytes of output modulo n-1, to make the bias negligible, and add 1 to get a valid scalar.
+	material := append(derive("key", label), derive("key-extra", label)[:16]...)
+	d := new(big.Int).SetBytes(material)
+	d.Mod(d, new(big.Int).Sub(n, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+	privateKey := &ecdsa.PrivateKey{D: d}
+	privateKey.Curve = curve
+	privateKey.X, privateKey.Y = curve.ScalarBaseMult(d.FillBytes(make([]byte, 32)))
+	serialNumber := new(big.Int).SetBytes(derive("serial", label)[:16])
+	return privateKey, serialNumber, true
+}
+
+func derive(purpose, label string) []byte {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// deterministicSigner signs using deterministic nonces as described in RFC 6979, so that signing the same digest
+// with the same key always produces the same signature.
+type deterministicSigner struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+func (s *deterministicSigner) Public() crypto.PublicKey {
+	return &s.privateKey.PublicKey
+}
+
+func (s *deterministicSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	curve := s.privateKey.Curve
+	n := curve.Params().N
+	size := (n.BitLen() + 7) / 8
+	z := bitsToInt(digest, n)
+	x := s.privateKey.D.FillBytes(make([]byte, size))
+	h := new(big.Int).Mod(z, n).FillBytes(make([]byte, size))
+	v := bytes.Repeat([]byte{0x01}, sha256.Size)
+	k := make([]byte, sha256.Size)
+	mac := func(key []byte, data ...[]byte) []byte {
+		m := hmac.New(sha256.New, key)
+		for _, d := range data {
+			m.Write(d)
+		}
+		return m.Sum(nil)
+	}
+	k = mac(k, v, []byte{0x00}, x, h)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, h)
+	v = mac(k, v)
+	halfOrder := new(big.Int).Rsh(n, 1)
+	for {
+		t := []byte{}
+		for len(t) < size {
+			v = mac(k, v)
+			t = append(t, v...)
+		}
+		nonce := bitsToInt(t, n)
+		if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+			rx, _ := curve.ScalarBaseMult(nonce.FillBytes(make([]byte, size)))
+			r := new(big.Int).Mod(rx, n)
+			if r.Sign() != 0 {
+				sig := new(big.Int).Mul(r, s.privateKey.D)
+				sig.Add(sig, z)
+				sig.Mul(sig, new(big.Int).ModInverse(nonce, n))
+				sig.Mod(sig, n)
+				if sig.Sign() != 0 {
+					if sig.Cmp(halfOrder) == 1 {
+						sig.Sub(n, sig)
+					}
+					return asn1.Marshal(struct{ R, S *big.Int }{r, sig})
+				}
+			}
+		}
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
+
+// bitsToInt converts a byte string into an integer, keeping only the leftmost bits up to the bit length of n.
+func bitsToInt(data []byte, n *big.Int) *big.Int {
+	result := new(big.Int).SetBytes(data)
+	if excess := len(data)*8 - n.BitLen(); excess > 0 {
+		result.Rsh(result, uint(excess))
+	}
+	return result
+}
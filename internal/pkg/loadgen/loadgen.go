@@ -0,0 +1,303 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"google.golang.org/grpc/status"
+)
+
+var logger = logging.New("loadgen")
+
+// MaxTransactions is the largest number of transactions that a benchmark can run.
+const MaxTransactions = 100000
+
+// MaxConcurrency is the largest number of transactions that a benchmark can run at the same time.
+const MaxConcurrency = 100
+
+// Transactor submits or evaluates transactions on behalf of an organization.
+type Transactor interface {
+	Organization() string
+	EvaluateTransaction(channel, chaincode, function string, args ...string) ([]byte, error)
+	SubmitTransaction(channel, chaincode, function string, args ...string) ([]byte, error)
+}
+
+// Options represents the options for a benchmark.
+type Options struct {
+	Channel       string   `json:"channel"`
+	Chaincode     string   `json:"chaincode"`
+	Function      string   `json:"function"`
+	Args          []string `json:"args"`
+	Submit        bool     `json:"submit"`
+	Transactions  int      `json:"transactions"`
+	Concurrency   int      `json:"concurrency"`
+	Organizations []string `json:"organizations,omitempty"`
+}
+
+// Latency represents the latency percentiles of the successful transactions, in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// Result represents the results of a benchmark, either for a single organization or for all organizations.
+type Result struct {
+	Organization string         `json:"organization,omitempty"`
+	Transactions int            `json:"transactions"`
+	Successes    int            `json:"successes"`
+	Failures     int            `json:"failures"`
+	Throughput   float64        `json:"throughput"`
+	Latency      Latency        `json:"latency"`
+	FailureCodes map[string]int `json:"failure_codes"`
+}
+
+// Report represents the report of a benchmark.
+type Report struct {
+	Options       *Options  `json:"options"`
+	Duration      float64   `json:"duration"`
+	Total         *Result   `json:"total"`
+	Organizations []*Result `json:"organizations"`
+}
+
+// TemplateData is the data available to the argument templates. Index is the index of the transaction, from zero, and
+// Worker is the index of the worker that is running it. Random is a random non-negative integer.
+type TemplateData struct {
+	Index        int
+	Worker       int
+	Organization string
+	Random       int64
+}
+
+type connectionTransactor struct {
+	organization string
+	peers        []*peer.Connection
+	orderer      *orderer.Connection
+}
+
+// NewTransactor returns a transactor that uses the specified peer and orderer connections.
+func NewTransactor(organization string, peers []*peer.Connection, orderer *orderer.Connection) Transactor {
+	return &connectionTransactor{organization, peers, orderer}
+}
+
+func (t *connectionTransactor) Organization() string {
+	return t.organization
+}
+
+func (t *connectionTransactor) EvaluateTransaction(channelName, chaincode, function string, args ...string) ([]byte, error) {
+	return channel.EvaluateTransaction(t.peers, t.orderer, channelName, chaincode, function, args...)
+}
+
+func (t *connectionTransactor) SubmitTransaction(channelName, chaincode, function string, args ...string) ([]byte, error) {
+	return channel.SubmitTransaction(t.peers, t.orderer, channelName, chaincode, function, args...)
+}
+
+type outcome struct {
+	organization string
+	latency      time.Duration
+	err          error
+}
+
+// Run runs a benchmark using the specified transactors. The transactions are assigned to the transactors in turn, so
+// they are spread evenly across the organizations. If the context is cancelled, no more transactions are started, and
+// the error from the context is returned once the transactions that have already started have completed.
+func Run(ctx context.Context, transactors []Transactor, options *Options) (*Report, error) {
+	if len(transactors) == 0 {
+		return nil, fmt.Errorf("No organizations specified for the benchmark")
+	} else if options.Chaincode == "" || options.Function == "" {
+		return nil, fmt.Errorf("Chaincode and function must be specified")
+	} else if options.Transactions < 1 {
+		return nil, fmt.Errorf("Number of transactions must be at least 1")
+	} else if options.Transactions > MaxTransactions {
+		return nil, fmt.Errorf("Number of transactions must be at most %d", MaxTransactions)
+	} else if options.Concurrency < 1 {
+		return nil, fmt.Errorf("Concurrency must be at least 1")
+	} else if options.Concurrency > MaxConcurrency {
+		return nil, fmt.Errorf("Concurrency must be at most %d", MaxConcurrency)
+	}
+	templates := []*template.Template{}
+	for i, arg := range options.Args {
+		t, err := template.New(fmt.Sprintf("arg%d", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	logger.Printf("Running %d transactions against %s/%s with concurrency %d", options.Transactions, options.Chaincode, options.Function, options.Concurrency)
+	indexes := make(chan int)
+	outcomes := make(chan *outcome, options.Transactions)
+	wg := &sync.WaitGroup{}
+	var templateErr error
+	var templateErrOnce sync.Once
+	start := time.Now()
+	for worker := 0; worker < options.Concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			random := rand.New(rand.NewSource(start.UnixNano() + int64(worker)))
+			for index := range indexes {
+				transactor := transactors[index%len(transactors)]
+				data := &TemplateData{index, worker, transactor.Organization(), random.Int63()}
+				args, err := expandArgs(templates, data)
+				if err != nil {
+					templateErrOnce.Do(func() { templateErr = err })
+					continue
+				}
+				txStart := time.Now()
+				if options.Submit {
+					_, err = transactor.SubmitTransaction(options.Channel, options.Chaincode, options.Function, args...)
+				} else {
+					_, err = transactor.EvaluateTransaction(options.Channel, options.Chaincode, options.Function, args...)
+				}
+				outcomes <- &outcome{transactor.Organization(), time.Since(txStart), err}
+			}
+		}(worker)
+	}
+dispatch:
+	for index := 0; index < options.Transactions; index++ {
+		select {
+		case indexes <- index:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	duration := time.Since(start)
+	close(outcomes)
+	if err := ctx.Err(); err != nil {
+		logger.Printf("Benchmark against %s/%s stopped: %v", options.Chaincode, options.Function, err)
+		return nil, err
+	} else if templateErr != nil {
+		return nil, templateErr
+	}
+	return buildReport(transactors, options, duration, outcomes), nil
+}
+
+func expandArgs(templates []*template.Template, data *TemplateData) ([]string, error) {
+	result := []string{}
+	for _, t := range templates {
+		buffer := &bytes.Buffer{}
+		if err := t.Execute(buffer, data); err != nil {
+			return nil, err
+		}
+		result = append(result, buffer.String())
+	}
+	return result, nil
+}
+
+// FailureCode returns a short code that describes why a transaction failed. This is the validation code for a
+// transaction that was committed but marked as invalid, the status returned by the peer for a transaction that was
+// not endorsed, or the gRPC status code for a transaction that could not be sent.
+func FailureCode(err error) string {
+	var validationErr *channel.ValidationError
+	var endorsementErr *channel.EndorsementError
+	if errors.As(err, &validationErr) {
+		return validationErr.Code.String()
+	} else if errors.As(err, &endorsementErr) {
+		return fmt.Sprintf("ENDORSEMENT_STATUS_%d", endorsementErr.Status)
+	} else if s, ok := status.FromError(err); ok {
+		return fmt.Sprintf("GRPC_%s", s.Code())
+	}
+	return "UNKNOWN"
+}
+
+func buildReport(transactors []Transactor, options *Options, duration time.Duration, outcomes <-chan *outcome) *Report {
+	total := &Result{FailureCodes: map[string]int{}}
+	totalLatencies := []time.Duration{}
+	organizations := []*Result{}
+	organizationResults := map[string]*Result{}
+	organizationLatencies := map[string][]time.Duration{}
+	for _, transactor := range transactors {
+		name := transactor.Organization()
+		if _, ok := organizationResults[name]; ok {
+			continue
+		}
+		result := &Result{Organization: name, FailureCodes: map[string]int{}}
+		organizations = append(organizations, result)
+		organizationResults[name] = result
+	}
+	for outcome := range outcomes {
+		result := organizationResults[outcome.organization]
+		for _, r := range []*Result{total, result} {
+			r.Transactions++
+			if outcome.err != nil {
+				r.Failures++
+				r.FailureCodes[FailureCode(outcome.err)]++
+			} else {
+				r.Successes++
+			}
+		}
+		if outcome.err != nil {
+			logger.Debugf("Transaction for organization %s failed: %v", outcome.organization, outcome.err)
+			continue
+		}
+		totalLatencies = append(totalLatencies, outcome.latency)
+		organizationLatencies[outcome.organization] = append(organizationLatencies[outcome.organization], outcome.latency)
+	}
+	seconds := duration.Seconds()
+	total.Throughput = float64(total.Successes) / seconds
+	total.Latency = buildLatency(totalLatencies)
+	for _, result := range organizations {
+		result.Throughput = float64(result.Successes) / seconds
+		result.Latency = buildLatency(organizationLatencies[result.Organization])
+	}
+	return &Report{
+		Options:       options,
+		Duration:      seconds,
+		Total:         total,
+		Organizations: organizations,
+	}
+}
+
+func buildLatency(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+	return Latency{
+		Min:  milliseconds(latencies[0]),
+		Mean: milliseconds(sum / time.Duration(len(latencies))),
+		P50:  milliseconds(percentile(latencies, 50)),
+		P90:  milliseconds(percentile(latencies, 90)),
+		P95:  milliseconds(percentile(latencies, 95)),
+		P99:  milliseconds(percentile(latencies, 99)),
+		Max:  milliseconds(latencies[len(latencies)-1]),
+	}
+}
+
+// percentile returns the specified percentile of the sorted latencies, using the nearest rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,156 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package loadgen_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/loadgen"
+	"github.com/hyperledger-labs/microfab/internal/pkg/loadgen/fakes"
+	fpeer "github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTransactor(organization string) *fakes.Transactor {
+	transactor := &fakes.Transactor{}
+	transactor.OrganizationReturns(organization)
+	return transactor
+}
+
+var _ = Describe("the loadgen package", func() {
+
+	var org1, org2 *fakes.Transactor
+	var options *loadgen.Options
+
+	BeforeEach(func() {
+		org1 = newTransactor("Org1")
+		org2 = newTransactor("Org2")
+		options = &loadgen.Options{
+			Channel:      "channel1",
+			Chaincode:    "asset",
+			Function:     "put",
+			Args:         []string{"key{{.Index}}", "{{.Organization}}-{{.Worker}}-{{.Random}}"},
+			Submit:       true,
+			Transactions: 10,
+			Concurrency:  2,
+		}
+	})
+
+	Context("loadgen.Run()", func() {
+
+		It("should submit the transactions across all of the organizations", func() {
+			report, err := loadgen.Run(context.Background(), []loadgen.Transactor{org1, org2}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(org1.SubmitTransactionCallCount()).To(Equal(5))
+			Expect(org2.SubmitTransactionCallCount()).To(Equal(5))
+			Expect(org1.EvaluateTransactionCallCount()).To(Equal(0))
+			keys := []string{}
+			for _, transactor := range []*fakes.Transactor{org1, org2} {
+				for i := 0; i < transactor.SubmitTransactionCallCount(); i++ {
+					channel, chaincode, function, args := transactor.SubmitTransactionArgsForCall(i)
+					Expect(channel).To(Equal("channel1"))
+					Expect(chaincode).To(Equal("asset"))
+					Expect(function).To(Equal("put"))
+					Expect(args).To(HaveLen(2))
+					Expect(args[1]).To(MatchRegexp(fmt.Sprintf("^%s-[01]-\\d+$", transactor.Organization())))
+					keys = append(keys, args[0])
+				}
+			}
+			Expect(keys).To(ConsistOf("key0", "key1", "key2", "key3", "key4", "key5", "key6", "key7", "key8", "key9"))
+			Expect(report.Total.Transactions).To(Equal(10))
+			Expect(report.Total.Successes).To(Equal(10))
+			Expect(report.Total.Failures).To(Equal(0))
+			Expect(report.Total.Throughput).To(BeNumerically(">", 0))
+			Expect(report.Organizations).To(HaveLen(2))
+			Expect(report.Organizations[0].Organization).To(Equal("Org1"))
+			Expect(report.Organizations[0].Successes).To(Equal(5))
+			Expect(report.Organizations[1].Organization).To(Equal("Org2"))
+			Expect(report.Organizations[1].Successes).To(Equal(5))
+		})
+
+		It("should evaluate the transactions if submit is not set", func() {
+			options.Submit = false
+			_, err := loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(org1.EvaluateTransactionCallCount()).To(Equal(10))
+			Expect(org1.SubmitTransactionCallCount()).To(Equal(0))
+		})
+
+		It("should report the failure codes for each organization", func() {
+			org1.SubmitTransactionReturns(nil, &channel.ValidationError{TxID: "txid", Code: fpeer.TxValidationCode_MVCC_READ_CONFLICT})
+			org2.SubmitTransactionReturns(nil, &channel.EndorsementError{Status: 500, Message: "oops"})
+			report, err := loadgen.Run(context.Background(), []loadgen.Transactor{org1, org2}, options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Total.Failures).To(Equal(10))
+			Expect(report.Total.FailureCodes).To(Equal(map[string]int{"MVCC_READ_CONFLICT": 5, "ENDORSEMENT_STATUS_500": 5}))
+			Expect(report.Total.Latency).To(Equal(loadgen.Latency{}))
+			Expect(report.Organizations[0].FailureCodes).To(Equal(map[string]int{"MVCC_READ_CONFLICT": 5}))
+			Expect(report.Organizations[1].FailureCodes).To(Equal(map[string]int{"ENDORSEMENT_STATUS_500": 5}))
+		})
+
+		It("should stop starting transactions when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			options.Transactions = 1000
+			options.Concurrency = 1
+			org1.SubmitTransactionStub = func(string, string, string, ...string) ([]byte, error) {
+				if org1.SubmitTransactionCallCount() == 5 {
+					cancel()
+				}
+				return nil, nil
+			}
+			_, err := loadgen.Run(ctx, []loadgen.Transactor{org1}, options)
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(org1.SubmitTransactionCallCount()).To(BeNumerically("<", 10))
+		})
+
+		It("should return an error for an invalid argument template", func() {
+			options.Args = []string{"{{.Index"}
+			_, err := loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(HaveOccurred())
+			options.Args = []string{"{{.Missing}}"}
+			_, err = loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error for invalid options", func() {
+			_, err := loadgen.Run(context.Background(), []loadgen.Transactor{}, options)
+			Expect(err).To(MatchError("No organizations specified for the benchmark"))
+			options.Concurrency = loadgen.MaxConcurrency + 1
+			_, err = loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(MatchError("Concurrency must be at most 100"))
+			options.Concurrency = 0
+			_, err = loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(MatchError("Concurrency must be at least 1"))
+			options.Transactions = loadgen.MaxTransactions + 1
+			_, err = loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(MatchError("Number of transactions must be at most 100000"))
+			options.Transactions = 0
+			_, err = loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(MatchError("Number of transactions must be at least 1"))
+			options.Function = ""
+			_, err = loadgen.Run(context.Background(), []loadgen.Transactor{org1}, options)
+			Expect(err).To(MatchError("Chaincode and function must be specified"))
+		})
+
+	})
+
+	Context("loadgen.FailureCode()", func() {
+
+		It("should return the code for each kind of error", func() {
+			Expect(loadgen.FailureCode(&channel.ValidationError{Code: fpeer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE})).To(Equal("ENDORSEMENT_POLICY_FAILURE"))
+			Expect(loadgen.FailureCode(&channel.EndorsementError{Status: 404})).To(Equal("ENDORSEMENT_STATUS_404"))
+			Expect(loadgen.FailureCode(status.Error(codes.Unavailable, "down"))).To(Equal("GRPC_Unavailable"))
+			Expect(loadgen.FailureCode(errors.New("oops"))).To(Equal("UNKNOWN"))
+		})
+
+	})
+
+})
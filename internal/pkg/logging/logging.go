@@ -0,0 +1,207 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package logging
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mutex        sync.RWMutex
+	format                 = "text"
+	output       io.Writer = os.Stdout
+	defaultLevel           = slog.LevelInfo
+	levels                 = map[string]slog.Level{}
+)
+
+// Logger represents a structured logger for a single component.
+type Logger struct {
+	*slog.Logger
+}
+
+// Configure sets the format ("text" or "json"), the default level, and the per-component levels for all loggers.
+func Configure(newFormat string, level string, componentLevels map[string]string) error {
+	if newFormat != "text" && newFormat != "json" {
+		return fmt.Errorf("Invalid log format %s, must be text or json", newFormat)
+	}
+	parsedLevel, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	parsedLevels := map[string]slog.Level{}
+	for component, componentLevel := range componentLevels {
+		parsedLevels[component], err = ParseLevel(componentLevel)
+		if err != nil {
+			return err
+		}
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	format = newFormat
+	defaultLevel = parsedLevel
+	levels = parsedLevels
+	return nil
+}
+
+// SetOutput sets the writer that all loggers write to.
+func SetOutput(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	output = w
+}
+
+// ParseLevel parses a level name (debug, info, warn, or error).
+func ParseLevel(level string) (slog.Level, error) {
+	var result slog.Level
+	if err := result.UnmarshalText([]byte(level)); err != nil {
+		return result, fmt.Errorf("Invalid log level %s, must be debug, info, warn or error", level)
+	}
+	return result, nil
+}
+
+// New creates a new logger for the specified component.
+func New(component string) *Logger {
+	return &Logger{slog.New(&componentHandler{component: component})}
+}
+
+// NewFileLogger creates a new logger for the specified component that writes to the specified file, using the configured format.
+func NewFileLogger(component string, w io.Writer) *Logger {
+	return &Logger{slog.New(newHandler(w)).With("component", component)}
+}
+
+// Printf logs a formatted message at the info level.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	l.Info(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// Print logs a message at the info level.
+func (l *Logger) Print(v ...interface{}) {
+	l.Info(strings.TrimSuffix(fmt.Sprint(v...), "\n"))
+}
+
+// Println logs a message at the info level.
+func (l *Logger) Println(v ...interface{}) {
+	l.Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Debugf logs a formatted message at the debug level.
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.Debug(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// Warnf logs a formatted message at the warn level.
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.Warn(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// Errorf logs a formatted message at the error level.
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.Error(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// Fatalf logs a formatted message at the error level, and then exits.
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.Errorf(format, v...)
+	os.Exit(1)
+}
+
+// Pipe logs each line read from the reader as output from the specified component, and also writes it to the specified file.
+// The file is closed once the reader has been exhausted.
+func Pipe(component string, reader io.Reader, file io.WriteCloser) {
+	logger := New(component)
+	fileLogger := NewFileLogger(component, file)
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(bufio.ScanLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		logger.Info(line)
+		fileLogger.Info(line)
+	}
+	file.Close()
+}
+
+// LineTime returns the time that a line written by a logger was logged, in either the text or the JSON format.
+func LineTime(line string) (time.Time, bool) {
+	if strings.HasPrefix(line, "{") {
+		record := struct {
+			Time time.Time `json:"time"`
+		}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil || record.Time.IsZero() {
+			return time.Time{}, false
+		}
+		return record.Time, true
+	}
+	if !strings.HasPrefix(line, "time=") {
+		return time.Time{}, false
+	}
+	value := strings.TrimPrefix(line, "time=")
+	if index := strings.IndexByte(value, ' '); index >= 0 {
+		value = value[:index]
+	}
+	result, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return result, true
+}
+
+func newHandler(w io.Writer) slog.Handler {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+func levelFor(component string) slog.Level {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if level, ok := levels[component]; ok {
+		return level
+	}
+	return defaultLevel
+}
+
+// componentHandler looks up the configured format, output, and level each time a record is logged,
+// so that loggers created during package initialization honour the configuration loaded later.
+type componentHandler struct {
+	component string
+	attrs     []slog.Attr
+}
+
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= levelFor(h.component)
+}
+
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	mutex.RLock()
+	w := output
+	mutex.RUnlock()
+	record.AddAttrs(slog.String("component", h.component))
+	record.AddAttrs(h.attrs...)
+	return newHandler(w).Handle(ctx, record)
+}
+
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	newAttrs := append([]slog.Attr{}, h.attrs...)
+	newAttrs = append(newAttrs, attrs...)
+	return &componentHandler{component: h.component, attrs: newAttrs}
+}
+
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	// Groups are not used by Microfab, so attributes are always logged at the top level.
+	return h
+}
@@ -0,0 +1,138 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func parseLines(buffer *bytes.Buffer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		result = append(result, entry)
+	}
+	return result
+}
+
+var _ = Describe("the logging package", func() {
+
+	var output *bytes.Buffer
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+		logging.SetOutput(output)
+	})
+
+	AfterEach(func() {
+		logging.SetOutput(os.Stdout)
+		Expect(logging.Configure("text", "info", nil)).To(Succeed())
+	})
+
+	Context("logging.Configure()", func() {
+
+		When("called with an invalid format", func() {
+			It("returns an error", func() {
+				Expect(logging.Configure("xml", "info", nil)).NotTo(Succeed())
+			})
+		})
+
+		When("called with an invalid level", func() {
+			It("returns an error", func() {
+				Expect(logging.Configure("json", "loud", nil)).NotTo(Succeed())
+				Expect(logging.Configure("json", "info", map[string]string{"proxy": "loud"})).NotTo(Succeed())
+			})
+		})
+
+		When("called with per-component levels", func() {
+			It("filters messages for each component", func() {
+				Expect(logging.Configure("json", "info", map[string]string{"proxy": "debug", "console": "error"})).To(Succeed())
+				logging.New("proxy").Debugf("proxy %s", "debug")
+				logging.New("console").Printf("console info")
+				logging.New("console").Errorf("console error")
+				logging.New("peer").Debugf("peer debug")
+				logging.New("peer").Printf("peer info\n")
+				entries := parseLines(output)
+				Expect(entries).To(HaveLen(3))
+				Expect(entries[0]).To(HaveKeyWithValue("component", "proxy"))
+				Expect(entries[0]).To(HaveKeyWithValue("level", "DEBUG"))
+				Expect(entries[0]).To(HaveKeyWithValue("msg", "proxy debug"))
+				Expect(entries[1]).To(HaveKeyWithValue("component", "console"))
+				Expect(entries[1]).To(HaveKeyWithValue("level", "ERROR"))
+				Expect(entries[2]).To(HaveKeyWithValue("component", "peer"))
+				Expect(entries[2]).To(HaveKeyWithValue("msg", "peer info"))
+				Expect(entries[2]).To(HaveKey("time"))
+			})
+		})
+
+	})
+
+	Context("logging.Pipe()", func() {
+
+		When("called", func() {
+			It("logs and writes each line with a timestamp and component ID", func() {
+				Expect(logging.Configure("json", "info", nil)).To(Succeed())
+				file := nopCloser{&bytes.Buffer{}}
+				logging.Pipe("org1peer", strings.NewReader("first line\nsecond line\n"), file)
+				for _, entries := range [][]map[string]interface{}{parseLines(output), parseLines(file.Buffer)} {
+					Expect(entries).To(HaveLen(2))
+					Expect(entries[0]).To(HaveKeyWithValue("component", "org1peer"))
+					Expect(entries[0]).To(HaveKeyWithValue("msg", "first line"))
+					Expect(entries[0]).To(HaveKey("time"))
+					Expect(entries[1]).To(HaveKeyWithValue("msg", "second line"))
+				}
+			})
+		})
+
+	})
+
+	Context("logging.LineTime()", func() {
+
+		for _, format := range []string{"text", "json"} {
+			format := format
+			When("called with a line in the "+format+" format", func() {
+				It("returns the time that the line was logged", func() {
+					Expect(logging.Configure(format, "info", nil)).To(Succeed())
+					file := nopCloser{&bytes.Buffer{}}
+					before := time.Now().Add(-time.Second)
+					logging.Pipe("org1peer", strings.NewReader("first line\n"), file)
+					logged, ok := logging.LineTime(strings.TrimSpace(file.String()))
+					Expect(ok).To(BeTrue())
+					Expect(logged).To(BeTemporally(">", before))
+					Expect(logged).To(BeTemporally("<=", time.Now()))
+				})
+			})
+		}
+
+		When("called with a line without a time", func() {
+			It("returns false", func() {
+				_, ok := logging.LineTime("first line")
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+	})
+
+})
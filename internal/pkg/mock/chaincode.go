@@ -0,0 +1,374 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const executeTimeout = 30 * time.Second
+
+type chaincodeMetadata struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+type connectionInfo struct {
+	Address            string `json:"address"`
+	DialTimeout        string `json:"dial_timeout"`
+	TLSRequired        bool   `json:"tls_required"`
+	ClientAuthRequired bool   `json:"client_auth_required"`
+	ClientKey          string `json:"client_key"`
+	ClientCert         string `json:"client_cert"`
+	RootCert           string `json:"root_cert"`
+}
+
+type chaincodePackage struct {
+	id         string
+	label      string
+	data       []byte
+	connection *connectionInfo
+}
+
+type transactionContext struct {
+	channelID string
+	txID      string
+	namespace string
+	simulator *simulator
+	done      chan *peer.ChaincodeMessage
+}
+
+// chaincodeConnection represents a connection to a chaincode-as-a-service server, which can be shared by many concurrent transactions.
+type chaincodeConnection struct {
+	sync.Mutex
+	sendMutex    sync.Mutex
+	clientConn   *grpc.ClientConn
+	stream       peer.Chaincode_ConnectClient
+	transactions map[string]*transactionContext
+	closed       chan struct{}
+	err          error
+}
+
+// parseChaincodePackage parses a chaincode package. Only chaincode-as-a-service packages are supported, as the
+// mock peer cannot build or launch chaincode itself.
+func parseChaincodePackage(data []byte) (*chaincodePackage, error) {
+	files, err := readTarGz(data)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read chaincode package")
+	}
+	metadataBytes, ok := files["metadata.json"]
+	if !ok {
+		return nil, fmt.Errorf("Chaincode package does not contain metadata.json")
+	}
+	metadata := &chaincodeMetadata{}
+	if err := json.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse metadata.json")
+	}
+	if metadata.Type != "ccaas" && metadata.Type != "external" {
+		return nil, fmt.Errorf("Chaincode packages of type %s are not supported in mock mode, use a chaincode-as-a-service package", metadata.Type)
+	}
+	codeBytes, ok := files["code.tar.gz"]
+	if !ok {
+		return nil, fmt.Errorf("Chaincode package does not contain code.tar.gz")
+	}
+	codeFiles, err := readTarGz(codeBytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read code.tar.gz")
+	}
+	connectionBytes, ok := codeFiles["connection.json"]
+	if !ok {
+		return nil, fmt.Errorf("Chaincode package does not contain connection.json")
+	}
+	connection := &connectionInfo{}
+	if err := json.Unmarshal(connectionBytes, connection); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse connection.json")
+	}
+	if connection.Address == "" {
+		return nil, fmt.Errorf("Chaincode package connection.json does not specify an address")
+	}
+	hash := sha256.Sum256(data)
+	return &chaincodePackage{
+		id:         fmt.Sprintf("%s:%x", metadata.Label, hash),
+		label:      metadata.Label,
+		data:       data,
+		connection: connection,
+	}, nil
+}
+
+func readTarGz(data []byte) (map[string][]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	result := map[string][]byte{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		contents, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, err
+		}
+		result[strings.TrimPrefix(header.Name, "./")] = contents
+	}
+	return result, nil
+}
+
+// connectChaincode connects to a chaincode-as-a-service server, and completes the registration handshake that the
+// peer would normally perform.
+func connectChaincode(info *connectionInfo) (*chaincodeConnection, error) {
+	dialTimeout := 10 * time.Second
+	if info.DialTimeout != "" {
+		var err error
+		dialTimeout, err = time.ParseDuration(info.DialTimeout)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid dial_timeout in connection.json")
+		}
+	}
+	transportOption := grpc.WithInsecure()
+	if info.TLSRequired {
+		tlsConfig := &gotls.Config{}
+		if info.RootCert != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(info.RootCert)) {
+				return nil, fmt.Errorf("Invalid root_cert in connection.json")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if info.ClientAuthRequired {
+			certificate, err := gotls.X509KeyPair([]byte(info.ClientCert), []byte(info.ClientKey))
+			if err != nil {
+				return nil, errors.WithMessage(err, "invalid client_cert or client_key in connection.json")
+			}
+			tlsConfig.Certificates = []gotls.Certificate{certificate}
+		}
+		transportOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	clientConn, err := grpc.DialContext(ctx, info.Address, transportOption, grpc.WithBlock())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to connect to chaincode at %s", info.Address)
+	}
+	stream, err := peer.NewChaincodeClient(clientConn).Connect(context.Background())
+	if err != nil {
+		clientConn.Close()
+		return nil, errors.WithMessagef(err, "failed to connect to chaincode at %s", info.Address)
+	}
+	msg, err := stream.Recv()
+	if err != nil {
+		clientConn.Close()
+		return nil, errors.WithMessagef(err, "failed to register chaincode at %s", info.Address)
+	} else if msg.Type != peer.ChaincodeMessage_REGISTER {
+		clientConn.Close()
+		return nil, fmt.Errorf("Chaincode at %s sent %v instead of REGISTER", info.Address, msg.Type)
+	}
+	chaincodeID := &peer.ChaincodeID{}
+	util.UnmarshalOrPanic(msg.Payload, chaincodeID)
+	logger.Printf("Chaincode %s registered from %s", chaincodeID.Name, info.Address)
+	c := &chaincodeConnection{
+		clientConn:   clientConn,
+		stream:       stream,
+		transactions: map[string]*transactionContext{},
+		closed:       make(chan struct{}),
+	}
+	for _, msgType := range []peer.ChaincodeMessage_Type{peer.ChaincodeMessage_REGISTERED, peer.ChaincodeMessage_READY} {
+		if err := c.send(&peer.ChaincodeMessage{Type: msgType}); err != nil {
+			clientConn.Close()
+			return nil, err
+		}
+	}
+	go c.receive()
+	return c, nil
+}
+
+func (c *chaincodeConnection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *chaincodeConnection) close(err error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.isClosed() {
+		return
+	}
+	c.err = err
+	close(c.closed)
+	c.clientConn.Close()
+}
+
+func (c *chaincodeConnection) send(msg *peer.ChaincodeMessage) error {
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+	return c.stream.Send(msg)
+}
+
+// execute sends the specified INIT or TRANSACTION message to the chaincode, and waits for it to complete.
+func (c *chaincodeConnection) execute(tx *transactionContext, msg *peer.ChaincodeMessage) (*peer.ChaincodeMessage, error) {
+	key := tx.channelID + tx.txID
+	c.Lock()
+	if c.isClosed() {
+		c.Unlock()
+		return nil, c.err
+	} else if _, ok := c.transactions[key]; ok {
+		c.Unlock()
+		return nil, fmt.Errorf("Transaction %s is already executing", tx.txID)
+	}
+	c.transactions[key] = tx
+	c.Unlock()
+	defer func() {
+		c.Lock()
+		delete(c.transactions, key)
+		c.Unlock()
+	}()
+	if err := c.send(msg); err != nil {
+		return nil, err
+	}
+	select {
+	case response := <-tx.done:
+		return response, nil
+	case <-c.closed:
+		return nil, c.err
+	case <-time.After(executeTimeout):
+		return nil, fmt.Errorf("Timeout expired while executing transaction %s", tx.txID)
+	}
+}
+
+func (c *chaincodeConnection) receive() {
+	for {
+		msg, err := c.stream.Recv()
+		if err != nil {
+			logger.Warnf("Chaincode connection closed: %v", err)
+			c.close(errors.WithMessage(err, "chaincode connection closed"))
+			return
+		}
+		if msg.Type == peer.ChaincodeMessage_KEEPALIVE {
+			continue
+		}
+		c.Lock()
+		tx, ok := c.transactions[msg.ChannelId+msg.Txid]
+		c.Unlock()
+		if !ok {
+			logger.Warnf("Chaincode sent %v for unknown transaction %s", msg.Type, msg.Txid)
+			continue
+		}
+		switch msg.Type {
+		case peer.ChaincodeMessage_COMPLETED, peer.ChaincodeMessage_ERROR:
+			tx.done <- msg
+		default:
+			if err := c.send(handleChaincodeRequest(tx, msg)); err != nil {
+				c.close(err)
+				return
+			}
+		}
+	}
+}
+
+// handleChaincodeRequest handles a request from the chaincode to read or write the world state during a transaction.
+func handleChaincodeRequest(tx *transactionContext, msg *peer.ChaincodeMessage) *peer.ChaincodeMessage {
+	payload, err := handleChaincodeRequestPayload(tx, msg)
+	if err != nil {
+		return &peer.ChaincodeMessage{
+			Type:      peer.ChaincodeMessage_ERROR,
+			Payload:   []byte(err.Error()),
+			Txid:      msg.Txid,
+			ChannelId: msg.ChannelId,
+		}
+	}
+	return &peer.ChaincodeMessage{
+		Type:      peer.ChaincodeMessage_RESPONSE,
+		Payload:   payload,
+		Txid:      msg.Txid,
+		ChannelId: msg.ChannelId,
+	}
+}
+
+func handleChaincodeRequestPayload(tx *transactionContext, msg *peer.ChaincodeMessage) ([]byte, error) {
+	switch msg.Type {
+	case peer.ChaincodeMessage_GET_STATE:
+		request := &peer.GetState{}
+		if err := proto.Unmarshal(msg.Payload, request); err != nil {
+			return nil, err
+		} else if request.Collection != "" {
+			return nil, fmt.Errorf("Private data is not supported in mock mode")
+		}
+		return tx.simulator.getState(tx.namespace, request.Key), nil
+	case peer.ChaincodeMessage_PUT_STATE:
+		request := &peer.PutState{}
+		if err := proto.Unmarshal(msg.Payload, request); err != nil {
+			return nil, err
+		} else if request.Collection != "" {
+			return nil, fmt.Errorf("Private data is not supported in mock mode")
+		}
+		tx.simulator.putState(tx.namespace, request.Key, request.Value)
+		return nil, nil
+	case peer.ChaincodeMessage_DEL_STATE:
+		request := &peer.DelState{}
+		if err := proto.Unmarshal(msg.Payload, request); err != nil {
+			return nil, err
+		} else if request.Collection != "" {
+			return nil, fmt.Errorf("Private data is not supported in mock mode")
+		}
+		tx.simulator.delState(tx.namespace, request.Key)
+		return nil, nil
+	case peer.ChaincodeMessage_GET_STATE_BY_RANGE:
+		request := &peer.GetStateByRange{}
+		if err := proto.Unmarshal(msg.Payload, request); err != nil {
+			return nil, err
+		} else if request.Collection != "" {
+			return nil, fmt.Errorf("Private data is not supported in mock mode")
+		}
+		response := &peer.QueryResponse{
+			Results: []*peer.QueryResultBytes{},
+			HasMore: false,
+			Id:      msg.Txid,
+		}
+		for _, kv := range tx.simulator.getStateRange(tx.namespace, request.StartKey, request.EndKey) {
+			response.Results = append(response.Results, &peer.QueryResultBytes{
+				ResultBytes: util.MarshalOrPanic(kv),
+			})
+		}
+		return util.MarshalOrPanic(response), nil
+	case peer.ChaincodeMessage_QUERY_STATE_NEXT, peer.ChaincodeMessage_QUERY_STATE_CLOSE:
+		// All results are returned by the initial query, so there is never anything more to return.
+		return util.MarshalOrPanic(&peer.QueryResponse{Id: msg.Txid}), nil
+	case peer.ChaincodeMessage_GET_STATE_METADATA:
+		return util.MarshalOrPanic(&peer.StateMetadataResult{}), nil
+	}
+	return nil, fmt.Errorf("%v is not supported in mock mode", msg.Type)
+}
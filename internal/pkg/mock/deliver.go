@@ -0,0 +1,78 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"context"
+	"math"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+)
+
+// seekBlocks passes each block requested by the specified seek info envelope to the callback, and returns the
+// status that should be sent back to the client. The channel lookup function allows peers to hide channels that
+// they have not joined.
+func seekBlocks(ctx context.Context, lookup func(string) (*Channel, bool), envelope *common.Envelope, callback blocks.DeliverCallback) (common.Status, error) {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return common.Status_BAD_REQUEST, nil
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return common.Status_BAD_REQUEST, nil
+	}
+	seekInfo := &orderer.SeekInfo{}
+	if err := proto.Unmarshal(payload.Data, seekInfo); err != nil {
+		return common.Status_BAD_REQUEST, nil
+	}
+	channel, ok := lookup(channelHeader.ChannelId)
+	if !ok {
+		return common.Status_NOT_FOUND, nil
+	}
+	height := channel.Height()
+	start, ok := seekPosition(seekInfo.Start, height)
+	if !ok {
+		return common.Status_BAD_REQUEST, nil
+	}
+	stop, ok := seekPosition(seekInfo.Stop, height)
+	if !ok {
+		return common.Status_BAD_REQUEST, nil
+	} else if start > stop {
+		return common.Status_BAD_REQUEST, nil
+	}
+	for number := start; number <= stop; number++ {
+		if seekInfo.Behavior == orderer.SeekInfo_FAIL_IF_NOT_READY && number >= channel.Height() {
+			return common.Status_NOT_FOUND, nil
+		}
+		block, err := channel.waitForBlock(ctx, number)
+		if err != nil {
+			return common.Status_SERVICE_UNAVAILABLE, err
+		}
+		if err := callback(block); err != nil {
+			return common.Status_INTERNAL_SERVER_ERROR, err
+		}
+		if number == math.MaxUint64 {
+			break
+		}
+	}
+	return common.Status_SUCCESS, nil
+}
+
+func seekPosition(position *orderer.SeekPosition, height uint64) (uint64, bool) {
+	switch t := position.GetType().(type) {
+	case *orderer.SeekPosition_Oldest:
+		return 0, true
+	case *orderer.SeekPosition_Newest:
+		return height - 1, true
+	case *orderer.SeekPosition_Specified:
+		return t.Specified.Number, true
+	case *orderer.SeekPosition_NextCommit:
+		return height, true
+	}
+	return 0, false
+}
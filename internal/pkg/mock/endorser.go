@@ -0,0 +1,312 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+type proposal struct {
+	signedProposal *peer.SignedProposal
+	proposal       *peer.Proposal
+	header         *common.Header
+	channelHeader  *common.ChannelHeader
+	payload        *peer.ChaincodeProposalPayload
+	chaincodeID    *peer.ChaincodeID
+	input          *peer.ChaincodeInput
+}
+
+func parseProposal(signedProposal *peer.SignedProposal) (*proposal, error) {
+	result := &proposal{
+		signedProposal: signedProposal,
+		proposal:       &peer.Proposal{},
+		header:         &common.Header{},
+		channelHeader:  &common.ChannelHeader{},
+		payload:        &peer.ChaincodeProposalPayload{},
+	}
+	if err := proto.Unmarshal(signedProposal.ProposalBytes, result.proposal); err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(result.proposal.Header, result.header); err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(result.header.ChannelHeader, result.channelHeader); err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(result.proposal.Payload, result.payload); err != nil {
+		return nil, err
+	}
+	cis := &peer.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(result.payload.Input, cis); err != nil {
+		return nil, err
+	}
+	result.chaincodeID = cis.GetChaincodeSpec().GetChaincodeId()
+	result.input = cis.GetChaincodeSpec().GetInput()
+	if result.chaincodeID == nil || result.input == nil || len(result.input.Args) == 0 {
+		return nil, fmt.Errorf("Proposal does not specify a chaincode and function")
+	}
+	return result, nil
+}
+
+func (p *proposal) function() string {
+	return string(p.input.Args[0])
+}
+
+func (p *proposal) args() [][]byte {
+	return p.input.Args[1:]
+}
+
+// hash computes the proposal hash in the same way as Fabric, excluding the transient map from the payload.
+func (p *proposal) hash() []byte {
+	payload := util.MarshalOrPanic(&peer.ChaincodeProposalPayload{Input: p.payload.Input})
+	hasher := sha256.New()
+	hasher.Write(p.header.ChannelHeader)
+	hasher.Write(p.header.SignatureHeader)
+	hasher.Write(payload)
+	return hasher.Sum(nil)
+}
+
+func (p *Peer) processProposal(signedProposal *peer.SignedProposal) (*peer.ProposalResponse, error) {
+	proposal, err := parseProposal(signedProposal)
+	if err != nil {
+		return nil, err
+	}
+	var channel *Channel
+	if channelID := proposal.channelHeader.ChannelId; channelID != "" {
+		var ok bool
+		channel, ok = p.joinedChannel(channelID)
+		if !ok {
+			return errorResponse(fmt.Sprintf("Channel '%s' not found", channelID)), nil
+		}
+	}
+	name := proposal.chaincodeID.Name
+	logger.Debugf("Processing proposal %s for %s/%s on peer %s", proposal.channelHeader.TxId, name, proposal.function(), p.peer.Identity().Name())
+	switch name {
+	case "cscc":
+		return p.invokeCSCC(proposal), nil
+	case "qscc":
+		return p.invokeQSCC(proposal), nil
+	}
+	if channel == nil {
+		if name == "_lifecycle" {
+			return p.invokeLifecycle(proposal, nil), nil
+		}
+		return errorResponse(fmt.Sprintf("Chaincode %s must be invoked on a channel", name)), nil
+	}
+	simulator := newSimulator(channel)
+	var response *peer.ProposalResponse
+	var event *peer.ChaincodeEvent
+	if name == "_lifecycle" {
+		response = p.invokeLifecycle(proposal, simulator)
+	} else {
+		response, event = p.invokeChaincode(proposal, channel, simulator)
+	}
+	if response.Response.Status >= 400 {
+		return response, nil
+	}
+	return p.endorse(proposal, response.Response, simulator.results(), event), nil
+}
+
+// endorse builds and signs a proposal response containing the results of simulating the proposal.
+func (p *Peer) endorse(proposal *proposal, response *peer.Response, results []byte, event *peer.ChaincodeEvent) *peer.ProposalResponse {
+	action := &peer.ChaincodeAction{
+		Results:     results,
+		Response:    response,
+		ChaincodeId: proposal.chaincodeID,
+	}
+	if event != nil {
+		action.Events = util.MarshalOrPanic(event)
+	}
+	payload := util.MarshalOrPanic(&peer.ProposalResponsePayload{
+		ProposalHash: proposal.hash(),
+		Extension:    util.MarshalOrPanic(action),
+	})
+	endorser := util.MarshalOrPanic(&msp.SerializedIdentity{
+		Mspid:   p.peer.MSPID(),
+		IdBytes: p.peer.Identity().Certificate().Bytes(),
+	})
+	return &peer.ProposalResponse{
+		Version:  1,
+		Response: response,
+		Payload:  payload,
+		Endorsement: &peer.Endorsement{
+			Endorser:  endorser,
+			Signature: p.peer.Identity().Sign(payload, endorser),
+		},
+	}
+}
+
+func (p *Peer) invokeCSCC(proposal *proposal) *peer.ProposalResponse {
+	args := proposal.args()
+	switch proposal.function() {
+	case "JoinChain":
+		if len(args) < 1 {
+			return errorResponse("Incorrect number of arguments, expecting a genesis block")
+		}
+		block := &common.Block{}
+		if err := proto.Unmarshal(args[0], block); err != nil || len(block.GetData().GetData()) == 0 {
+			return errorResponse("Invalid genesis block")
+		}
+		envelope := &common.Envelope{}
+		if err := proto.Unmarshal(block.Data.Data[0], envelope); err != nil {
+			return errorResponse("Invalid genesis block")
+		}
+		channelHeader, err := getChannelHeader(envelope)
+		if err != nil {
+			return errorResponse("Invalid genesis block")
+		}
+		if err := p.joinChannel(channelHeader.ChannelId); err != nil {
+			return errorResponse(err.Error())
+		}
+		logger.Printf("Peer %s joined channel %s", p.peer.Identity().Name(), channelHeader.ChannelId)
+		return successResponse(nil)
+	case "GetChannels":
+		response := &peer.ChannelQueryResponse{}
+		for _, name := range p.Channels() {
+			response.Channels = append(response.Channels, &peer.ChannelInfo{ChannelId: name})
+		}
+		return successResponse(util.MarshalOrPanic(response))
+	case "GetConfigBlock":
+		if len(args) < 1 {
+			return errorResponse("Incorrect number of arguments, expecting a channel name")
+		}
+		channel, ok := p.joinedChannel(string(args[0]))
+		if !ok {
+			return errorResponse(fmt.Sprintf("Unknown channel ID, %s", args[0]))
+		}
+		return successResponse(util.MarshalOrPanic(channel.ConfigBlock()))
+	}
+	return errorResponse(fmt.Sprintf("Requested function %s not found in mock cscc", proposal.function()))
+}
+
+func (p *Peer) invokeQSCC(proposal *proposal) *peer.ProposalResponse {
+	args := proposal.args()
+	if len(args) < 1 {
+		return errorResponse("Incorrect number of arguments, expecting a channel name")
+	}
+	channel, ok := p.joinedChannel(string(args[0]))
+	if !ok {
+		return errorResponse(fmt.Sprintf("Unknown channel ID, %s", args[0]))
+	}
+	switch proposal.function() {
+	case "GetChainInfo":
+		height := channel.Height()
+		current, _ := channel.Block(height - 1)
+		info := &common.BlockchainInfo{
+			Height:            height,
+			CurrentBlockHash:  blockHeaderHash(current.Header),
+			PreviousBlockHash: current.Header.PreviousHash,
+		}
+		return successResponse(util.MarshalOrPanic(info))
+	case "GetBlockByNumber":
+		if len(args) < 2 {
+			return errorResponse("Incorrect number of arguments, expecting a block number")
+		}
+		number, err := strconv.ParseUint(string(args[1]), 10, 64)
+		if err != nil {
+			return errorResponse(fmt.Sprintf("Failed to parse block number: %v", err))
+		}
+		block, ok := channel.Block(number)
+		if !ok {
+			return errorResponse(fmt.Sprintf("Block number %d not found", number))
+		}
+		return successResponse(util.MarshalOrPanic(block))
+	case "GetTransactionByID", "GetBlockByTxID":
+		if len(args) < 2 {
+			return errorResponse("Incorrect number of arguments, expecting a transaction ID")
+		}
+		envelope, number, validationCode, ok := channel.Transaction(string(args[1]))
+		if !ok {
+			// The same error as the real ledger, so that clients can tell an unknown transaction ID from other errors.
+			return errorResponse(fmt.Sprintf("Failed to get transaction with ID %s, error no such transaction ID [%s] in index", args[1], args[1]))
+		}
+		if proposal.function() == "GetBlockByTxID" {
+			block, _ := channel.Block(number)
+			return successResponse(util.MarshalOrPanic(block))
+		}
+		return successResponse(util.MarshalOrPanic(&peer.ProcessedTransaction{
+			TransactionEnvelope: envelope,
+			ValidationCode:      int32(validationCode),
+		}))
+	}
+	return errorResponse(fmt.Sprintf("Requested function %s not found in mock qscc", proposal.function()))
+}
+
+// invokeChaincode executes the proposal using the chaincode-as-a-service server installed for the chaincode definition that this peer's organization approved.
+func (p *Peer) invokeChaincode(proposal *proposal, channel *Channel, simulator *simulator) (*peer.ProposalResponse, *peer.ChaincodeEvent) {
+	name := proposal.chaincodeID.Name
+	definition, ok := getDefinition(channel, name)
+	if !ok {
+		return errorResponse(fmt.Sprintf("make sure the chaincode %s has been successfully defined on channel %s and try again: chaincode %s not found", name, channel.Name(), name)), nil
+	}
+	approval, ok := getApproval(channel, name, p.peer.MSPID())
+	if !ok || approval.Sequence != definition.Sequence || approval.PackageID == "" {
+		return errorResponse(fmt.Sprintf("chaincode definition for '%s' exists, but chaincode is not installed", name)), nil
+	}
+	pkg, ok := p.installedPackage(approval.PackageID)
+	if !ok {
+		return errorResponse(fmt.Sprintf("chaincode definition for '%s' exists, but chaincode is not installed", name)), nil
+	}
+	connection, err := p.chaincodeConnection(pkg)
+	if err != nil {
+		return errorResponse(err.Error()), nil
+	}
+	msgType := peer.ChaincodeMessage_TRANSACTION
+	if proposal.input.IsInit {
+		msgType = peer.ChaincodeMessage_INIT
+	}
+	tx := &transactionContext{
+		channelID: channel.Name(),
+		txID:      proposal.channelHeader.TxId,
+		namespace: name,
+		simulator: simulator,
+		done:      make(chan *peer.ChaincodeMessage, 1),
+	}
+	msg, err := connection.execute(tx, &peer.ChaincodeMessage{
+		Type:      msgType,
+		Payload:   util.MarshalOrPanic(proposal.input),
+		Txid:      tx.txID,
+		ChannelId: tx.channelID,
+		Proposal:  proposal.signedProposal,
+	})
+	if err != nil {
+		return errorResponse(fmt.Sprintf("error in simulation: %v", err)), nil
+	} else if msg.Type == peer.ChaincodeMessage_ERROR {
+		return errorResponse(fmt.Sprintf("error in simulation: %s", msg.Payload)), nil
+	}
+	response := &peer.Response{}
+	if err := proto.Unmarshal(msg.Payload, response); err != nil {
+		return errorResponse(fmt.Sprintf("error in simulation: %v", err)), nil
+	}
+	return &peer.ProposalResponse{Version: 1, Response: response}, msg.ChaincodeEvent
+}
+
+func successResponse(payload []byte) *peer.ProposalResponse {
+	return &peer.ProposalResponse{
+		Version: 1,
+		Response: &peer.Response{
+			Status:  int32(common.Status_SUCCESS),
+			Payload: payload,
+		},
+	}
+}
+
+func errorResponse(message string) *peer.ProposalResponse {
+	return &peer.ProposalResponse{
+		Version: 1,
+		Response: &peer.Response{
+			Status:  int32(common.Status_INTERNAL_SERVER_ERROR),
+			Message: message,
+		},
+	}
+}
@@ -0,0 +1,366 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Ledger represents an in-memory ledger that is shared by the mock orderer and all of the mock peers.
+type Ledger struct {
+	sync.Mutex
+	channels map[string]*Channel
+}
+
+// Channel represents the blocks and world state of a single channel in the ledger.
+type Channel struct {
+	sync.RWMutex
+	name         string
+	blocks       []*common.Block
+	config       *common.Config
+	lastConfig   uint64
+	state        map[string]map[string]*versionedValue
+	transactions map[string]*transaction
+	updated      chan struct{}
+}
+
+type versionedValue struct {
+	value   []byte
+	version *kvrwset.Version
+}
+
+type transaction struct {
+	blockNumber    uint64
+	envelope       *common.Envelope
+	validationCode peer.TxValidationCode
+}
+
+type asn1Header struct {
+	Number       *big.Int
+	PreviousHash []byte
+	DataHash     []byte
+}
+
+// NewLedger creates a new, empty ledger.
+func NewLedger() *Ledger {
+	return &Ledger{
+		channels: map[string]*Channel{},
+	}
+}
+
+// Channel returns the specified channel, if it exists.
+func (l *Ledger) Channel(name string) (*Channel, bool) {
+	l.Lock()
+	defer l.Unlock()
+	channel, ok := l.channels[name]
+	return channel, ok
+}
+
+// Channels returns the names of all of the channels.
+func (l *Ledger) Channels() []string {
+	l.Lock()
+	defer l.Unlock()
+	result := []string{}
+	for name := range l.channels {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (l *Ledger) createChannel(name string, envelope *common.Envelope, config *common.Config) error {
+	l.Lock()
+	defer l.Unlock()
+	if _, ok := l.channels[name]; ok {
+		return fmt.Errorf("Channel %s already exists", name)
+	}
+	channel := &Channel{
+		name:         name,
+		blocks:       []*common.Block{},
+		state:        map[string]map[string]*versionedValue{},
+		transactions: map[string]*transaction{},
+		updated:      make(chan struct{}),
+	}
+	channel.appendConfig(envelope, config)
+	l.channels[name] = channel
+	return nil
+}
+
+// Name returns the name of the channel.
+func (c *Channel) Name() string {
+	return c.name
+}
+
+// Height returns the number of blocks in the channel.
+func (c *Channel) Height() uint64 {
+	c.RLock()
+	defer c.RUnlock()
+	return uint64(len(c.blocks))
+}
+
+// Block returns the specified block, if it exists.
+func (c *Channel) Block(number uint64) (*common.Block, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if number >= uint64(len(c.blocks)) {
+		return nil, false
+	}
+	return c.blocks[number], true
+}
+
+// Config returns a copy of the current configuration of the channel.
+func (c *Channel) Config() *common.Config {
+	c.RLock()
+	defer c.RUnlock()
+	return proto.Clone(c.config).(*common.Config)
+}
+
+// ConfigBlock returns the latest config block in the channel.
+func (c *Channel) ConfigBlock() *common.Block {
+	c.RLock()
+	defer c.RUnlock()
+	return c.blocks[c.lastConfig]
+}
+
+// Transaction returns the envelope, block number and validation code for the specified transaction, if it exists.
+func (c *Channel) Transaction(txID string) (*common.Envelope, uint64, peer.TxValidationCode, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	tx, ok := c.transactions[txID]
+	if !ok {
+		return nil, 0, 0, false
+	}
+	return tx.envelope, tx.blockNumber, tx.validationCode, true
+}
+
+func (c *Channel) waitForBlock(ctx context.Context, number uint64) (*common.Block, error) {
+	for {
+		c.RLock()
+		if number < uint64(len(c.blocks)) {
+			block := c.blocks[number]
+			c.RUnlock()
+			return block, nil
+		}
+		updated := c.updated
+		c.RUnlock()
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Channel) getState(namespace, key string) *versionedValue {
+	c.RLock()
+	defer c.RUnlock()
+	return c.state[namespace][key]
+}
+
+func (c *Channel) getStateRange(namespace, startKey, endKey string) []string {
+	c.RLock()
+	defer c.RUnlock()
+	keys := []string{}
+	for key := range c.state[namespace] {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Channel) appendConfig(envelope *common.Envelope, config *common.Config) *common.Block {
+	c.Lock()
+	defer c.Unlock()
+	number := uint64(len(c.blocks))
+	c.config = config
+	c.lastConfig = number
+	c.recordTransaction(envelope, number, peer.TxValidationCode_VALID)
+	return c.appendBlock(envelope, peer.TxValidationCode_VALID)
+}
+
+func (c *Channel) appendTransaction(envelope *common.Envelope) *common.Block {
+	c.Lock()
+	defer c.Unlock()
+	number := uint64(len(c.blocks))
+	validationCode := c.validateAndCommit(envelope, number)
+	return c.appendBlock(envelope, validationCode)
+}
+
+func (c *Channel) appendBlock(envelope *common.Envelope, validationCode peer.TxValidationCode) *common.Block {
+	number := uint64(len(c.blocks))
+	data := util.MarshalOrPanic(envelope)
+	dataHash := sha256.Sum256(data)
+	var previousHash []byte
+	if number > 0 {
+		previousHash = blockHeaderHash(c.blocks[number-1].Header)
+	}
+	lastConfig := util.MarshalOrPanic(&common.LastConfig{Index: c.lastConfig})
+	block := &common.Block{
+		Header: &common.BlockHeader{
+			Number:       number,
+			PreviousHash: previousHash,
+			DataHash:     dataHash[:],
+		},
+		Data: &common.BlockData{
+			Data: [][]byte{data},
+		},
+		Metadata: &common.BlockMetadata{
+			Metadata: [][]byte{
+				util.MarshalOrPanic(&common.Metadata{
+					Value: util.MarshalOrPanic(&common.OrdererBlockMetadata{
+						LastConfig: &common.LastConfig{Index: c.lastConfig},
+					}),
+				}),
+				util.MarshalOrPanic(&common.Metadata{Value: lastConfig}),
+				{byte(validationCode)},
+				{},
+				{},
+			},
+		},
+	}
+	c.blocks = append(c.blocks, block)
+	close(c.updated)
+	c.updated = make(chan struct{})
+	return block
+}
+
+func (c *Channel) recordTransaction(envelope *common.Envelope, blockNumber uint64, validationCode peer.TxValidationCode) {
+	channelHeader, err := getChannelHeader(envelope)
+	if err != nil || channelHeader.TxId == "" {
+		return
+	}
+	if _, ok := c.transactions[channelHeader.TxId]; ok {
+		return
+	}
+	c.transactions[channelHeader.TxId] = &transaction{blockNumber, envelope, validationCode}
+}
+
+func (c *Channel) validateAndCommit(envelope *common.Envelope, blockNumber uint64) peer.TxValidationCode {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return peer.TxValidationCode_BAD_PAYLOAD
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return peer.TxValidationCode_BAD_CHANNEL_HEADER
+	}
+	if _, ok := c.transactions[channelHeader.TxId]; ok {
+		return peer.TxValidationCode_DUPLICATE_TXID
+	}
+	validationCode, writes := c.validate(payload)
+	if validationCode == peer.TxValidationCode_VALID {
+		version := &kvrwset.Version{BlockNum: blockNumber, TxNum: 0}
+		for _, nsWrites := range writes {
+			for _, write := range nsWrites.writes {
+				namespaceState, ok := c.state[nsWrites.namespace]
+				if !ok {
+					namespaceState = map[string]*versionedValue{}
+					c.state[nsWrites.namespace] = namespaceState
+				}
+				if write.IsDelete {
+					delete(namespaceState, write.Key)
+				} else {
+					namespaceState[write.Key] = &versionedValue{write.Value, version}
+				}
+			}
+		}
+	}
+	c.recordTransaction(envelope, blockNumber, validationCode)
+	return validationCode
+}
+
+type namespaceWrites struct {
+	namespace string
+	writes    []*kvrwset.KVWrite
+}
+
+func (c *Channel) validate(payload *common.Payload) (peer.TxValidationCode, []*namespaceWrites) {
+	tx := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.Data, tx); err != nil {
+		return peer.TxValidationCode_BAD_PAYLOAD, nil
+	}
+	result := []*namespaceWrites{}
+	for _, action := range tx.Actions {
+		chaincodeActionPayload := &peer.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(action.Payload, chaincodeActionPayload); err != nil {
+			return peer.TxValidationCode_BAD_PAYLOAD, nil
+		}
+		proposalResponsePayload := &peer.ProposalResponsePayload{}
+		if err := proto.Unmarshal(chaincodeActionPayload.GetAction().GetProposalResponsePayload(), proposalResponsePayload); err != nil {
+			return peer.TxValidationCode_BAD_RESPONSE_PAYLOAD, nil
+		}
+		chaincodeAction := &peer.ChaincodeAction{}
+		if err := proto.Unmarshal(proposalResponsePayload.Extension, chaincodeAction); err != nil {
+			return peer.TxValidationCode_BAD_RESPONSE_PAYLOAD, nil
+		}
+		txRWSet := &rwset.TxReadWriteSet{}
+		if err := proto.Unmarshal(chaincodeAction.Results, txRWSet); err != nil {
+			return peer.TxValidationCode_BAD_RWSET, nil
+		}
+		for _, nsRWSet := range txRWSet.NsRwset {
+			kvRWSet := &kvrwset.KVRWSet{}
+			if err := proto.Unmarshal(nsRWSet.Rwset, kvRWSet); err != nil {
+				return peer.TxValidationCode_BAD_RWSET, nil
+			}
+			for _, read := range kvRWSet.Reads {
+				current := c.state[nsRWSet.Namespace][read.Key]
+				if !sameVersion(current, read.Version) {
+					return peer.TxValidationCode_MVCC_READ_CONFLICT, nil
+				}
+			}
+			result = append(result, &namespaceWrites{nsRWSet.Namespace, kvRWSet.Writes})
+		}
+	}
+	return peer.TxValidationCode_VALID, result
+}
+
+func sameVersion(current *versionedValue, version *kvrwset.Version) bool {
+	if current == nil {
+		return version == nil
+	} else if version == nil {
+		return false
+	}
+	return current.version.BlockNum == version.BlockNum && current.version.TxNum == version.TxNum
+}
+
+func blockHeaderHash(header *common.BlockHeader) []byte {
+	data, err := asn1.Marshal(asn1Header{
+		Number:       new(big.Int).SetUint64(header.Number),
+		PreviousHash: header.PreviousHash,
+		DataHash:     header.DataHash,
+	})
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+func getChannelHeader(envelope *common.Envelope) (*common.ChannelHeader, error) {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return nil, err
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return nil, err
+	}
+	return channelHeader, nil
+}
@@ -0,0 +1,262 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+)
+
+const lifecycleNamespace = "_lifecycle"
+
+// chaincodeDefinition is stored as JSON in the _lifecycle namespace of the world state, using a simpler layout than Fabric.
+type chaincodeDefinition struct {
+	Sequence            int64  `json:"sequence"`
+	Version             string `json:"version"`
+	EndorsementPlugin   string `json:"endorsement_plugin"`
+	ValidationPlugin    string `json:"validation_plugin"`
+	ValidationParameter []byte `json:"validation_parameter"`
+	InitRequired        bool   `json:"init_required"`
+	PackageID           string `json:"package_id,omitempty"`
+}
+
+func definitionKey(name string) string {
+	return fmt.Sprintf("definitions/%s", name)
+}
+
+func approvalKey(name, mspID string) string {
+	return fmt.Sprintf("approvals/%s/%s", name, mspID)
+}
+
+func getDefinition(channel *Channel, name string) (*chaincodeDefinition, bool) {
+	return decodeDefinition(channel.getState(lifecycleNamespace, definitionKey(name)))
+}
+
+func getApproval(channel *Channel, name, mspID string) (*chaincodeDefinition, bool) {
+	return decodeDefinition(channel.getState(lifecycleNamespace, approvalKey(name, mspID)))
+}
+
+func decodeDefinition(value *versionedValue) (*chaincodeDefinition, bool) {
+	if value == nil {
+		return nil, false
+	}
+	definition := &chaincodeDefinition{}
+	if err := json.Unmarshal(value.value, definition); err != nil {
+		return nil, false
+	}
+	return definition, true
+}
+
+func (d *chaincodeDefinition) matches(other *chaincodeDefinition) bool {
+	return d.Sequence == other.Sequence && d.Version == other.Version && d.InitRequired == other.InitRequired
+}
+
+func readDefinition(simulator *simulator, key string) *chaincodeDefinition {
+	value := simulator.getState(lifecycleNamespace, key)
+	if value == nil {
+		return nil
+	}
+	definition := &chaincodeDefinition{}
+	if err := json.Unmarshal(value, definition); err != nil {
+		return nil
+	}
+	return definition
+}
+
+func writeDefinition(simulator *simulator, key string, definition *chaincodeDefinition) {
+	value, err := json.Marshal(definition)
+	if err != nil {
+		panic(err)
+	}
+	simulator.putState(lifecycleNamespace, key, value)
+}
+
+// invokeLifecycle handles the subset of the _lifecycle system chaincode used to install, approve, commit and query chaincode.
+// The simulator is nil for functions that are not invoked on a channel.
+func (p *Peer) invokeLifecycle(proposal *proposal, simulator *simulator) *peer.ProposalResponse {
+	args := proposal.args()
+	if len(args) < 1 {
+		return errorResponse("Incorrect number of arguments, expecting an argument message")
+	}
+	arg := args[0]
+	function := proposal.function()
+	switch function {
+	case "InstallChaincode":
+		request := &lifecycle.InstallChaincodeArgs{}
+		if err := proto.Unmarshal(arg, request); err != nil {
+			return errorResponse(err.Error())
+		}
+		pkg, err := p.installPackage(request.ChaincodeInstallPackage)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		return successResponse(util.MarshalOrPanic(&lifecycle.InstallChaincodeResult{
+			PackageId: pkg.id,
+			Label:     pkg.label,
+		}))
+	case "QueryInstalledChaincodes":
+		result := &lifecycle.QueryInstalledChaincodesResult{}
+		for _, pkg := range p.installedPackages() {
+			result.InstalledChaincodes = append(result.InstalledChaincodes, &lifecycle.QueryInstalledChaincodesResult_InstalledChaincode{
+				PackageId: pkg.id,
+				Label:     pkg.label,
+			})
+		}
+		return successResponse(util.MarshalOrPanic(result))
+	case "GetInstalledChaincodePackage":
+		request := &lifecycle.GetInstalledChaincodePackageArgs{}
+		if err := proto.Unmarshal(arg, request); err != nil {
+			return errorResponse(err.Error())
+		}
+		pkg, ok := p.installedPackage(request.PackageId)
+		if !ok {
+			return errorResponse(fmt.Sprintf("could not load package for package ID '%s'", request.PackageId))
+		}
+		return successResponse(util.MarshalOrPanic(&lifecycle.GetInstalledChaincodePackageResult{
+			ChaincodeInstallPackage: pkg.data,
+		}))
+	}
+	if simulator == nil {
+		return errorResponse(fmt.Sprintf("Function %s must be invoked on a channel", function))
+	}
+	switch function {
+	case "ApproveChaincodeDefinitionForMyOrg":
+		request := &lifecycle.ApproveChaincodeDefinitionForMyOrgArgs{}
+		if err := proto.Unmarshal(arg, request); err != nil {
+			return errorResponse(err.Error())
+		}
+		approval := &chaincodeDefinition{
+			Sequence:            request.Sequence,
+			Version:             request.Version,
+			EndorsementPlugin:   request.EndorsementPlugin,
+			ValidationPlugin:    request.ValidationPlugin,
+			ValidationParameter: request.ValidationParameter,
+			InitRequired:        request.InitRequired,
+			PackageID:           request.GetSource().GetLocalPackage().GetPackageId(),
+		}
+		if err := checkSequence(simulator, request.Name, request.Sequence, true); err != nil {
+			return errorResponse(err.Error())
+		}
+		writeDefinition(simulator, approvalKey(request.Name, p.peer.MSPID()), approval)
+		return successResponse(nil)
+	case "CheckCommitReadiness":
+		request := &lifecycle.CheckCommitReadinessArgs{}
+		if err := proto.Unmarshal(arg, request); err != nil {
+			return errorResponse(err.Error())
+		}
+		definition := &chaincodeDefinition{Sequence: request.Sequence, Version: request.Version, InitRequired: request.InitRequired}
+		return successResponse(util.MarshalOrPanic(&lifecycle.CheckCommitReadinessResult{
+			Approvals: approvals(simulator, request.Name, definition),
+		}))
+	case "CommitChaincodeDefinition":
+		request := &lifecycle.CommitChaincodeDefinitionArgs{}
+		if err := proto.Unmarshal(arg, request); err != nil {
+			return errorResponse(err.Error())
+		}
+		definition := &chaincodeDefinition{
+			Sequence:            request.Sequence,
+			Version:             request.Version,
+			EndorsementPlugin:   request.EndorsementPlugin,
+			ValidationPlugin:    request.ValidationPlugin,
+			ValidationParameter: request.ValidationParameter,
+			InitRequired:        request.InitRequired,
+		}
+		if err := checkSequence(simulator, request.Name, request.Sequence, false); err != nil {
+			return errorResponse(err.Error())
+		}
+		approved := false
+		for _, value := range approvals(simulator, request.Name, definition) {
+			approved = approved || value
+		}
+		if !approved {
+			return errorResponse(fmt.Sprintf("chaincode definition not agreed to by any organization on channel %s", simulator.channel.Name()))
+		}
+		writeDefinition(simulator, definitionKey(request.Name), definition)
+		return successResponse(util.MarshalOrPanic(&lifecycle.CommitChaincodeDefinitionResult{}))
+	case "QueryChaincodeDefinition":
+		request := &lifecycle.QueryChaincodeDefinitionArgs{}
+		if err := proto.Unmarshal(arg, request); err != nil {
+			return errorResponse(err.Error())
+		}
+		definition := readDefinition(simulator, definitionKey(request.Name))
+		if definition == nil {
+			return &peer.ProposalResponse{
+				Version: 1,
+				Response: &peer.Response{
+					Status:  int32(common.Status_NOT_FOUND),
+					Message: fmt.Sprintf("namespace %s is not defined", request.Name),
+				},
+			}
+		}
+		return successResponse(util.MarshalOrPanic(&lifecycle.QueryChaincodeDefinitionResult{
+			Sequence:            definition.Sequence,
+			Version:             definition.Version,
+			EndorsementPlugin:   definition.EndorsementPlugin,
+			ValidationPlugin:    definition.ValidationPlugin,
+			ValidationParameter: definition.ValidationParameter,
+			InitRequired:        definition.InitRequired,
+			Approvals:           approvals(simulator, request.Name, definition),
+		}))
+	case "QueryChaincodeDefinitions":
+		result := &lifecycle.QueryChaincodeDefinitionsResult{}
+		for _, key := range simulator.channel.getStateRange(lifecycleNamespace, "definitions/", "definitions0") {
+			definition := readDefinition(simulator, key)
+			if definition == nil {
+				continue
+			}
+			result.ChaincodeDefinitions = append(result.ChaincodeDefinitions, &lifecycle.QueryChaincodeDefinitionsResult_ChaincodeDefinition{
+				Name:                strings.TrimPrefix(key, "definitions/"),
+				Sequence:            definition.Sequence,
+				Version:             definition.Version,
+				EndorsementPlugin:   definition.EndorsementPlugin,
+				ValidationPlugin:    definition.ValidationPlugin,
+				ValidationParameter: definition.ValidationParameter,
+				InitRequired:        definition.InitRequired,
+			})
+		}
+		return successResponse(util.MarshalOrPanic(result))
+	}
+	return errorResponse(fmt.Sprintf("Function %s is not supported by _lifecycle in mock mode", function))
+}
+
+// checkSequence checks that the requested sequence is the next sequence for the chaincode. Approvals may also be
+// made for the current sequence.
+func checkSequence(simulator *simulator, name string, sequence int64, allowCurrent bool) error {
+	var current int64
+	if definition := readDefinition(simulator, definitionKey(name)); definition != nil {
+		current = definition.Sequence
+	}
+	if sequence == current+1 || (allowCurrent && sequence == current && current > 0) {
+		return nil
+	}
+	return fmt.Errorf("requested sequence is %d, but new definition must be sequence %d", sequence, current+1)
+}
+
+// approvals returns whether each organization in the channel has approved the specified definition.
+func approvals(simulator *simulator, name string, definition *chaincodeDefinition) map[string]bool {
+	result := map[string]bool{}
+	application, ok := simulator.channel.Config().GetChannelGroup().GetGroups()["Application"]
+	if !ok {
+		return result
+	}
+	mspIDs := []string{}
+	for mspID := range application.Groups {
+		mspIDs = append(mspIDs, mspID)
+	}
+	sort.Strings(mspIDs)
+	for _, mspID := range mspIDs {
+		approval := readDefinition(simulator, approvalKey(name, mspID))
+		result[mspID] = approval != nil && approval.matches(definition)
+	}
+	return result
+}
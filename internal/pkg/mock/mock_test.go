@@ -0,0 +1,255 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger-labs/microfab/internal/pkg/channel"
+	"github.com/hyperledger-labs/microfab/internal/pkg/config"
+	"github.com/hyperledger-labs/microfab/internal/pkg/mock"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	fpeer "github.com/hyperledger/fabric-protos-go/peer"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+)
+
+func freePort() int32 {
+	listener, err := net.Listen("tcp", "localhost:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+	return int32(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func buildTarGz(files map[string][]byte) []byte {
+	buffer := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, contents := range files {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+			Typeflag: tar.TypeReg,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tarWriter.Write(contents)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+	return buffer.Bytes()
+}
+
+func buildPackage(chaincodeType, address string) []byte {
+	code := buildTarGz(map[string][]byte{
+		"connection.json": []byte(fmt.Sprintf(`{"address":"%s","dial_timeout":"5s"}`, address)),
+	})
+	return buildTarGz(map[string][]byte{
+		"metadata.json": []byte(fmt.Sprintf(`{"type":"%s","label":"asset"}`, chaincodeType)),
+		"code.tar.gz":   code,
+	})
+}
+
+// testChaincode is a minimal chaincode-as-a-service server with put and get functions.
+type testChaincode struct{}
+
+func (c *testChaincode) Connect(stream fpeer.Chaincode_ConnectServer) error {
+	err := stream.Send(&fpeer.ChaincodeMessage{
+		Type:    fpeer.ChaincodeMessage_REGISTER,
+		Payload: util.MarshalOrPanic(&fpeer.ChaincodeID{Name: "asset"}),
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		} else if msg.Type != fpeer.ChaincodeMessage_TRANSACTION {
+			continue
+		}
+		input := &fpeer.ChaincodeInput{}
+		util.UnmarshalOrPanic(msg.Payload, input)
+		request := &fpeer.ChaincodeMessage{Txid: msg.Txid, ChannelId: msg.ChannelId}
+		switch string(input.Args[0]) {
+		case "put":
+			request.Type = fpeer.ChaincodeMessage_PUT_STATE
+			request.Payload = util.MarshalOrPanic(&fpeer.PutState{Key: string(input.Args[1]), Value: input.Args[2]})
+		case "get":
+			request.Type = fpeer.ChaincodeMessage_GET_STATE
+			request.Payload = util.MarshalOrPanic(&fpeer.GetState{Key: string(input.Args[1])})
+		}
+		if err := stream.Send(request); err != nil {
+			return err
+		}
+		response, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		err = stream.Send(&fpeer.ChaincodeMessage{
+			Type:           fpeer.ChaincodeMessage_COMPLETED,
+			Payload:        util.MarshalOrPanic(&fpeer.Response{Status: 200, Payload: response.Payload}),
+			Txid:           msg.Txid,
+			ChannelId:      msg.ChannelId,
+			ChaincodeEvent: &fpeer.ChaincodeEvent{EventName: string(input.Args[0])},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+var _ = Describe("the mock package", func() {
+
+	var ledger *mock.Ledger
+	var testOrganization *organization.Organization
+	var testOrderer *orderer.Orderer
+	var testPeer *peer.Peer
+	var mockOrderer *mock.Orderer
+	var mockPeer *mock.Peer
+	var ordererConnection *orderer.Connection
+	var peerConnection *peer.Connection
+
+	BeforeEach(func() {
+		testDirectory, err := ioutil.TempDir("", "ut-mock")
+		Expect(err).NotTo(HaveOccurred())
+		ordererOrganization, err := organization.New("Orderer", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		testOrganization, err = organization.New("Org1", nil, nil)
+		Expect(err).NotTo(HaveOccurred())
+		ledger = mock.NewLedger()
+		testOrderer, err = orderer.New(ordererOrganization, testDirectory, 8080, freePort(), "grpc://orderer-api.127-0-0-1.nip.io:8080", freePort(), "http://orderer-operations.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		mockOrderer = mock.NewOrderer(testOrderer, []*organization.Organization{testOrganization}, ledger)
+		Expect(mockOrderer.Start()).To(Succeed())
+		testPeer, err = peer.New(testOrganization, testDirectory, 8080, freePort(), "grpc://org1peer-api.127-0-0-1.nip.io:8080", freePort(), "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", freePort(), "http://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, freePort(), "http://org1peer-gossip.127-0-0-1.nip.io:8080")
+		Expect(err).NotTo(HaveOccurred())
+		mockPeer = mock.NewPeer(testPeer, ledger)
+		Expect(mockPeer.Start()).To(Succeed())
+		ordererConnection, err = orderer.Connect(testOrderer, testOrganization.MSPID(), testOrganization.Admin())
+		Expect(err).NotTo(HaveOccurred())
+		peerConnection, err = peer.Connect(testPeer, testOrganization.MSPID(), testOrganization.Admin())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		peerConnection.Close()
+		ordererConnection.Close()
+		Expect(mockPeer.Stop()).To(Succeed())
+		Expect(mockOrderer.Stop()).To(Succeed())
+	})
+
+	createAndJoinChannel := func() {
+		err := channel.CreateChannel(ordererConnection, "channel1", channel.AddMSPID(testOrganization.MSPID()))
+		Expect(err).NotTo(HaveOccurred())
+		genesisBlock, err := blocks.GetGenesisBlock(ordererConnection, "channel1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(peerConnection.JoinChannel(genesisBlock)).To(Succeed())
+	}
+
+	Context("mock.Peer and mock.Orderer", func() {
+
+		It("serves the operations health check", func() {
+			resp, err := http.Get(fmt.Sprintf("%s/healthz", testPeer.OperationsURL(true)))
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(200))
+		})
+
+		It("creates channels that the peer can join", func() {
+			createAndJoinChannel()
+			channels, err := peerConnection.ListChannels()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(channels).To(Equal([]string{"channel1"}))
+			Expect(mockPeer.Channels()).To(Equal([]string{"channel1"}))
+			channelConfig, err := config.GetConfig(mockPeer, "channel1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(channelConfig.ChannelGroup.Groups["Application"].Groups).To(HaveKey(testOrganization.MSPID()))
+			Expect(channelConfig.ChannelGroup.Groups["Application"].Groups[testOrganization.MSPID()].Values).To(HaveKey("MSP"))
+		})
+
+		It("does not deliver blocks from channels that the peer has not joined", func() {
+			err := channel.CreateChannel(ordererConnection, "channel1", channel.AddMSPID(testOrganization.MSPID()))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = blocks.GetNewestBlock(peerConnection, "channel1")
+			Expect(err).To(MatchError(ContainSubstring("NOT_FOUND")))
+		})
+
+		It("applies channel configuration updates", func() {
+			createAndJoinChannel()
+			err := channel.UpdateChannel(ordererConnection, "channel1", channel.AddAnchorPeer(testOrganization.MSPID(), "org1peer-api.127-0-0-1.nip.io", 8080))
+			Expect(err).NotTo(HaveOccurred())
+			newestBlock, err := blocks.GetNewestBlock(mockPeer, "channel1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newestBlock.Header.Number).To(BeEquivalentTo(1))
+			channelConfig, err := config.GetConfig(peerConnection, "channel1")
+			Expect(err).NotTo(HaveOccurred())
+			organizationGroup := channelConfig.ChannelGroup.Groups["Application"].Groups[testOrganization.MSPID()]
+			Expect(organizationGroup.Values).To(HaveKey("AnchorPeers"))
+			Expect(organizationGroup.Values).To(HaveKey("MSP"))
+		})
+
+		It("installs, approves, commits and invokes chaincode-as-a-service", func() {
+			listener, err := net.Listen("tcp", "localhost:0")
+			Expect(err).NotTo(HaveOccurred())
+			server := grpc.NewServer()
+			fpeer.RegisterChaincodeServer(server, &testChaincode{})
+			go server.Serve(listener)
+			defer server.Stop()
+			createAndJoinChannel()
+			packageID, err := peerConnection.InstallChaincode(buildPackage("ccaas", listener.Addr().String()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(packageID).To(HavePrefix("asset:"))
+			peers := []*peer.Connection{peerConnection}
+			err = channel.ApproveChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0", packageID)
+			Expect(err).NotTo(HaveOccurred())
+			err = channel.CommitChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = channel.SubmitTransaction(peers, ordererConnection, "channel1", "asset", "put", "key1", "value1")
+			Expect(err).NotTo(HaveOccurred())
+			result, err := channel.EvaluateTransaction(peers, ordererConnection, "channel1", "asset", "get", "key1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal("value1"))
+		})
+
+		It("rejects a second commit of the same chaincode definition", func() {
+			createAndJoinChannel()
+			packageID, err := peerConnection.InstallChaincode(buildPackage("ccaas", "localhost:9999"))
+			Expect(err).NotTo(HaveOccurred())
+			peers := []*peer.Connection{peerConnection}
+			err = channel.ApproveChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0", packageID)
+			Expect(err).NotTo(HaveOccurred())
+			err = channel.CommitChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0")
+			Expect(err).NotTo(HaveOccurred())
+			err = channel.CommitChaincodeDefinition(peers, ordererConnection, "channel1", 1, "asset", "1.0")
+			Expect(err).To(MatchError(ContainSubstring("new definition must be sequence 2")))
+		})
+
+		It("rejects chaincode packages that are not chaincode-as-a-service", func() {
+			_, err := peerConnection.InstallChaincode(buildPackage("golang", "localhost:9999"))
+			Expect(err).To(MatchError(ContainSubstring("not supported in mock mode")))
+		})
+
+		It("fails to invoke chaincode that has not been defined", func() {
+			createAndJoinChannel()
+			_, err := channel.EvaluateTransaction([]*peer.Connection{peerConnection}, ordererConnection, "channel1", "asset", "get", "key1")
+			Expect(err).To(MatchError(ContainSubstring("chaincode asset not found")))
+		})
+
+	})
+
+})
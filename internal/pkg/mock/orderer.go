@@ -0,0 +1,326 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
+	"github.com/hyperledger-labs/microfab/internal/pkg/protoutil"
+	"github.com/hyperledger-labs/microfab/internal/pkg/txid"
+	"github.com/hyperledger/fabric-protos-go/common"
+	ab "github.com/hyperledger/fabric-protos-go/orderer"
+	"google.golang.org/grpc"
+)
+
+// Orderer represents a mock ordering service that cuts a new block for every transaction it receives.
+type Orderer struct {
+	sync.Mutex
+	orderer    *orderer.Orderer
+	consortium []*organization.Organization
+	ledger     *Ledger
+	grpcServer *grpc.Server
+	httpServer *http.Server
+}
+
+type atomicBroadcastServer struct {
+	orderer *Orderer
+}
+
+// NewOrderer creates a new mock ordering service for the specified orderer definition.
+func NewOrderer(orderer *orderer.Orderer, consortium []*organization.Organization, ledger *Ledger) *Orderer {
+	return &Orderer{
+		orderer:    orderer,
+		consortium: consortium,
+		ledger:     ledger,
+	}
+}
+
+// Start starts the mock ordering service.
+func (o *Orderer) Start() error {
+	grpcServer, err := newGRPCServer(o.orderer.TLS())
+	if err != nil {
+		return err
+	}
+	ab.RegisterAtomicBroadcastServer(grpcServer, &atomicBroadcastServer{o})
+	httpServer, err := newOperationsServer(o.orderer.OperationsPort(true), o.orderer.TLS())
+	if err != nil {
+		return err
+	}
+	err = startServers(grpcServer, o.orderer.APIPort(true), httpServer)
+	if err != nil {
+		return err
+	}
+	o.grpcServer = grpcServer
+	o.httpServer = httpServer
+	return nil
+}
+
+// Stop stops the mock ordering service.
+func (o *Orderer) Stop() error {
+	err := stopServers(o.grpcServer, o.httpServer)
+	o.grpcServer = nil
+	o.httpServer = nil
+	return err
+}
+
+// MSPID returns the MSP ID of the orderer.
+func (o *Orderer) MSPID() string {
+	return o.orderer.MSPID()
+}
+
+// Identity returns the identity of the orderer.
+func (o *Orderer) Identity() *identity.Identity {
+	return o.orderer.Identity()
+}
+
+// Deliver requests one or more blocks from the mock ordering service, without going through gRPC.
+func (o *Orderer) Deliver(envelope *common.Envelope, callback blocks.DeliverCallback) error {
+	status, err := seekBlocks(context.Background(), o.ledger.Channel, envelope, callback)
+	if err != nil {
+		return err
+	} else if status != common.Status_SUCCESS {
+		return fmt.Errorf("Bad status returned by orderer: %v", status)
+	}
+	return nil
+}
+
+// Broadcast orders the specified envelope into a new block.
+func (o *Orderer) Broadcast(envelope *common.Envelope) error {
+	status, err := o.order(envelope)
+	if err != nil {
+		return err
+	} else if status != common.Status_SUCCESS {
+		return fmt.Errorf("Bad status returned by ordering service %v", status)
+	}
+	return nil
+}
+
+func (o *Orderer) order(envelope *common.Envelope) (common.Status, error) {
+	// Channels are created and updated one at a time, so that two updates cannot be based on the same config.
+	o.Lock()
+	defer o.Unlock()
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+		return common.Status_BAD_REQUEST, err
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return common.Status_BAD_REQUEST, err
+	}
+	switch common.HeaderType(channelHeader.Type) {
+	case common.HeaderType_CONFIG_UPDATE:
+		return o.updateConfig(channelHeader.ChannelId, envelope, payload)
+	case common.HeaderType_ENDORSER_TRANSACTION:
+		channel, ok := o.ledger.Channel(channelHeader.ChannelId)
+		if !ok {
+			return common.Status_NOT_FOUND, fmt.Errorf("Channel %s does not exist", channelHeader.ChannelId)
+		}
+		block := channel.appendTransaction(envelope)
+		logger.Debugf("Ordered transaction %s into block %d on channel %s", channelHeader.TxId, block.Header.Number, channelHeader.ChannelId)
+		return common.Status_SUCCESS, nil
+	}
+	return common.Status_BAD_REQUEST, fmt.Errorf("Unsupported header type %v", common.HeaderType(channelHeader.Type))
+}
+
+func (o *Orderer) updateConfig(channelID string, envelope *common.Envelope, payload *common.Payload) (common.Status, error) {
+	configUpdateEnvelope := &common.ConfigUpdateEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configUpdateEnvelope); err != nil {
+		return common.Status_BAD_REQUEST, err
+	}
+	configUpdate := &common.ConfigUpdate{}
+	if err := proto.Unmarshal(configUpdateEnvelope.ConfigUpdate, configUpdate); err != nil {
+		return common.Status_BAD_REQUEST, err
+	}
+	consortium, err := o.consortiumGroups()
+	if err != nil {
+		return common.Status_INTERNAL_SERVER_ERROR, err
+	}
+	channel, ok := o.ledger.Channel(channelID)
+	if !ok {
+		config, err := o.newChannelConfig(configUpdate)
+		if err != nil {
+			return common.Status_BAD_REQUEST, err
+		}
+		if err := fillOrganizations(config, consortium); err != nil {
+			return common.Status_BAD_REQUEST, err
+		}
+		err = o.ledger.createChannel(channelID, o.buildConfigEnvelope(channelID, config, envelope), config)
+		if err != nil {
+			return common.Status_BAD_REQUEST, err
+		}
+		logger.Printf("Created channel %s", channelID)
+		return common.Status_SUCCESS, nil
+	}
+	config := channel.Config()
+	config.ChannelGroup = mergeGroup(config.ChannelGroup, configUpdate.WriteSet)
+	config.Sequence++
+	if err := fillOrganizations(config, consortium); err != nil {
+		return common.Status_BAD_REQUEST, err
+	}
+	block := channel.appendConfig(o.buildConfigEnvelope(channelID, config, envelope), config)
+	logger.Printf("Updated channel %s in block %d", channelID, block.Header.Number)
+	return common.Status_SUCCESS, nil
+}
+
+func (o *Orderer) consortiumGroups() (map[string]*common.ConfigGroup, error) {
+	systemConfig, err := o.orderer.SystemChannelConfig(o.consortium)
+	if err != nil {
+		return nil, err
+	}
+	return systemConfig.ChannelGroup.Groups["Consortiums"].Groups["SampleConsortium"].Groups, nil
+}
+
+// newChannelConfig builds the configuration for a new channel in the same way as the real ordering service,
+// by combining the configuration of the system channel with the application group from the config update.
+func (o *Orderer) newChannelConfig(configUpdate *common.ConfigUpdate) (*common.Config, error) {
+	application, ok := configUpdate.GetWriteSet().GetGroups()["Application"]
+	if !ok {
+		return nil, fmt.Errorf("Config update for channel %s does not contain an application group", configUpdate.ChannelId)
+	}
+	config, err := o.orderer.SystemChannelConfig(o.consortium)
+	if err != nil {
+		return nil, err
+	}
+	delete(config.ChannelGroup.Groups, "Consortiums")
+	config.ChannelGroup.Groups["Application"] = application
+	if consortium, ok := configUpdate.WriteSet.Values["Consortium"]; ok {
+		config.ChannelGroup.Values["Consortium"] = consortium
+	}
+	config.Sequence = 1
+	return config, nil
+}
+
+func (o *Orderer) buildConfigEnvelope(channelID string, config *common.Config, lastUpdate *common.Envelope) *common.Envelope {
+	txID := txid.New(o.orderer.MSPID(), o.orderer.Identity())
+	header := protoutil.BuildHeader(common.HeaderType_CONFIG, channelID, txID)
+	configEnvelope := &common.ConfigEnvelope{
+		Config:     config,
+		LastUpdate: lastUpdate,
+	}
+	payload := protoutil.BuildPayload(header, configEnvelope)
+	return protoutil.BuildEnvelope(payload, o.orderer.Identity())
+}
+
+// fillOrganizations replaces any empty organization groups in the application group with the definition of
+// that organization from the consortium, as the real ordering service does when a channel is created.
+func fillOrganizations(config *common.Config, consortium map[string]*common.ConfigGroup) error {
+	application, ok := config.ChannelGroup.Groups["Application"]
+	if !ok {
+		return nil
+	}
+	for mspID, group := range application.Groups {
+		if len(group.Values) > 0 || len(group.Policies) > 0 {
+			continue
+		}
+		definition, ok := consortium[mspID]
+		if !ok {
+			return fmt.Errorf("Organization %s is not a member of the consortium", mspID)
+		}
+		application.Groups[mspID] = proto.Clone(definition).(*common.ConfigGroup)
+	}
+	return nil
+}
+
+// mergeGroup applies the write set of a config update to a config group. Elements in the write set with the same version
+// as the current element are unchanged, and only their children are merged. Elements with a different version replace the
+// current element. If a group has a different version, then its set of children is exactly the set in the write set.
+func mergeGroup(current *common.ConfigGroup, update *common.ConfigGroup) *common.ConfigGroup {
+	if current == nil {
+		return update
+	}
+	result := &common.ConfigGroup{
+		Version:   current.Version,
+		ModPolicy: current.ModPolicy,
+		Groups:    map[string]*common.ConfigGroup{},
+		Values:    map[string]*common.ConfigValue{},
+		Policies:  map[string]*common.ConfigPolicy{},
+	}
+	if update.Version == current.Version {
+		for key, group := range current.Groups {
+			result.Groups[key] = group
+		}
+		for key, value := range current.Values {
+			result.Values[key] = value
+		}
+		for key, policy := range current.Policies {
+			result.Policies[key] = policy
+		}
+	} else {
+		result.Version = update.Version
+		result.ModPolicy = update.ModPolicy
+	}
+	for key, group := range update.Groups {
+		result.Groups[key] = mergeGroup(current.Groups[key], group)
+	}
+	for key, value := range update.Values {
+		if existing, ok := current.Values[key]; ok && existing.Version == value.Version {
+			result.Values[key] = existing
+		} else {
+			result.Values[key] = value
+		}
+	}
+	for key, policy := range update.Policies {
+		if existing, ok := current.Policies[key]; ok && existing.Version == policy.Version {
+			result.Policies[key] = existing
+		} else {
+			result.Policies[key] = policy
+		}
+	}
+	return result
+}
+
+func (s *atomicBroadcastServer) Broadcast(stream ab.AtomicBroadcast_BroadcastServer) error {
+	for {
+		envelope, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		response := &ab.BroadcastResponse{}
+		response.Status, err = s.orderer.order(envelope)
+		if err != nil {
+			logger.Warnf("Failed to order transaction: %v", err)
+			response.Info = err.Error()
+		}
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *atomicBroadcastServer) Deliver(stream ab.AtomicBroadcast_DeliverServer) error {
+	for {
+		envelope, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		status, err := seekBlocks(stream.Context(), s.orderer.ledger.Channel, envelope, func(block *common.Block) error {
+			return stream.Send(&ab.DeliverResponse{
+				Type: &ab.DeliverResponse_Block{Block: block},
+			})
+		})
+		if err != nil {
+			return err
+		}
+		err = stream.Send(&ab.DeliverResponse{
+			Type: &ab.DeliverResponse_Status{Status: status},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
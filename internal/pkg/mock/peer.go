@@ -0,0 +1,337 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/blocks"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger/fabric-protos-go/common"
+	fpeer "github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+)
+
+// Peer represents a mock peer that endorses proposals and delivers blocks from the shared ledger.
+type Peer struct {
+	sync.Mutex
+	peer            *peer.Peer
+	ledger          *Ledger
+	channels        map[string]bool
+	packages        map[string]*chaincodePackage
+	chaincodesMutex sync.Mutex
+	chaincodes      map[string]*chaincodeConnection
+	grpcServer      *grpc.Server
+	httpServer      *http.Server
+}
+
+type endorserServer struct {
+	peer *Peer
+}
+
+type deliverServer struct {
+	peer *Peer
+}
+
+// NewPeer creates a new mock peer for the specified peer definition.
+func NewPeer(peer *peer.Peer, ledger *Ledger) *Peer {
+	return &Peer{
+		peer:       peer,
+		ledger:     ledger,
+		channels:   map[string]bool{},
+		packages:   map[string]*chaincodePackage{},
+		chaincodes: map[string]*chaincodeConnection{},
+	}
+}
+
+// Start starts the mock peer.
+func (p *Peer) Start() error {
+	grpcServer, err := newGRPCServer(p.peer.TLS())
+	if err != nil {
+		return err
+	}
+	fpeer.RegisterEndorserServer(grpcServer, &endorserServer{p})
+	fpeer.RegisterDeliverServer(grpcServer, &deliverServer{p})
+	httpServer, err := newOperationsServer(p.peer.OperationsPort(true), p.peer.TLS())
+	if err != nil {
+		return err
+	}
+	err = startServers(grpcServer, p.peer.APIPort(true), httpServer)
+	if err != nil {
+		return err
+	}
+	p.grpcServer = grpcServer
+	p.httpServer = httpServer
+	return nil
+}
+
+// Stop stops the mock peer, and closes any connections to chaincode.
+func (p *Peer) Stop() error {
+	p.chaincodesMutex.Lock()
+	for address, connection := range p.chaincodes {
+		connection.close(fmt.Errorf("Peer stopped"))
+		delete(p.chaincodes, address)
+	}
+	p.chaincodesMutex.Unlock()
+	err := stopServers(p.grpcServer, p.httpServer)
+	p.grpcServer = nil
+	p.httpServer = nil
+	return err
+}
+
+// MSPID returns the MSP ID of the peer.
+func (p *Peer) MSPID() string {
+	return p.peer.MSPID()
+}
+
+// Identity returns the identity of the peer.
+func (p *Peer) Identity() *identity.Identity {
+	return p.peer.Identity()
+}
+
+// Channels returns the names of the channels that the peer has joined.
+func (p *Peer) Channels() []string {
+	p.Lock()
+	defer p.Unlock()
+	result := []string{}
+	for name := range p.channels {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Deliver requests one or more blocks from the mock peer, without going through gRPC.
+func (p *Peer) Deliver(envelope *common.Envelope, callback blocks.DeliverCallback) error {
+	status, err := seekBlocks(context.Background(), p.joinedChannel, envelope, callback)
+	if err != nil {
+		return err
+	} else if status != common.Status_SUCCESS {
+		return fmt.Errorf("Bad status returned by peer: %v", status)
+	}
+	return nil
+}
+
+func (p *Peer) joinChannel(name string) error {
+	if _, ok := p.ledger.Channel(name); !ok {
+		return fmt.Errorf("Channel %s does not exist", name)
+	}
+	p.Lock()
+	defer p.Unlock()
+	if p.channels[name] {
+		return fmt.Errorf("Peer has already joined channel %s", name)
+	}
+	p.channels[name] = true
+	return nil
+}
+
+func (p *Peer) joinedChannel(name string) (*Channel, bool) {
+	p.Lock()
+	joined := p.channels[name]
+	p.Unlock()
+	if !joined {
+		return nil, false
+	}
+	return p.ledger.Channel(name)
+}
+
+func (p *Peer) installPackage(data []byte) (*chaincodePackage, error) {
+	pkg, err := parseChaincodePackage(data)
+	if err != nil {
+		return nil, err
+	}
+	p.Lock()
+	defer p.Unlock()
+	if _, ok := p.packages[pkg.id]; ok {
+		return nil, fmt.Errorf("Chaincode already successfully installed (package ID '%s')", pkg.id)
+	}
+	p.packages[pkg.id] = pkg
+	logger.Printf("Installed chaincode package %s on peer %s", pkg.id, p.peer.Identity().Name())
+	return pkg, nil
+}
+
+func (p *Peer) installedPackage(packageID string) (*chaincodePackage, bool) {
+	p.Lock()
+	defer p.Unlock()
+	pkg, ok := p.packages[packageID]
+	return pkg, ok
+}
+
+func (p *Peer) installedPackages() []*chaincodePackage {
+	p.Lock()
+	defer p.Unlock()
+	result := []*chaincodePackage{}
+	for _, pkg := range p.packages {
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].id < result[j].id
+	})
+	return result
+}
+
+// chaincodeConnection returns the connection to the chaincode-as-a-service server for the specified package,
+// connecting (or reconnecting) to it if required.
+func (p *Peer) chaincodeConnection(pkg *chaincodePackage) (*chaincodeConnection, error) {
+	p.chaincodesMutex.Lock()
+	defer p.chaincodesMutex.Unlock()
+	address := pkg.connection.Address
+	if connection, ok := p.chaincodes[address]; ok && !connection.isClosed() {
+		return connection, nil
+	}
+	connection, err := connectChaincode(pkg.connection)
+	if err != nil {
+		return nil, err
+	}
+	p.chaincodes[address] = connection
+	return connection, nil
+}
+
+func (s *endorserServer) ProcessProposal(ctx context.Context, signedProposal *fpeer.SignedProposal) (*fpeer.ProposalResponse, error) {
+	return s.peer.processProposal(signedProposal)
+}
+
+func (s *deliverServer) Deliver(stream fpeer.Deliver_DeliverServer) error {
+	return s.peer.deliverStream(stream.Context(), stream.Recv, func(block *common.Block) error {
+		return stream.Send(&fpeer.DeliverResponse{
+			Type: &fpeer.DeliverResponse_Block{Block: block},
+		})
+	}, func(status common.Status) error {
+		return stream.Send(&fpeer.DeliverResponse{
+			Type: &fpeer.DeliverResponse_Status{Status: status},
+		})
+	})
+}
+
+func (s *deliverServer) DeliverFiltered(stream fpeer.Deliver_DeliverFilteredServer) error {
+	return s.peer.deliverStream(stream.Context(), stream.Recv, func(block *common.Block) error {
+		return stream.Send(&fpeer.DeliverResponse{
+			Type: &fpeer.DeliverResponse_FilteredBlock{FilteredBlock: filterBlock(block)},
+		})
+	}, func(status common.Status) error {
+		return stream.Send(&fpeer.DeliverResponse{
+			Type: &fpeer.DeliverResponse_Status{Status: status},
+		})
+	})
+}
+
+func (s *deliverServer) DeliverWithPrivateData(stream fpeer.Deliver_DeliverWithPrivateDataServer) error {
+	return s.peer.deliverStream(stream.Context(), stream.Recv, func(block *common.Block) error {
+		return stream.Send(&fpeer.DeliverResponse{
+			Type: &fpeer.DeliverResponse_BlockAndPrivateData{
+				BlockAndPrivateData: &fpeer.BlockAndPrivateData{Block: block},
+			},
+		})
+	}, func(status common.Status) error {
+		return stream.Send(&fpeer.DeliverResponse{
+			Type: &fpeer.DeliverResponse_Status{Status: status},
+		})
+	})
+}
+
+func (p *Peer) deliverStream(ctx context.Context, recv func() (*common.Envelope, error), sendBlock blocks.DeliverCallback, sendStatus func(common.Status) error) error {
+	for {
+		envelope, err := recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		status, err := seekBlocks(ctx, p.joinedChannel, envelope, sendBlock)
+		if err != nil {
+			return err
+		}
+		if err := sendStatus(status); err != nil {
+			return err
+		}
+	}
+}
+
+// filterBlock converts a block into a filtered block, which contains only the transaction IDs, validation codes and chaincode events.
+func filterBlock(block *common.Block) *fpeer.FilteredBlock {
+	result := &fpeer.FilteredBlock{
+		Number:               block.Header.Number,
+		FilteredTransactions: []*fpeer.FilteredTransaction{},
+	}
+	filter := block.GetMetadata().GetMetadata()[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	for i, data := range block.Data.Data {
+		envelope := &common.Envelope{}
+		if err := proto.Unmarshal(data, envelope); err != nil {
+			continue
+		}
+		payload := &common.Payload{}
+		if err := proto.Unmarshal(envelope.Payload, payload); err != nil {
+			continue
+		}
+		channelHeader := &common.ChannelHeader{}
+		if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+			continue
+		}
+		result.ChannelId = channelHeader.ChannelId
+		filteredTransaction := &fpeer.FilteredTransaction{
+			Txid: channelHeader.TxId,
+			Type: common.HeaderType(channelHeader.Type),
+		}
+		if i < len(filter) {
+			filteredTransaction.TxValidationCode = fpeer.TxValidationCode(filter[i])
+		}
+		if common.HeaderType(channelHeader.Type) == common.HeaderType_ENDORSER_TRANSACTION {
+			filteredTransaction.Data = &fpeer.FilteredTransaction_TransactionActions{
+				TransactionActions: filterTransactionActions(payload.Data),
+			}
+		}
+		result.FilteredTransactions = append(result.FilteredTransactions, filteredTransaction)
+	}
+	return result
+}
+
+func filterTransactionActions(data []byte) *fpeer.FilteredTransactionActions {
+	result := &fpeer.FilteredTransactionActions{
+		ChaincodeActions: []*fpeer.FilteredChaincodeAction{},
+	}
+	chaincodeAction, err := getChaincodeAction(data)
+	if err != nil {
+		return result
+	}
+	chaincodeEvent := &fpeer.ChaincodeEvent{}
+	if err := proto.Unmarshal(chaincodeAction.Events, chaincodeEvent); err != nil || chaincodeEvent.EventName == "" {
+		return result
+	}
+	// Filtered blocks do not include the event payload.
+	chaincodeEvent.Payload = nil
+	result.ChaincodeActions = append(result.ChaincodeActions, &fpeer.FilteredChaincodeAction{
+		ChaincodeEvent: chaincodeEvent,
+	})
+	return result
+}
+
+func getChaincodeAction(data []byte) (*fpeer.ChaincodeAction, error) {
+	tx := &fpeer.Transaction{}
+	if err := proto.Unmarshal(data, tx); err != nil {
+		return nil, err
+	} else if len(tx.Actions) == 0 {
+		return nil, fmt.Errorf("Transaction has no actions")
+	}
+	chaincodeActionPayload := &fpeer.ChaincodeActionPayload{}
+	if err := proto.Unmarshal(tx.Actions[0].Payload, chaincodeActionPayload); err != nil {
+		return nil, err
+	}
+	proposalResponsePayload := &fpeer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(chaincodeActionPayload.GetAction().GetProposalResponsePayload(), proposalResponsePayload); err != nil {
+		return nil, err
+	}
+	chaincodeAction := &fpeer.ChaincodeAction{}
+	if err := proto.Unmarshal(proposalResponsePayload.Extension, chaincodeAction); err != nil {
+		return nil, err
+	}
+	return chaincodeAction, nil
+}
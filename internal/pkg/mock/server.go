@@ -0,0 +1,94 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	gotls "crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var logger = logging.New("mock")
+
+func newGRPCServer(tls *identity.Identity) (*grpc.Server, error) {
+	if tls == nil {
+		return grpc.NewServer(), nil
+	}
+	certificate, err := gotls.X509KeyPair(tls.Certificate().Bytes(), tls.PrivateKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	creds := credentials.NewServerTLSFromCert(&certificate)
+	return grpc.NewServer(grpc.Creds(creds)), nil
+}
+
+func newOperationsServer(port int32, tls *identity.Identity) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]string{"status": "OK"})
+	})
+	mux.HandleFunc("/version", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Add("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(map[string]string{"Version": "mock"})
+	})
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+	if tls != nil {
+		certificate, err := gotls.X509KeyPair(tls.Certificate().Bytes(), tls.PrivateKey().Bytes())
+		if err != nil {
+			return nil, err
+		}
+		server.TLSConfig = &gotls.Config{
+			Certificates: []gotls.Certificate{certificate},
+		}
+	}
+	return server, nil
+}
+
+// startServers listens on the API and operations ports before returning, so that clients can connect as soon as it does.
+func startServers(grpcServer *grpc.Server, apiPort int32, httpServer *http.Server) error {
+	apiListener, err := net.Listen("tcp", fmt.Sprintf(":%d", apiPort))
+	if err != nil {
+		return err
+	}
+	operationsListener, err := net.Listen("tcp", httpServer.Addr)
+	if err != nil {
+		apiListener.Close()
+		return err
+	}
+	go grpcServer.Serve(apiListener)
+	go func() {
+		var err error
+		if httpServer.TLSConfig != nil {
+			err = httpServer.ServeTLS(operationsListener, "", "")
+		} else {
+			err = httpServer.Serve(operationsListener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Operations server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+func stopServers(grpcServer *grpc.Server, httpServer *http.Server) error {
+	if grpcServer != nil {
+		grpcServer.Stop()
+	}
+	if httpServer != nil {
+		return httpServer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package mock
+
+import (
+	"sort"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+)
+
+// simulator records the reads and writes made by a transaction against the world state of a channel.
+// As in Fabric, reads always return the committed value, and never the value written earlier in the same transaction.
+type simulator struct {
+	channel *Channel
+	reads   map[string]map[string]*kvrwset.KVRead
+	writes  map[string]map[string]*kvrwset.KVWrite
+}
+
+func newSimulator(channel *Channel) *simulator {
+	return &simulator{
+		channel: channel,
+		reads:   map[string]map[string]*kvrwset.KVRead{},
+		writes:  map[string]map[string]*kvrwset.KVWrite{},
+	}
+}
+
+func (s *simulator) getState(namespace, key string) []byte {
+	current := s.channel.getState(namespace, key)
+	s.recordRead(namespace, key, current)
+	if current == nil {
+		return nil
+	}
+	return current.value
+}
+
+// getStateRange returns the keys and values in the specified range. Unlike Fabric, the range itself is not
+// recorded, so phantom reads are not detected; each key that is returned is recorded as a read.
+func (s *simulator) getStateRange(namespace, startKey, endKey string) []*queryresult.KV {
+	result := []*queryresult.KV{}
+	for _, key := range s.channel.getStateRange(namespace, startKey, endKey) {
+		value := s.getState(namespace, key)
+		if value == nil {
+			continue
+		}
+		result = append(result, &queryresult.KV{
+			Namespace: namespace,
+			Key:       key,
+			Value:     value,
+		})
+	}
+	return result
+}
+
+func (s *simulator) putState(namespace, key string, value []byte) {
+	s.recordWrite(namespace, &kvrwset.KVWrite{Key: key, Value: value})
+}
+
+func (s *simulator) delState(namespace, key string) {
+	s.recordWrite(namespace, &kvrwset.KVWrite{Key: key, IsDelete: true})
+}
+
+func (s *simulator) recordRead(namespace, key string, current *versionedValue) {
+	if _, ok := s.reads[namespace]; !ok {
+		s.reads[namespace] = map[string]*kvrwset.KVRead{}
+	}
+	read := &kvrwset.KVRead{Key: key}
+	if current != nil {
+		read.Version = current.version
+	}
+	s.reads[namespace][key] = read
+}
+
+func (s *simulator) recordWrite(namespace string, write *kvrwset.KVWrite) {
+	if _, ok := s.writes[namespace]; !ok {
+		s.writes[namespace] = map[string]*kvrwset.KVWrite{}
+	}
+	s.writes[namespace][write.Key] = write
+}
+
+// results returns the marshalled read-write set, with namespaces and keys sorted so that every peer produces the same bytes.
+func (s *simulator) results() []byte {
+	namespaces := map[string]bool{}
+	for namespace := range s.reads {
+		namespaces[namespace] = true
+	}
+	for namespace := range s.writes {
+		namespaces[namespace] = true
+	}
+	sortedNamespaces := []string{}
+	for namespace := range namespaces {
+		sortedNamespaces = append(sortedNamespaces, namespace)
+	}
+	sort.Strings(sortedNamespaces)
+	txRWSet := &rwset.TxReadWriteSet{
+		DataModel: rwset.TxReadWriteSet_KV,
+	}
+	for _, namespace := range sortedNamespaces {
+		kvRWSet := &kvrwset.KVRWSet{}
+		for _, read := range s.reads[namespace] {
+			kvRWSet.Reads = append(kvRWSet.Reads, read)
+		}
+		sort.Slice(kvRWSet.Reads, func(i, j int) bool {
+			return kvRWSet.Reads[i].Key < kvRWSet.Reads[j].Key
+		})
+		for _, write := range s.writes[namespace] {
+			kvRWSet.Writes = append(kvRWSet.Writes, write)
+		}
+		sort.Slice(kvRWSet.Writes, func(i, j int) bool {
+			return kvRWSet.Writes[i].Key < kvRWSet.Writes[j].Key
+		})
+		txRWSet.NsRwset = append(txRWSet.NsRwset, &rwset.NsReadWriteSet{
+			Namespace: namespace,
+			Rwset:     util.MarshalOrPanic(kvRWSet),
+		})
+	}
+	return util.MarshalOrPanic(txRWSet)
+}
@@ -6,18 +6,17 @@ package orderer
 
 import (
 	"crypto/tls"
-	"fmt"
-	"log"
+	"crypto/x509"
 	"net/url"
-	"os"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 	"github.com/hyperledger-labs/microfab/pkg/client"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "console"), log.LstdFlags)
+var logger = logging.New("orderer")
 
 // Connection represents a connection to a orderer.
 type Connection struct {
@@ -31,13 +30,22 @@ type Connection struct {
 func Connect(orderer *Orderer, mspID string, identity *identity.Identity) (*Connection, error) {
 	var clientConn *grpc.ClientConn
 	var err error
-	if orderer.tls != nil {
+	if orderer.tls != nil && orderer.remote {
+		// The ordering service is not run by Microfab, so verify it using its TLS CA.
+		rootCAs := x509.NewCertPool()
+		rootCAs.AddCert(orderer.tls.CA().Certificate())
+		creds := credentials.NewTLS(&tls.Config{
+			RootCAs:    rootCAs,
+			ServerName: orderer.APIHostname(true),
+		})
+		clientConn, err = grpc.Dial(orderer.APIHost(true), grpc.WithTransportCredentials(creds))
+	} else if orderer.tls != nil {
 		creds := credentials.NewTLS(&tls.Config{
 			InsecureSkipVerify: true,
 		})
-		clientConn, err = grpc.Dial(fmt.Sprintf("localhost:%d", orderer.apiPort), grpc.WithTransportCredentials(creds))
+		clientConn, err = grpc.Dial(orderer.APIHost(true), grpc.WithTransportCredentials(creds))
 	} else {
-		clientConn, err = grpc.Dial(fmt.Sprintf("localhost:%d", orderer.apiPort), grpc.WithInsecure())
+		clientConn, err = grpc.Dial(orderer.APIHost(true), grpc.WithInsecure())
 	}
 	if err != nil {
 		return nil, err
@@ -60,14 +68,14 @@ func ConnectClient(orderer *client.OrderingService, mspID string, identity *iden
 	var clientConn *grpc.ClientConn
 
 	if tlsEnabled {
-		logger.Printf("Using TLS")
+		logger.Debugf("Using TLS")
 		creds := credentials.NewTLS(&tls.Config{
 			InsecureSkipVerify: true,
 		})
 
 		clientConn, err = grpc.Dial(parsedURL.Host, grpc.WithTransportCredentials(creds), grpc.WithAuthority(orderer.APIOptions.DefaultAuthority))
 	} else {
-		logger.Printf("Not Using TLS")
+		logger.Debugf("Not Using TLS")
 		clientConn, err = grpc.Dial(parsedURL.Host, grpc.WithInsecure(), grpc.WithAuthority(orderer.APIOptions.DefaultAuthority))
 	}
 
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"net/url"
 	"os/exec"
+	"path"
+	"strconv"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
 	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
@@ -26,6 +28,10 @@ type Orderer struct {
 	operationsURL  *url.URL
 	command        *exec.Cmd
 	tls            *identity.Identity
+	overrides      map[string]interface{}
+	hostname       string
+	external       bool
+	remote         bool
 }
 
 // New creates a new orderer.
@@ -43,7 +49,22 @@ func New(organization *organization.Organization, directory string, microFabPort
 	if err != nil {
 		return nil, err
 	}
-	return &Orderer{organization, organization.MSPID(), identity, directory, microFabPort, apiPort, parsedAPIURL, operationsPort, parsedOperationsURL, nil, nil}, nil
+	return &Orderer{organization, organization.MSPID(), identity, directory, microFabPort, apiPort, parsedAPIURL, operationsPort, parsedOperationsURL, nil, nil, nil, "localhost", false, false}, nil
+}
+
+// NewRemote creates an orderer for an existing ordering service that is not run by Microfab, and is not behind the
+// Microfab proxy. The organization must use the MSP ID and admin identity of the ordering service.
+func NewRemote(organization *organization.Organization, apiURL string) (*Orderer, error) {
+	parsedAPIURL, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(parsedAPIURL.Port())
+	if err != nil {
+		return nil, fmt.Errorf("The API URL %s for the ordering service must include a port", apiURL)
+	}
+	apiPort := int32(port)
+	return &Orderer{organization, organization.MSPID(), organization.Admin(), "", apiPort, apiPort, parsedAPIURL, 0, &url.URL{}, nil, nil, nil, parsedAPIURL.Hostname(), true, true}, nil
 }
 
 // TLS gets the TLS identity for this orderer.
@@ -56,6 +77,38 @@ func (o *Orderer) EnableTLS(tls *identity.Identity) {
 	o.tls = tls
 }
 
+// SetOverrides sets the orderer.yaml overrides, keyed by dotted key path, that are applied on top of the generated configuration.
+func (o *Orderer) SetOverrides(overrides map[string]interface{}) {
+	o.overrides = overrides
+}
+
+// SetExternal marks the orderer as running outside of Microfab on the specified hostname. Microfab generates the
+// configuration for an external orderer, but waits for it to be started instead of starting it.
+func (o *Orderer) SetExternal(hostname string) {
+	o.hostname = hostname
+	o.external = true
+}
+
+// External returns true if the orderer runs outside of Microfab.
+func (o *Orderer) External() bool {
+	return o.external
+}
+
+// Remote returns true if the orderer is part of an existing ordering service that is not run by Microfab.
+func (o *Orderer) Remote() bool {
+	return o.remote
+}
+
+// Identity returns the identity of the orderer.
+func (o *Orderer) Identity() *identity.Identity {
+	return o.identity
+}
+
+// SetIdentity replaces the generated identity of the orderer with the specified identity.
+func (o *Orderer) SetIdentity(id *identity.Identity) {
+	o.identity = id
+}
+
 // Organization returns the organization of the orderer.
 func (o *Orderer) Organization() *organization.Organization {
 	return o.organization
@@ -66,10 +119,15 @@ func (o *Orderer) MSPID() string {
 	return o.mspID
 }
 
+// LogFile returns the path to the file that the output of the orderer is written to.
+func (o *Orderer) LogFile() string {
+	return path.Join(o.directory, "logs", "orderer.log")
+}
+
 // APIHostname returns the hostname of the orderer.
 func (o *Orderer) APIHostname(internal bool) string {
 	if internal {
-		return "localhost"
+		return o.hostname
 	}
 	return o.apiURL.Hostname()
 }
@@ -77,7 +135,7 @@ func (o *Orderer) APIHostname(internal bool) string {
 // APIHost returns the host (hostname:port) of the orderer.
 func (o *Orderer) APIHost(internal bool) string {
 	if internal {
-		return fmt.Sprintf("localhost:%d", o.apiPort)
+		return fmt.Sprintf("%s:%d", o.hostname, o.apiPort)
 	}
 	return fmt.Sprintf("%s:%d", o.APIHostname(false), o.microfabPort)
 }
@@ -97,7 +155,7 @@ func (o *Orderer) APIURL(internal bool) *url.URL {
 		scheme = "grpcs"
 	}
 	if internal {
-		url, _ := url.Parse(fmt.Sprintf("%s://localhost:%d", scheme, o.apiPort))
+		url, _ := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, o.hostname, o.apiPort))
 		return url
 	}
 
@@ -108,7 +166,7 @@ func (o *Orderer) APIURL(internal bool) *url.URL {
 // OperationsHostname returns the hostname of the orderer.
 func (o *Orderer) OperationsHostname(internal bool) string {
 	if internal {
-		return "localhost"
+		return o.hostname
 	}
 	return o.operationsURL.Hostname()
 }
@@ -116,7 +174,7 @@ func (o *Orderer) OperationsHostname(internal bool) string {
 // OperationsHost returns the host (hostname:port) of the orderer.
 func (o *Orderer) OperationsHost(internal bool) string {
 	if internal {
-		return fmt.Sprintf("localhost:%d", o.operationsPort)
+		return fmt.Sprintf("%s:%d", o.hostname, o.operationsPort)
 	}
 	return fmt.Sprintf("%s:%d", o.OperationsHostname(false), o.microfabPort)
 }
@@ -136,7 +194,7 @@ func (o *Orderer) OperationsURL(internal bool) *url.URL {
 		scheme = "https"
 	}
 	if internal {
-		url, _ := url.Parse(fmt.Sprintf("%s://localhost:%d", scheme, o.operationsPort))
+		url, _ := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, o.hostname, o.operationsPort))
 		return url
 	}
 	url, _ := url.Parse(fmt.Sprintf("%s://%s", scheme, o.OperationsHost(false)))
@@ -67,4 +67,22 @@ var _ = Describe("the orderer package", func() {
 
 	})
 
+	Context("orderer.SetExternal()", func() {
+
+		When("called", func() {
+			It("uses the external hostname for the internal endpoints", func() {
+				o, err := orderer.New(testOrganization, testDirectory, 8080, 7050, "grpc://orderer-api.127-0-0-1.nip.io:8080", 8443, "http://orderer-operations.127-0-0-1.nip.io:8080")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(o.External()).To(BeFalse())
+				o.SetExternal("debughost")
+				Expect(o.External()).To(BeTrue())
+				Expect(o.APIHostname(true)).To(Equal("debughost"))
+				Expect(o.APIHost(true)).To(Equal("debughost:7050"))
+				Expect(o.APIHost(false)).To(Equal("orderer-api.127-0-0-1.nip.io:8080"))
+				Expect(o.OperationsURL(true).String()).To(BeEquivalentTo("http://debughost:8443"))
+			})
+		})
+
+	})
+
 })
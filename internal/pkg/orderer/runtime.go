@@ -5,17 +5,17 @@
 package orderer
 
 import (
-	"bufio"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
 	"path"
+	"strings"
 	"time"
 
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
 	"github.com/hyperledger-labs/microfab/internal/pkg/protoutil"
 	"github.com/hyperledger-labs/microfab/internal/pkg/txid"
@@ -24,6 +24,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/orderer"
 	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 )
 
 // Start starts the orderer.
@@ -32,8 +33,6 @@ func (o *Orderer) Start(consortium []*organization.Organization, timeout time.Du
 	if err != nil {
 		return err
 	}
-	configDirectory := path.Join(o.directory, "config")
-	dataDirectory := path.Join(o.directory, "data")
 	logsDirectory := path.Join(o.directory, "logs")
 	mspDirectory := path.Join(o.directory, "msp")
 	tlsDirectory := path.Join(o.directory, "tls")
@@ -45,45 +44,31 @@ func (o *Orderer) Start(consortium []*organization.Organization, timeout time.Du
 	if err != nil {
 		return err
 	}
-	cmd := exec.Command("orderer", "start")
-	cmd.Env = os.Environ()
-	extraEnvs := []string{
-		"FABRIC_LOGGING_SPEC=info",
-		fmt.Sprintf("ORDERER_GENERAL_LOCALMSPDIR=%s", mspDirectory),
-		fmt.Sprintf("ORDERER_GENERAL_LOCALMSPID=%s", o.mspID),
-		"ORDERER_GENERAL_BOOTSTRAPMETHOD=file",
-		fmt.Sprintf("ORDERER_GENERAL_BOOTSTRAPFILE=%s", path.Join(configDirectory, "genesisblock")),
-		fmt.Sprintf("ORDERER_FILELEDGER_LOCATION=%s", dataDirectory),
-		fmt.Sprintf("ORDERER_CONSENSUS_WALDIR=%s", path.Join(dataDirectory, "etcdraft", "wal")),
-		fmt.Sprintf("ORDERER_CONSENSUS_SNAPDIR=%s", path.Join(dataDirectory, "etcdraft", "snapshot")),
-		"ORDERER_METRICS_PROVIDER=prometheus",
-		"ORDERER_GENERAL_LISTENADDRESS=0.0.0.0",
-		fmt.Sprintf("ORDERER_GENERAL_LISTENPORT=%d", o.apiPort),
-		fmt.Sprintf("ORDERER_OPERATIONS_LISTENADDRESS=0.0.0.0:%d", o.operationsPort),
-	}
 	if o.tls != nil {
-		certFile := path.Join(tlsDirectory, "cert.pem")
-		keyFile := path.Join(tlsDirectory, "key.pem")
-		caFile := path.Join(tlsDirectory, "ca.pem")
-		extraEnvs = append(extraEnvs,
-			"ORDERER_GENERAL_TLS_ENABLED=true",
-			fmt.Sprintf("ORDERER_GENERAL_TLS_CERTIFICATE=%s", certFile),
-			fmt.Sprintf("ORDERER_GENERAL_TLS_PRIVATEKEY=%s", keyFile),
-			fmt.Sprintf("ORDERER_GENERAL_TLS_ROOTCAS=%s", caFile),
-			"ORDERER_OPERATIONS_TLS_ENABLED=true",
-			fmt.Sprintf("ORDERER_OPERATIONS_TLS_CERTIFICATE=%s", certFile),
-			fmt.Sprintf("ORDERER_OPERATIONS_TLS_PRIVATEKEY=%s", keyFile),
-		)
-		if err := ioutil.WriteFile(certFile, o.tls.Certificate().Bytes(), 0644); err != nil {
+		if err := ioutil.WriteFile(path.Join(tlsDirectory, "cert.pem"), o.tls.Certificate().Bytes(), 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path.Join(tlsDirectory, "key.pem"), o.tls.PrivateKey().Bytes(), 0644); err != nil {
 			return err
 		}
-		if err := ioutil.WriteFile(keyFile, o.tls.PrivateKey().Bytes(), 0644); err != nil {
+		if err := ioutil.WriteFile(path.Join(tlsDirectory, "ca.pem"), o.tls.CA().Bytes(), 0644); err != nil {
 			return err
 		}
-		if err := ioutil.WriteFile(caFile, o.tls.CA().Bytes(), 0644); err != nil {
+	}
+	extraEnvs, err := o.Environment(o.directory)
+	if err != nil {
+		return err
+	}
+	if o.external {
+		envFile := path.Join(o.directory, "config", "orderer.env")
+		if err := ioutil.WriteFile(envFile, []byte(strings.Join(extraEnvs, "\n")+"\n"), 0644); err != nil {
 			return err
 		}
+		logger.Printf("Waiting for external orderer for ordering organization %s, start it with the environment variables in %s", o.organization.Name(), envFile)
+		return o.waitForStart(timeout, nil)
 	}
+	cmd := exec.Command("orderer", "start")
+	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, extraEnvs...)
 	cmd.Stdin = nil
 	logFile, err := os.OpenFile(path.Join(logsDirectory, "orderer.log"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
@@ -94,19 +79,7 @@ func (o *Orderer) Start(consortium []*organization.Organization, timeout time.Du
 	if err != nil {
 		return errors.WithMessage(err, "failed to open pipe")
 	}
-	go func() {
-		reader := bufio.NewReader(pipe)
-		scanner := bufio.NewScanner(reader)
-		scanner.Split(bufio.ScanLines)
-		id := "orderer"
-		logger := log.New(os.Stdout, fmt.Sprintf("[%16s] ", id), 0)
-		for scanner.Scan() {
-			logger.Println(scanner.Text())
-			logFile.WriteString(scanner.Text())
-		}
-		pipe.Close()
-		logFile.Close()
-	}()
+	go logging.Pipe("orderer", pipe, logFile)
 	cmd.Stderr = cmd.Stdout
 	err = cmd.Start()
 	if err != nil {
@@ -120,6 +93,10 @@ func (o *Orderer) Start(consortium []*organization.Organization, timeout time.Du
 			errchan <- err
 		}
 	}()
+	return o.waitForStart(timeout, errchan)
+}
+
+func (o *Orderer) waitForStart(timeout time.Duration, errchan chan error) error {
 	timeoutCh := time.After(timeout)
 	tick := time.Tick(250 * time.Millisecond)
 	for {
@@ -150,6 +127,48 @@ func (o *Orderer) Stop() error {
 	return nil
 }
 
+// Environment returns the environment variables used to configure the orderer, with the configuration, data, MSP
+// and TLS files for the orderer located under the specified directory.
+func (o *Orderer) Environment(directory string) ([]string, error) {
+	configDirectory := path.Join(directory, "config")
+	dataDirectory := path.Join(directory, "data")
+	mspDirectory := path.Join(directory, "msp")
+	extraEnvs := []string{
+		"FABRIC_LOGGING_SPEC=info",
+		fmt.Sprintf("ORDERER_GENERAL_LOCALMSPDIR=%s", mspDirectory),
+		fmt.Sprintf("ORDERER_GENERAL_LOCALMSPID=%s", o.mspID),
+		"ORDERER_GENERAL_BOOTSTRAPMETHOD=file",
+		fmt.Sprintf("ORDERER_GENERAL_BOOTSTRAPFILE=%s", path.Join(configDirectory, "genesisblock")),
+		fmt.Sprintf("ORDERER_FILELEDGER_LOCATION=%s", dataDirectory),
+		fmt.Sprintf("ORDERER_CONSENSUS_WALDIR=%s", path.Join(dataDirectory, "etcdraft", "wal")),
+		fmt.Sprintf("ORDERER_CONSENSUS_SNAPDIR=%s", path.Join(dataDirectory, "etcdraft", "snapshot")),
+		"ORDERER_METRICS_PROVIDER=prometheus",
+		"ORDERER_GENERAL_LISTENADDRESS=0.0.0.0",
+		fmt.Sprintf("ORDERER_GENERAL_LISTENPORT=%d", o.apiPort),
+		fmt.Sprintf("ORDERER_OPERATIONS_LISTENADDRESS=0.0.0.0:%d", o.operationsPort),
+	}
+	if o.tls != nil {
+		tlsDirectory := path.Join(directory, "tls")
+		certFile := path.Join(tlsDirectory, "cert.pem")
+		keyFile := path.Join(tlsDirectory, "key.pem")
+		caFile := path.Join(tlsDirectory, "ca.pem")
+		extraEnvs = append(extraEnvs,
+			"ORDERER_GENERAL_TLS_ENABLED=true",
+			fmt.Sprintf("ORDERER_GENERAL_TLS_CERTIFICATE=%s", certFile),
+			fmt.Sprintf("ORDERER_GENERAL_TLS_PRIVATEKEY=%s", keyFile),
+			fmt.Sprintf("ORDERER_GENERAL_TLS_ROOTCAS=%s", caFile),
+			"ORDERER_OPERATIONS_TLS_ENABLED=true",
+			fmt.Sprintf("ORDERER_OPERATIONS_TLS_CERTIFICATE=%s", certFile),
+			fmt.Sprintf("ORDERER_OPERATIONS_TLS_PRIVATEKEY=%s", keyFile),
+		)
+	}
+	overrideEnvs, err := o.createOverrides()
+	if err != nil {
+		return nil, err
+	}
+	return append(extraEnvs, overrideEnvs...), nil
+}
+
 func (o *Orderer) createDirectories() error {
 	directories := []string{
 		o.directory,
@@ -168,6 +187,30 @@ func (o *Orderer) createDirectories() error {
 	return nil
 }
 
+func (o *Orderer) createOverrides() ([]string, error) {
+	if len(o.overrides) == 0 {
+		return []string{}, nil
+	}
+	fabricConfigPath, ok := os.LookupEnv("FABRIC_CFG_PATH")
+	if !ok {
+		return nil, fmt.Errorf("FABRIC_CFG_PATH not defined")
+	}
+	configData, err := ioutil.ReadFile(path.Join(fabricConfigPath, "orderer.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	config := map[interface{}]interface{}{}
+	err = yaml.Unmarshal(configData, config)
+	if err != nil {
+		return nil, err
+	}
+	result, err := util.OverridesToEnvironment("ORDERER", config, o.overrides)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to apply orderer.yaml overrides")
+	}
+	return result, nil
+}
+
 func (o *Orderer) hasStarted() bool {
 	cli := &http.Client{
 		Transport: &http.Transport{
@@ -178,16 +221,41 @@ func (o *Orderer) hasStarted() bool {
 	}
 	resp, err := cli.Get(fmt.Sprintf("%s/healthz", o.OperationsURL(true)))
 	if err != nil {
-		log.Printf("error waiting for orderer: %v\n", err)
+		logger.Debugf("error waiting for orderer: %v", err)
 		return false
 	}
 	return resp.StatusCode == 200
 }
 
 func (o *Orderer) createGenesisBlock(consortium []*organization.Organization) error {
+	genesisBlock, err := o.GenesisBlock(consortium)
+	if err != nil {
+		return err
+	}
+	data := util.MarshalOrPanic(genesisBlock)
+	configDirectory := path.Join(o.directory, "config")
+	return ioutil.WriteFile(path.Join(configDirectory, "genesisblock"), data, 0644)
+}
+
+// GenesisBlock builds the genesis block for the system channel, with the specified organizations as members of the consortium.
+func (o *Orderer) GenesisBlock(consortium []*organization.Organization) (*common.Block, error) {
 	txID := txid.New(o.mspID, o.identity)
 	header := protoutil.BuildHeader(common.HeaderType_CONFIG, "testchainid", txID)
+	config, err := o.SystemChannelConfig(consortium)
+	if err != nil {
+		return nil, err
+	}
+	configEnvelope := &common.ConfigEnvelope{
+		Config:     config,
+		LastUpdate: nil,
+	}
+	payload := protoutil.BuildPayload(header, configEnvelope)
+	envelope := protoutil.BuildEnvelope(payload, o.identity)
+	return protoutil.BuildGenesisBlock(envelope), nil
+}
 
+// SystemChannelConfig builds the configuration for the system channel, with the specified organizations as members of the consortium.
+func (o *Orderer) SystemChannelConfig(consortium []*organization.Organization) (*common.Config, error) {
 	var consensusType *orderer.ConsensusType
 
 	if o.tls != nil {
@@ -348,24 +416,15 @@ func (o *Orderer) createGenesisBlock(consortium []*organization.Organization) er
 	}
 	configGroup, err := protoutil.BuildConfigGroupFromOrganization(o.organization, o.tls)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	config.ChannelGroup.Groups["Orderer"].Groups[o.organization.MSPID()] = configGroup
 	for _, organization := range consortium {
 		configGroup, err := protoutil.BuildConfigGroupFromOrganization(organization, o.tls)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		config.ChannelGroup.Groups["Consortiums"].Groups["SampleConsortium"].Groups[organization.MSPID()] = configGroup
 	}
-	configEnvelope := &common.ConfigEnvelope{
-		Config:     config,
-		LastUpdate: nil,
-	}
-	payload := protoutil.BuildPayload(header, configEnvelope)
-	envelope := protoutil.BuildEnvelope(payload, o.identity)
-	genesisBlock := protoutil.BuildGenesisBlock(envelope)
-	data := util.MarshalOrPanic(genesisBlock)
-	configDirectory := path.Join(o.directory, "config")
-	return ioutil.WriteFile(path.Join(configDirectory, "genesisblock"), data, 0644)
+	return config, nil
 }
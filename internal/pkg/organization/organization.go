@@ -9,16 +9,20 @@ import (
 	"regexp"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+	"github.com/hyperledger/fabric-protos-go/common"
 )
 
 // Organization represents a loaded organization definition.
 type Organization struct {
-	name    string
-	ca      *identity.Identity
-	admin   *identity.Identity
-	caAdmin *identity.Identity
-	mspID   string
-	tlsCA   *identity.Identity
+	name     string
+	ca       *identity.Identity
+	admin    *identity.Identity
+	caAdmin  *identity.Identity
+	mspID    string
+	tlsCA    *identity.Identity
+	policies map[string]*common.Policy
+	tlsRoots []*certificate.Certificate
 }
 
 // New creates a new organization.
@@ -39,7 +43,7 @@ func New(name string, ca *identity.Identity, tlsCA *identity.Identity) (*Organiz
 	safeRegex := regexp.MustCompile("[^a-zA-Z0-9]+")
 	safeName := safeRegex.ReplaceAllString(name, "")
 	mspID := fmt.Sprintf("%sMSP", safeName)
-	return &Organization{name, ca, admin, nil, mspID, tlsCA}, nil
+	return &Organization{name, ca, admin, nil, mspID, tlsCA, nil, nil}, nil
 }
 
 // Name returns the name of the organization.
@@ -52,6 +56,11 @@ func (o *Organization) MSPID() string {
 	return o.mspID
 }
 
+// SetMSPID sets the MSP ID for the organization.
+func (o *Organization) SetMSPID(mspID string) {
+	o.mspID = mspID
+}
+
 // CA returns the CA for the organization.
 func (o *Organization) CA() *identity.Identity {
 	return o.ca
@@ -62,6 +71,36 @@ func (o *Organization) Admin() *identity.Identity {
 	return o.admin
 }
 
+// SetAdmin sets the admin identity for the organization.
+func (o *Organization) SetAdmin(id *identity.Identity) {
+	o.admin = id
+}
+
+// Policies returns the policies for the organization, or nil if the default policies should be used.
+func (o *Organization) Policies() map[string]*common.Policy {
+	return o.policies
+}
+
+// SetPolicies sets the policies for the organization, replacing the default policies with the same names.
+func (o *Organization) SetPolicies(policies map[string]*common.Policy) {
+	o.policies = policies
+}
+
+// TLSCA returns the TLS CA for the organization, which issues the TLS certificates for its nodes.
+func (o *Organization) TLSCA() *identity.Identity {
+	return o.tlsCA
+}
+
+// TLSRootCerts returns the additional TLS root certificates for the organization.
+func (o *Organization) TLSRootCerts() []*certificate.Certificate {
+	return o.tlsRoots
+}
+
+// AddTLSRootCert adds a TLS root certificate for the organization, for example the CA of an external node.
+func (o *Organization) AddTLSRootCert(cert *certificate.Certificate) {
+	o.tlsRoots = append(o.tlsRoots, cert)
+}
+
 // CAAdmin returns the CA admin identity for the organization.
 func (o *Organization) CAAdmin() *identity.Identity {
 	return o.caAdmin
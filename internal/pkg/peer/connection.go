@@ -6,7 +6,6 @@ package peer
 
 import (
 	"crypto/tls"
-	"fmt"
 	"net/url"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
@@ -28,14 +27,14 @@ func Connect(peer *Peer, mspID string, identity *identity.Identity) (*Connection
 	var clientConn *grpc.ClientConn
 	var err error
 	if peer.tls != nil {
-		logger.Println("Peer TLS Enabled")
+		logger.Debugf("Peer TLS Enabled")
 		creds := credentials.NewTLS(&tls.Config{
 			InsecureSkipVerify: true,
 		})
-		clientConn, err = grpc.Dial(fmt.Sprintf("localhost:%d", peer.apiPort), grpc.WithTransportCredentials(creds))
+		clientConn, err = grpc.Dial(peer.APIHost(true), grpc.WithTransportCredentials(creds))
 	} else {
-		logger.Println("Peer _not_ TLS Enabled")
-		clientConn, err = grpc.Dial(fmt.Sprintf("localhost:%d", peer.apiPort), grpc.WithInsecure())
+		logger.Debugf("Peer _not_ TLS Enabled")
+		clientConn, err = grpc.Dial(peer.APIHost(true), grpc.WithInsecure())
 	}
 	if err != nil {
 		return nil, err
@@ -54,19 +53,19 @@ func ConnectClient(peer *client.Peer, mspID string, identity *identity.Identity,
 	if err != nil {
 		return nil, err
 	}
-	logger.Printf("ConnectionClient Peer has parsedURL %s\n", parsedURL)
+	logger.Debugf("ConnectionClient Peer has parsedURL %s", parsedURL)
 
 	var clientConn *grpc.ClientConn
 
 	if tlsEnabled {
-		logger.Printf("Using TLS")
+		logger.Debugf("Using TLS")
 		creds := credentials.NewTLS(&tls.Config{
 			InsecureSkipVerify: true,
 		})
 
 		clientConn, err = grpc.Dial(parsedURL.Host, grpc.WithTransportCredentials(creds), grpc.WithAuthority(peer.APIOptions.DefaultAuthority))
 	} else {
-		logger.Printf("Not Using TLS")
+		logger.Debugf("Not Using TLS")
 		clientConn, err = grpc.Dial(parsedURL.Host, grpc.WithInsecure(), grpc.WithAuthority(peer.APIOptions.DefaultAuthority))
 	}
 
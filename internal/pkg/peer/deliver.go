@@ -19,6 +19,14 @@ import (
 func (c *Connection) Deliver(envelope *common.Envelope, callback blocks.DeliverCallback) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	return c.DeliverWithContext(ctx, envelope, callback)
+}
+
+// DeliverWithContext requests one or more blocks from the peer, until the peer has delivered all of the requested
+// blocks or the specified context is done.
+func (c *Connection) DeliverWithContext(ctx context.Context, envelope *common.Envelope, callback blocks.DeliverCallback) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	deliverClient, err := peer.NewDeliverClient(c.clientConn).Deliver(ctx)
 	if err != nil {
 		return err
@@ -30,12 +38,22 @@ func (c *Connection) Deliver(envelope *common.Envelope, callback blocks.DeliverC
 		for {
 			response, err := deliverClient.Recv()
 			if err == io.EOF {
-				eof <- true
+				select {
+				case eof <- true:
+				case <-ctx.Done():
+				}
 				return
 			} else if err != nil {
-				errors <- err
-			} else {
-				responses <- response
+				select {
+				case errors <- err:
+				case <-ctx.Done():
+				}
+				return
+			}
+			select {
+			case responses <- response:
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
@@ -69,6 +87,8 @@ func (c *Connection) Deliver(envelope *common.Envelope, callback blocks.DeliverC
 			}
 		case <-eof:
 			done = true
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 	return nil
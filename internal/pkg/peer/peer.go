@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/url"
 	"os/exec"
+	"path"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
 	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
@@ -32,6 +33,9 @@ type Peer struct {
 	gossipURL      *url.URL
 	command        *exec.Cmd
 	tls            *identity.Identity
+	overrides      map[string]interface{}
+	hostname       string
+	external       bool
 }
 
 // New creates a new peer.
@@ -58,7 +62,7 @@ func New(organization *organization.Organization, directory string, microfabPort
 		return nil, err
 	}
 
-	return &Peer{organization, identity, organization.MSPID(), directory, microfabPort, apiPort, parsedAPIURL, chaincodePort, parsedChaincodeURL, operationsPort, parsedOperationsURL, couchDB, couchDBPort, gossipPort, parsedGossipURL, nil, nil}, nil
+	return &Peer{organization, identity, organization.MSPID(), directory, microfabPort, apiPort, parsedAPIURL, chaincodePort, parsedChaincodeURL, operationsPort, parsedOperationsURL, couchDB, couchDBPort, gossipPort, parsedGossipURL, nil, nil, nil, "localhost", false}, nil
 }
 
 // TLS gets the TLS identity for this peer.
@@ -71,6 +75,33 @@ func (p *Peer) EnableTLS(tls *identity.Identity) {
 	p.tls = tls
 }
 
+// SetOverrides sets the core.yaml overrides, keyed by dotted key path, that are applied on top of the generated configuration.
+func (p *Peer) SetOverrides(overrides map[string]interface{}) {
+	p.overrides = overrides
+}
+
+// SetExternal marks the peer as running outside of Microfab on the specified hostname. Microfab generates the
+// configuration for an external peer, but waits for it to be started instead of starting it.
+func (p *Peer) SetExternal(hostname string) {
+	p.hostname = hostname
+	p.external = true
+}
+
+// External returns true if the peer runs outside of Microfab.
+func (p *Peer) External() bool {
+	return p.external
+}
+
+// Identity returns the identity of the peer.
+func (p *Peer) Identity() *identity.Identity {
+	return p.identity
+}
+
+// SetIdentity replaces the generated identity of the peer with the specified identity.
+func (p *Peer) SetIdentity(id *identity.Identity) {
+	p.identity = id
+}
+
 // Organization returns the organization of the peer.
 func (p *Peer) Organization() *organization.Organization {
 	return p.organization
@@ -81,10 +112,25 @@ func (p *Peer) MSPID() string {
 	return p.mspID
 }
 
+// LogFile returns the path to the file that the output of the peer is written to.
+func (p *Peer) LogFile() string {
+	return path.Join(p.directory, "logs", "peer.log")
+}
+
+// CouchDB returns true if the peer uses CouchDB as its state database.
+func (p *Peer) CouchDB() bool {
+	return p.couchDB
+}
+
+// CouchDBPort returns the port that the peer uses to connect to CouchDB.
+func (p *Peer) CouchDBPort() int32 {
+	return p.couchDBPort
+}
+
 // APIHostname returns the hostname of the peer.
 func (p *Peer) APIHostname(internal bool) string {
 	if internal {
-		return "localhost"
+		return p.hostname
 	}
 	return p.apiURL.Hostname()
 }
@@ -92,7 +138,7 @@ func (p *Peer) APIHostname(internal bool) string {
 // APIHost returns the host (hostname:port) of the peer.
 func (p *Peer) APIHost(internal bool) string {
 	if internal {
-		return fmt.Sprintf("localhost:%d", p.apiPort)
+		return fmt.Sprintf("%s:%d", p.hostname, p.apiPort)
 	}
 	return fmt.Sprintf("%s:%d", p.APIHostname(false), p.microfabPort)
 }
@@ -113,7 +159,7 @@ func (p *Peer) APIURL(internal bool) *url.URL {
 		scheme = "grpcs"
 	}
 	if internal {
-		url, _ := url.Parse(fmt.Sprintf("%s://localhost:%d", scheme, p.apiPort))
+		url, _ := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, p.hostname, p.apiPort))
 		return url
 	}
 
@@ -124,7 +170,7 @@ func (p *Peer) APIURL(internal bool) *url.URL {
 // ChaincodeHostname returns the hostname of the peer.
 func (p *Peer) ChaincodeHostname(internal bool) string {
 	if internal {
-		return "localhost"
+		return p.hostname
 	}
 	return p.chaincodeURL.Hostname()
 }
@@ -132,7 +178,7 @@ func (p *Peer) ChaincodeHostname(internal bool) string {
 // ChaincodeHost returns the host (hostname:port) of the peer.
 func (p *Peer) ChaincodeHost(internal bool) string {
 	if internal {
-		return fmt.Sprintf("localhost:%d", p.chaincodePort)
+		return fmt.Sprintf("%s:%d", p.hostname, p.chaincodePort)
 	}
 	return fmt.Sprintf("%s:%d", p.ChaincodeHostname(false), p.microfabPort)
 }
@@ -152,7 +198,7 @@ func (p *Peer) ChaincodeURL(internal bool) *url.URL {
 		scheme = "grpcs"
 	}
 	if internal {
-		url, _ := url.Parse(fmt.Sprintf("%s://localhost:%d", scheme, p.chaincodePort))
+		url, _ := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, p.hostname, p.chaincodePort))
 		return url
 	}
 	url, _ := url.Parse(fmt.Sprintf("%s://%s", scheme, p.ChaincodeHost(false)))
@@ -162,7 +208,7 @@ func (p *Peer) ChaincodeURL(internal bool) *url.URL {
 // OperationsHostname returns the hostname of the peer.
 func (p *Peer) OperationsHostname(internal bool) string {
 	if internal {
-		return "localhost"
+		return p.hostname
 	}
 	return p.operationsURL.Hostname()
 }
@@ -170,7 +216,7 @@ func (p *Peer) OperationsHostname(internal bool) string {
 // OperationsHost returns the host (hostname:port) of the peer.
 func (p *Peer) OperationsHost(internal bool) string {
 	if internal {
-		return fmt.Sprintf("localhost:%d", p.operationsPort)
+		return fmt.Sprintf("%s:%d", p.hostname, p.operationsPort)
 	}
 	return fmt.Sprintf("%s:%d", p.OperationsHostname(false), p.microfabPort)
 }
@@ -190,7 +236,7 @@ func (p *Peer) OperationsURL(internal bool) *url.URL {
 		scheme = "https"
 	}
 	if internal {
-		url, _ := url.Parse(fmt.Sprintf("%s://localhost:%d", scheme, p.operationsPort))
+		url, _ := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, p.hostname, p.operationsPort))
 		return url
 	}
 	url, _ := url.Parse(fmt.Sprintf("%s://%s", scheme, p.OperationsHost(false)))
@@ -200,7 +246,7 @@ func (p *Peer) OperationsURL(internal bool) *url.URL {
 // GossipHost returns the host of the gossip connection
 func (p *Peer) GossipHost(internal bool) string {
 	if internal {
-		return fmt.Sprintf("localhost:%d", p.gossipPort)
+		return fmt.Sprintf("%s:%d", p.hostname, p.gossipPort)
 	}
 	return fmt.Sprintf("%s:%d", p.GossipHostname(false), p.microfabPort)
 }
@@ -208,7 +254,7 @@ func (p *Peer) GossipHost(internal bool) string {
 // GossipHostname returns just the host name used for the gossip connection
 func (p *Peer) GossipHostname(internal bool) string {
 	if internal {
-		return "localhost"
+		return p.hostname
 	}
 	return p.gossipURL.Hostname()
 }
@@ -220,7 +266,7 @@ func (p *Peer) GossipURL(internal bool) *url.URL {
 		scheme = "https"
 	}
 	if internal {
-		url, _ := url.Parse(fmt.Sprintf("%s://localhost:%d", scheme, p.gossipPort))
+		url, _ := url.Parse(fmt.Sprintf("%s://%s:%d", scheme, p.hostname, p.gossipPort))
 		return url
 	}
 	url, _ := url.Parse(fmt.Sprintf("%s://%s", scheme, p.GossipHost(false)))
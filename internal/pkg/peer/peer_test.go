@@ -5,6 +5,7 @@
 package peer_test
 
 import (
+	"fmt"
 	"io/ioutil"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/organization"
@@ -80,4 +81,41 @@ var _ = Describe("the peer package", func() {
 
 	})
 
+	Context("peer.SetExternal()", func() {
+
+		When("called", func() {
+			It("uses the external hostname for the internal endpoints", func() {
+				p, err := peer.New(testOrganization, testDirectory, 8080, 7051, "grpc://org1peer-api.127-0-0-1.nip.io:8080", 7052, "grpc://org1peer-chaincode.127-0-0-1.nip.io:8080", 8443, "http://org1peer-operations.127-0-0-1.nip.io:8080", false, 0, 4000, "http://org1peer-gossip.127-0-0-1.nip.io:4000")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.External()).To(BeFalse())
+				p.SetExternal("debughost")
+				Expect(p.External()).To(BeTrue())
+				Expect(p.APIHostname(true)).To(Equal("debughost"))
+				Expect(p.APIHost(true)).To(Equal("debughost:7051"))
+				Expect(p.APIHost(false)).To(Equal("org1peer-api.127-0-0-1.nip.io:8080"))
+				Expect(p.ChaincodeURL(true).String()).To(BeEquivalentTo("grpc://debughost:7052"))
+				Expect(p.OperationsURL(true).String()).To(BeEquivalentTo("http://debughost:8443"))
+			})
+		})
+
+	})
+
+	Context("peer.IsTransactionNotFound()", func() {
+
+		When("called with the error for an unknown transaction ID", func() {
+			It("returns true", func() {
+				err := fmt.Errorf("Bad proposal response: status 500, mesage Failed to get block for txID abc, error no such transaction ID [abc] in index")
+				Expect(peer.IsTransactionNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("called with any other error", func() {
+			It("returns false", func() {
+				Expect(peer.IsTransactionNotFound(fmt.Errorf("connection refused"))).To(BeFalse())
+				Expect(peer.IsTransactionNotFound(nil)).To(BeFalse())
+			})
+		})
+
+	})
+
 })
@@ -0,0 +1,117 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package peer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger-labs/microfab/internal/pkg/protoutil"
+	"github.com/hyperledger-labs/microfab/internal/pkg/txid"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
+)
+
+// GetChainInfo asks the peer for the height and current block hash of the specified channel.
+func (c *Connection) GetChainInfo(channel string) (*common.BlockchainInfo, error) {
+	payload, err := c.query(channel, "qscc", []byte("GetChainInfo"), []byte(channel))
+	if err != nil {
+		return nil, err
+	}
+	result := &common.BlockchainInfo{}
+	err = proto.Unmarshal(payload, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IsTransactionNotFound returns true if the error was returned because the peer does not have the requested transaction
+// ID in its index, rather than because the peer could not be queried.
+func IsTransactionNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such transaction ID")
+}
+
+// GetBlockByTxID asks the peer for the block in the specified channel that contains the specified transaction. If the
+// peer does not have the transaction, the error satisfies IsTransactionNotFound.
+func (c *Connection) GetBlockByTxID(channel, txID string) (*common.Block, error) {
+	payload, err := c.query(channel, "qscc", []byte("GetBlockByTxID"), []byte(channel), []byte(txID))
+	if err != nil {
+		return nil, err
+	}
+	result := &common.Block{}
+	err = proto.Unmarshal(payload, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryChaincodeDefinitions asks the peer for the chaincode definitions committed on the specified channel.
+func (c *Connection) QueryChaincodeDefinitions(channel string) ([]*lifecycle.QueryChaincodeDefinitionsResult_ChaincodeDefinition, error) {
+	args := util.MarshalOrPanic(&lifecycle.QueryChaincodeDefinitionsArgs{})
+	payload, err := c.query(channel, "_lifecycle", []byte("QueryChaincodeDefinitions"), args)
+	if err != nil {
+		return nil, err
+	}
+	result := &lifecycle.QueryChaincodeDefinitionsResult{}
+	err = proto.Unmarshal(payload, result)
+	if err != nil {
+		return nil, err
+	}
+	return result.ChaincodeDefinitions, nil
+}
+
+// query sends a proposal for the specified system chaincode on the specified channel to the peer, and returns the
+// payload of the response.
+func (c *Connection) query(channel, chaincode string, args ...[]byte) ([]byte, error) {
+	txID := txid.New(c.mspID, c.identity)
+	channelHeader := protoutil.BuildChannelHeader(common.HeaderType_ENDORSER_TRANSACTION, channel, txID)
+	cche := &peer.ChaincodeHeaderExtension{
+		ChaincodeId: &peer.ChaincodeID{
+			Name: chaincode,
+		},
+	}
+	channelHeader.Extension = util.MarshalOrPanic(cche)
+	signatureHeader := protoutil.BuildSignatureHeader(txID)
+	header := &common.Header{
+		ChannelHeader:   util.MarshalOrPanic(channelHeader),
+		SignatureHeader: util.MarshalOrPanic(signatureHeader),
+	}
+	cciSpec := &peer.ChaincodeInvocationSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{
+			Type: peer.ChaincodeSpec_GOLANG,
+			ChaincodeId: &peer.ChaincodeID{
+				Name: chaincode,
+			},
+			Input: &peer.ChaincodeInput{
+				Args: args,
+			},
+		},
+	}
+	ccpp := &peer.ChaincodeProposalPayload{
+		Input: util.MarshalOrPanic(cciSpec),
+	}
+	proposal := &peer.Proposal{
+		Header:  util.MarshalOrPanic(header),
+		Payload: util.MarshalOrPanic(ccpp),
+	}
+	proposalBytes := util.MarshalOrPanic(proposal)
+	signature := c.identity.Sign(proposalBytes)
+	signedProposal := &peer.SignedProposal{
+		ProposalBytes: proposalBytes,
+		Signature:     signature,
+	}
+	response, err := c.ProcessProposal(signedProposal)
+	if err != nil {
+		return nil, err
+	} else if response.Response.Status != int32(common.Status_SUCCESS) {
+		return nil, fmt.Errorf("Bad proposal response: status %d, mesage %s", response.Response.Status, response.Response.Message)
+	}
+	return response.Response.Payload, nil
+}
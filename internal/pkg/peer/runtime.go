@@ -5,11 +5,9 @@
 package peer
 
 import (
-	"bufio"
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"os/exec"
@@ -17,12 +15,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 	"github.com/hyperledger-labs/microfab/internal/pkg/util"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "console"), log.LstdFlags)
+var logger = logging.New("peer")
 
 // Start starts the peer.
 func (p *Peer) Start(timeout time.Duration) error {
@@ -31,17 +30,20 @@ func (p *Peer) Start(timeout time.Duration) error {
 		return err
 	}
 	configDirectory := path.Join(p.directory, "config")
-	dataDirectory := path.Join(p.directory, "data")
 	logsDirectory := path.Join(p.directory, "logs")
 	mspDirectory := path.Join(p.directory, "msp")
 	err = util.CreateMSPDirectory(mspDirectory, p.identity)
 	if err != nil {
 		return err
 	}
-	err = p.createConfig(dataDirectory, mspDirectory)
+	err = p.createConfig()
 	if err != nil {
 		return err
 	}
+	if p.external {
+		logger.Printf("Waiting for external peer for endorsing organization %s, start it with FABRIC_CFG_PATH=%s peer node start", p.organization.Name(), configDirectory)
+		return p.waitForStart(timeout, nil)
+	}
 	cmd := exec.Command("peer", "node", "start")
 	cmd.Env = os.Environ()
 	extraEnvs := []string{
@@ -57,20 +59,9 @@ func (p *Peer) Start(timeout time.Duration) error {
 	if err != nil {
 		return err
 	}
-	go func() {
-		reader := bufio.NewReader(pipe)
-		scanner := bufio.NewScanner(reader)
-		scanner.Split(bufio.ScanLines)
-		id := strings.ToLower(p.identity.Name())
-		id = strings.ReplaceAll(id, " ", "")
-		logger := log.New(os.Stdout, fmt.Sprintf("[%16s] ", id), 0)
-		for scanner.Scan() {
-			logger.Println(scanner.Text())
-			logFile.WriteString(scanner.Text())
-		}
-		pipe.Close()
-		logFile.Close()
-	}()
+	id := strings.ToLower(p.identity.Name())
+	id = strings.ReplaceAll(id, " ", "")
+	go logging.Pipe(id, pipe, logFile)
 	cmd.Stderr = cmd.Stdout
 	err = cmd.Start()
 	if err != nil {
@@ -84,6 +75,10 @@ func (p *Peer) Start(timeout time.Duration) error {
 			errchan <- err
 		}
 	}()
+	return p.waitForStart(timeout, errchan)
+}
+
+func (p *Peer) waitForStart(timeout time.Duration, errchan chan error) error {
 	timeoutCh := time.After(timeout)
 	tick := time.Tick(250 * time.Millisecond)
 	for {
@@ -132,24 +127,53 @@ func (p *Peer) createDirectories() error {
 	return nil
 }
 
-func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
+func (p *Peer) createConfig() error {
+	config, err := p.CoreConfig(p.directory)
+	if err != nil {
+		return err
+	}
+	if p.tls != nil {
+		tlsDirectory := path.Join(p.directory, "tls")
+		if err := ioutil.WriteFile(path.Join(tlsDirectory, "cert.pem"), p.tls.Certificate().Bytes(), 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path.Join(tlsDirectory, "key.pem"), p.tls.PrivateKey().Bytes(), 0644); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path.Join(tlsDirectory, "ca.pem"), p.tls.CA().Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	configData, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	configFile := path.Join(p.directory, "config", "core.yaml")
+	return ioutil.WriteFile(configFile, configData, 0644)
+}
+
+// CoreConfig returns the core.yaml configuration for the peer, with the data, MSP and TLS files for the peer
+// located under the specified directory.
+func (p *Peer) CoreConfig(directory string) (map[interface{}]interface{}, error) {
+	dataDirectory := path.Join(directory, "data")
+	mspDirectory := path.Join(directory, "msp")
 	fabricConfigPath, ok := os.LookupEnv("FABRIC_CFG_PATH")
 	if !ok {
-		return fmt.Errorf("FABRIC_CFG_PATH not defined")
+		return nil, fmt.Errorf("FABRIC_CFG_PATH not defined")
 	}
 	configFile := path.Join(fabricConfigPath, "core.yaml")
 	configData, err := ioutil.ReadFile(configFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	config := map[interface{}]interface{}{}
 	err = yaml.Unmarshal(configData, config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	peer, ok := config["peer"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing peer section")
+		return nil, fmt.Errorf("core.yaml missing peer section")
 	}
 	peer["id"] = fmt.Sprintf("%speer", strings.ToLower(p.organization.Name()))
 	peer["mspConfigPath"] = mspDirectory
@@ -160,7 +184,7 @@ func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
 	peer["chaincodeListenAddress"] = fmt.Sprintf("127.0.0.1:%d", p.chaincodePort)
 	gossip, ok := peer["gossip"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing peer.gossip section")
+		return nil, fmt.Errorf("core.yaml missing peer.gossip section")
 	}
 
 	gossip["bootstrap"] = p.APIHost(true)
@@ -168,30 +192,30 @@ func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
 	gossip["orgLeader"] = true
 	gossip["endpoint"] = p.APIHost(true)
 	gossip["externalEndpoint"] = p.APIHost(true)
-	logger.Printf("Creating peer with gossip URL %s", gossip["bootstrap"])
+	logger.Debugf("Creating peer with gossip URL %s", gossip["bootstrap"])
 
 	metrics, ok := config["metrics"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing metrics section")
+		return nil, fmt.Errorf("core.yaml missing metrics section")
 	}
 	metrics["provider"] = "prometheus"
 	operations, ok := config["operations"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing operations section")
+		return nil, fmt.Errorf("core.yaml missing operations section")
 	}
 	operations["listenAddress"] = fmt.Sprintf("0.0.0.0:%d", p.operationsPort)
 	vm, ok := config["vm"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing vm section")
+		return nil, fmt.Errorf("core.yaml missing vm section")
 	}
 	vm["endpoint"] = ""
 	chaincode, ok := config["chaincode"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing chaincode section")
+		return nil, fmt.Errorf("core.yaml missing chaincode section")
 	}
 	homeDirectory, err := util.GetHomeDirectory()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	chaincode["externalBuilders"] = []map[interface{}]interface{}{
 		{
@@ -238,16 +262,16 @@ func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
 	}
 	ledger, ok := config["ledger"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing ledger section")
+		return nil, fmt.Errorf("core.yaml missing ledger section")
 	}
 	state, ok := ledger["state"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing ledger.state section")
+		return nil, fmt.Errorf("core.yaml missing ledger.state section")
 	}
 
 	snapshots, ok := ledger["snapshots"].(map[interface{}]interface{})
 	if !ok {
-		return fmt.Errorf("core.yaml missing ledger.snapshots section")
+		return nil, fmt.Errorf("core.yaml missing ledger.snapshots section")
 	}
 	snapshots["rootDir"] = path.Join(dataDirectory, "snapshots")
 
@@ -255,7 +279,7 @@ func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
 		state["stateDatabase"] = "CouchDB"
 		couchDBConfig, ok := state["couchDBConfig"].(map[interface{}]interface{})
 		if !ok {
-			return fmt.Errorf("core.yaml missing ledger.state.couchDBConfig section")
+			return nil, fmt.Errorf("core.yaml missing ledger.state.couchDBConfig section")
 		}
 		couchDBConfig["couchDBAddress"] = fmt.Sprintf("localhost:%d", p.couchDBPort)
 		couchDBConfig["username"] = "admin"
@@ -263,13 +287,13 @@ func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
 
 	}
 	if p.tls != nil {
-		tlsDirectory := path.Join(p.directory, "tls")
+		tlsDirectory := path.Join(directory, "tls")
 		certFile := path.Join(tlsDirectory, "cert.pem")
 		keyFile := path.Join(tlsDirectory, "key.pem")
 		caFile := path.Join(tlsDirectory, "ca.pem")
 		tls, ok := peer["tls"].(map[interface{}]interface{})
 		if !ok {
-			return fmt.Errorf("core.yaml missing peer.tls section")
+			return nil, fmt.Errorf("core.yaml missing peer.tls section")
 		}
 		tls["enabled"] = true
 		tls["cert"] = map[string]string{"file": certFile}
@@ -279,28 +303,18 @@ func (p *Peer) createConfig(dataDirectory, mspDirectory string) error {
 		tls["clientRootCAs"] = map[string]string{"file": caFile}
 		tls, ok = operations["tls"].(map[interface{}]interface{})
 		if !ok {
-			return fmt.Errorf("core.yaml missing operations.tls section")
+			return nil, fmt.Errorf("core.yaml missing operations.tls section")
 		}
 		tls["enabled"] = true
 		tls["cert"] = map[string]string{"file": certFile}
 		tls["key"] = map[string]string{"file": keyFile}
 
-		if err := ioutil.WriteFile(certFile, p.tls.Certificate().Bytes(), 0644); err != nil {
-			return err
-		}
-		if err := ioutil.WriteFile(keyFile, p.tls.PrivateKey().Bytes(), 0644); err != nil {
-			return err
-		}
-		if err := ioutil.WriteFile(caFile, p.tls.CA().Bytes(), 0644); err != nil {
-			return err
-		}
 	}
-	configData, err = yaml.Marshal(config)
+	err = util.ApplyOverrides(config, p.overrides)
 	if err != nil {
-		return err
+		return nil, errors.WithMessage(err, "failed to apply core.yaml overrides")
 	}
-	configFile = path.Join(p.directory, "config", "core.yaml")
-	return ioutil.WriteFile(configFile, configData, 0644)
+	return config, nil
 }
 
 func (p *Peer) hasStarted() bool {
@@ -313,7 +327,7 @@ func (p *Peer) hasStarted() bool {
 	}
 	resp, err := cli.Get(fmt.Sprintf("%s/healthz", p.OperationsURL(true)))
 	if err != nil {
-		log.Printf("error waiting for peer: %v\n", err)
+		logger.Debugf("error waiting for peer: %v", err)
 		return false
 	}
 	if resp.StatusCode != 200 {
@@ -168,7 +168,8 @@ func BuildFabricCryptoConfig() *msp.FabricCryptoConfig {
 	}
 }
 
-// BuildFabricMSPConfig builds the Fabric MSP configuration for an organization.
+// BuildFabricMSPConfig builds the Fabric MSP configuration for an organization. If TLS is enabled, the TLS root
+// certificates include the TLS CA of the organization, or the CA of the specified TLS identity if it has none.
 func BuildFabricMSPConfig(organization *organization.Organization, tls *identity.Identity) (*msp.FabricMSPConfig, error) {
 	mspConfig := &msp.FabricMSPConfig{
 		Name: organization.MSPID(),
@@ -187,9 +188,14 @@ func BuildFabricMSPConfig(organization *organization.Organization, tls *identity
 		CryptoConfig:                  BuildFabricCryptoConfig(),
 		FabricNodeOus:                 BuildFabricNodeOUs(),
 	}
-	if tls != nil {
+	if tls != nil && organization.TLSCA() != nil {
+		mspConfig.TlsRootCerts = [][]byte{organization.TLSCA().Certificate().Bytes()}
+	} else if tls != nil {
 		mspConfig.TlsRootCerts = [][]byte{tls.CA().Bytes()}
 	}
+	for _, cert := range organization.TLSRootCerts() {
+		mspConfig.TlsRootCerts = append(mspConfig.TlsRootCerts, cert.Bytes())
+	}
 	return mspConfig, nil
 }
 
@@ -245,5 +251,11 @@ func BuildConfigGroupFromOrganization(organization *organization.Organization, t
 			},
 		},
 	}
+	for name, policy := range organization.Policies() {
+		configGroup.Policies[name] = &common.ConfigPolicy{
+			ModPolicy: "Admins",
+			Policy:    policy,
+		}
+	}
 	return configGroup, nil
 }
@@ -8,31 +8,38 @@ import (
 	"crypto/tls"
 	gotls "crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
-	"os"
 	"regexp"
+	"strings"
 
 	"github.com/hyperledger-labs/microfab/internal/pkg/ca"
 	"github.com/hyperledger-labs/microfab/internal/pkg/console"
 	"github.com/hyperledger-labs/microfab/internal/pkg/couchdb"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/logging"
 	"github.com/hyperledger-labs/microfab/internal/pkg/orderer"
 	"github.com/hyperledger-labs/microfab/internal/pkg/peer"
+	"github.com/hyperledger-labs/microfab/internal/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
-var logger = log.New(os.Stdout, fmt.Sprintf("[%16s] ", "console"), log.LstdFlags)
+var logger = logging.New("proxy")
 
 type route struct {
-	SourceHost string
-	TargetHost string
-	UseHTTP2   bool
-	UseTLS     bool
+	SourceHost               string
+	TargetHost               string
+	UseHTTP2                 bool
+	UseTLS                   bool
+	ForwardClientCertificate bool
+	// ProxySecret proves to the console that the proxy passed on the client certificate.
+	ProxySecret string
 }
 
 type routeMap map[string]*route
@@ -45,6 +52,9 @@ type Proxy struct {
 	tls        *identity.Identity
 	caCertPool *x509.CertPool
 	peerCert   gotls.Certificate
+	nodeCerts  map[string]*gotls.Certificate
+	// clientCertHosts are the hostnames that clients can present a TLS client certificate to.
+	clientCertHosts map[string]bool
 }
 
 type h2cTransportWrapper struct {
@@ -69,9 +79,12 @@ func New(port int) (*Proxy, error) {
 		route, ok := p.routeMap[host]
 		if !ok && len(p.routes) > 0 {
 			route = p.routes[0]
-			logger.Printf("No route found for '%s' assuming ['%s','%s']", host, route.SourceHost, route.TargetHost)
+			logger.Warnf("No route found for '%s' assuming ['%s','%s']", host, route.SourceHost, route.TargetHost)
 		}
-		logger.Printf("Using route mapping for '%s' ['%s','%s','%t']", host, route.SourceHost, route.TargetHost, route.UseTLS)
+		logger.Debugf("Using route mapping for '%s' ['%s','%s','%t']", host, route.SourceHost, route.TargetHost, route.UseTLS)
+		// Clients cannot present a TLS client certificate without TLS, so never pass one on.
+		req.Header.Del(console.ClientCertificateHeader)
+		req.Header.Del(console.ProxySecretHeader)
 		if route.UseHTTP2 {
 			req.URL.Scheme = "h2c"
 		} else {
@@ -99,7 +112,7 @@ func New(port int) (*Proxy, error) {
 	}
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
-		Handler: h2c.NewHandler(reverseProxy, &http2.Server{}),
+		Handler: h2c.NewHandler(p.traceHandler(port, reverseProxy), &http2.Server{}),
 	}
 	err = http2.ConfigureServer(httpServer, nil)
 	if err != nil {
@@ -111,22 +124,28 @@ func New(port int) (*Proxy, error) {
 
 // NewWithTLS creates a new instance of a proxy that is TLS enabled.
 func NewWithTLS(tls *identity.Identity, port int) (*Proxy, error) {
-	p := &Proxy{routeMap: routeMap{}, tls: tls}
+	p := &Proxy{routeMap: routeMap{}, tls: tls, nodeCerts: map[string]*gotls.Certificate{}, clientCertHosts: map[string]bool{}}
 	p.caCertPool = x509.NewCertPool()
 	p.caCertPool.AddCert(tls.CA().Certificate())
 
 	director := func(req *http.Request) {
 		host := req.Host
-		logger.Printf("RemoteAddr=%s RequestURI=%s  Host=%s", req.RemoteAddr, req.RequestURI, host)
+		logger.Debugf("RemoteAddr=%s RequestURI=%s  Host=%s", req.RemoteAddr, req.RequestURI, host)
 		if !portRegex.MatchString(host) {
 			host += fmt.Sprintf(":%d", port)
 		}
 		route, ok := p.routeMap[host]
 		if !ok && len(p.routes) > 0 {
 			route = p.routes[0]
-			logger.Printf("No route found for '%s' assuming ['%s','%s']", host, route.SourceHost, route.TargetHost)
+			logger.Warnf("No route found for '%s' assuming ['%s','%s']", host, route.SourceHost, route.TargetHost)
+		}
+		logger.Debugf("Using route mapping for '%s' ['%s','%s','%t','%t']", host, route.SourceHost, route.TargetHost, route.UseTLS, route.UseHTTP2)
+		req.Header.Del(console.ClientCertificateHeader)
+		req.Header.Del(console.ProxySecretHeader)
+		if route.ForwardClientCertificate && req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+			req.Header.Set(console.ClientCertificateHeader, base64.StdEncoding.EncodeToString(req.TLS.PeerCertificates[0].Raw))
+			req.Header.Set(console.ProxySecretHeader, route.ProxySecret)
 		}
-		logger.Printf("Using route mapping for '%s' ['%s','%s','%t','%t']", host, route.SourceHost, route.TargetHost, route.UseTLS, route.UseHTTP2)
 		if route.UseTLS {
 			req.URL.Scheme = "https"
 		} else {
@@ -157,7 +176,7 @@ func NewWithTLS(tls *identity.Identity, port int) (*Proxy, error) {
 	}
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
-		Handler: reverseProxy,
+		Handler: p.traceHandler(port, reverseProxy),
 	}
 	err = http2.ConfigureServer(httpServer, nil)
 	if err != nil {
@@ -177,13 +196,68 @@ func NewWithTLS(tls *identity.Identity, port int) (*Proxy, error) {
 			"http/1.1",
 		},
 		Certificates: []gotls.Certificate{certificate},
+		// Present the TLS certificate of the node behind each route, so that clients see the same certificate
+		// as they would if they connected to the node directly.
+		GetCertificate: func(hello *gotls.ClientHelloInfo) (*gotls.Certificate, error) {
+			return p.nodeCerts[strings.ToLower(hello.ServerName)], nil
+		},
+		// Only ask for a TLS client certificate when connecting to a host that uses it, so that browsers do not
+		// prompt for a certificate for every other host.
+		GetConfigForClient: func(hello *gotls.ClientHelloInfo) (*gotls.Config, error) {
+			if !p.clientCertHosts[strings.ToLower(hello.ServerName)] {
+				return nil, nil
+			}
+			config := httpServer.TLSConfig.Clone()
+			config.ClientAuth = gotls.RequestClientCert
+			return config, nil
+		},
 	}
 	p.httpServer = httpServer
 	return p, nil
 }
 
+// traceHandler wraps the specified handler so that every proxied request is recorded as a span.
+func (p *Proxy) traceHandler(port int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if !portRegex.MatchString(host) {
+			host += fmt.Sprintf(":%d", port)
+		}
+		name := fmt.Sprintf("proxy %s", host)
+		attrs := []attribute.KeyValue{
+			attribute.String("http.host", host),
+			attribute.String("http.method", req.Method),
+			attribute.String("http.path", req.URL.Path),
+		}
+		if route, ok := p.routeMap[host]; ok {
+			attrs = append(attrs, attribute.String("proxy.target", route.TargetHost))
+		}
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+			parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)
+			if len(parts) == 2 {
+				name = fmt.Sprintf("proxy %s/%s", parts[0], parts[1])
+				attrs = append(attrs,
+					attribute.String("rpc.system", "grpc"),
+					attribute.String("rpc.service", parts[0]),
+					attribute.String("rpc.method", parts[1]),
+				)
+			}
+		}
+		ctx, span := tracing.Tracer().Start(req.Context(), name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+		defer span.End()
+		next.ServeHTTP(rw, req.WithContext(ctx))
+		status := rw.Header().Get("Grpc-Status")
+		if status == "" {
+			status = rw.Header().Get(http.TrailerPrefix + "Grpc-Status")
+		}
+		if status != "" {
+			span.SetAttributes(attribute.String("rpc.grpc.status_code", status))
+		}
+	})
+}
+
 func (p *Proxy) dialTLS(network, addr string) (net.Conn, error) {
-	logger.Printf("dialTLS %s %s", network, addr)
+	logger.Debugf("dialTLS %s %s", network, addr)
 	conn, err := net.Dial(network, addr)
 	if err != nil {
 		return nil, err
@@ -209,7 +283,7 @@ func (p *Proxy) dialTLS(network, addr string) (net.Conn, error) {
 	cert := cs.PeerCertificates[0]
 
 	// Verify here
-	log.Println(cert.Subject)
+	logger.Debugf("%v", cert.Subject)
 
 	return tlsConn, nil
 }
@@ -217,13 +291,18 @@ func (p *Proxy) dialTLS(network, addr string) (net.Conn, error) {
 // RegisterConsole registers the specified console with the proxy.
 func (p *Proxy) RegisterConsole(console *console.Console) {
 	route := &route{
-		SourceHost: console.URL().Host,
-		TargetHost: fmt.Sprintf("localhost:%d", console.Port()),
-		UseHTTP2:   false,
-		UseTLS:     true,
+		SourceHost:               console.URL().Host,
+		TargetHost:               fmt.Sprintf("localhost:%d", console.Port()),
+		UseHTTP2:                 false,
+		UseTLS:                   true,
+		ForwardClientCertificate: console.ClientCertificates(),
+		ProxySecret:              console.ProxySecret(),
 	}
 	p.routes = append(p.routes, route)
 	p.buildRouteMap()
+	if route.ForwardClientCertificate && p.clientCertHosts != nil {
+		p.clientCertHosts[strings.ToLower(console.URL().Hostname())] = true
+	}
 }
 
 // RegisterPeer registers the specified peer with the proxy.
@@ -250,6 +329,7 @@ func (p *Proxy) RegisterPeer(peer *peer.Peer) {
 	}
 	p.routes = append(p.routes, routes...)
 	p.buildRouteMap()
+	p.registerNodeCert(peer.TLS(), routes...)
 }
 
 // RegisterOrderer registers the specified orderer with the proxy.
@@ -270,6 +350,7 @@ func (p *Proxy) RegisterOrderer(orderer *orderer.Orderer) {
 	}
 	p.routes = append(p.routes, routes...)
 	p.buildRouteMap()
+	p.registerNodeCert(orderer.TLS(), routes...)
 }
 
 // RegisterCA registers the specified CA with the proxy.
@@ -290,6 +371,23 @@ func (p *Proxy) RegisterCA(ca *ca.CA) {
 	}
 	p.routes = append(p.routes, routes...)
 	p.buildRouteMap()
+	p.registerNodeCert(ca.TLS(), routes...)
+}
+
+// registerNodeCert presents the TLS certificate of a node for the source hosts of the specified routes.
+func (p *Proxy) registerNodeCert(tls *identity.Identity, routes ...*route) {
+	if p.nodeCerts == nil || tls == nil {
+		return
+	}
+	certificate, err := gotls.X509KeyPair(tls.Certificate().Bytes(), tls.PrivateKey().Bytes())
+	if err != nil {
+		logger.Warnf("Invalid TLS certificate %s: %v", tls.Name(), err)
+		return
+	}
+	for _, route := range routes {
+		hostname := strings.ToLower(portRegex.ReplaceAllString(route.SourceHost, ""))
+		p.nodeCerts[hostname] = &certificate
+	}
 }
 
 // RegisterCouchDB registers the specified CouchDB with the proxy.
@@ -326,6 +424,6 @@ func (p *Proxy) buildRouteMap() {
 // DumpRouteMap logs the route mappings that have been registered
 func (p *Proxy) DumpRouteMap() {
 	for key, route := range p.routeMap {
-		logger.Printf("%s ==> %v \n", key, route)
+		logger.Debugf("%s ==> %v", key, route)
 	}
 }
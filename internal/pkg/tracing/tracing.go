@@ -0,0 +1,92 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/hyperledger-labs/microfab"
+
+// Provider represents a trace provider that exports spans from Microfab.
+type Provider struct {
+	tracerProvider *sdktrace.TracerProvider
+	file           *os.File
+}
+
+// NewFileProvider creates a new trace provider that writes spans as JSON to the specified file.
+func NewFileProvider(file string) (*Provider, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return newProvider(exporter, f)
+}
+
+// NewOTLPProvider creates a new trace provider that sends spans to an OTLP/HTTP collector at the specified endpoint.
+func NewOTLPProvider(endpoint string) (*Provider, error) {
+	exporter, err := otlptracehttp.New(context.Background(), otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return newProvider(exporter, nil)
+}
+
+func newProvider(exporter sdktrace.SpanExporter, file *os.File) (*Provider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("microfabd")))
+	if err != nil {
+		return nil, err
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	return &Provider{tracerProvider, file}, nil
+}
+
+// Shutdown flushes any outstanding spans and stops the trace provider.
+func (p *Provider) Shutdown() error {
+	err := p.tracerProvider.Shutdown(context.Background())
+	if p.file != nil {
+		if closeErr := p.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Tracer returns the tracer used by Microfab. If no provider has been created, the tracer does nothing.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Span runs the specified function inside a new span, recording any error it returns.
+func Span(ctx context.Context, name string, fn func(context.Context) error, attrs ...attribute.KeyValue) error {
+	ctx, span := Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+	defer span.End()
+	err := fn(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var _ = Describe("the tracing package", func() {
+
+	var testFile string
+
+	BeforeEach(func() {
+		testDirectory, err := ioutil.TempDir("", "ut-tracing")
+		Expect(err).NotTo(HaveOccurred())
+		testFile = path.Join(testDirectory, "traces.json")
+	})
+
+	Context("tracing.NewFileProvider()", func() {
+
+		When("spans are recorded and the provider is shut down", func() {
+			It("writes the spans to the file", func() {
+				provider, err := tracing.NewFileProvider(testFile)
+				Expect(err).NotTo(HaveOccurred())
+				err = tracing.Span(context.Background(), "create endorsing organization", func(context.Context) error {
+					return nil
+				}, attribute.String("organization", "Org1"))
+				Expect(err).NotTo(HaveOccurred())
+				err = tracing.Span(context.Background(), "start peer", func(context.Context) error {
+					return errors.New("peer failed")
+				})
+				Expect(err).To(MatchError("peer failed"))
+				Expect(provider.Shutdown()).To(Succeed())
+				data, err := ioutil.ReadFile(testFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring(`"Name":"create endorsing organization"`))
+				Expect(string(data)).To(ContainSubstring(`"Value":"Org1"`))
+				Expect(string(data)).To(ContainSubstring(`"Name":"start peer"`))
+				Expect(string(data)).To(ContainSubstring("peer failed"))
+			})
+		})
+
+	})
+
+})
@@ -0,0 +1,137 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ApplyOverrides merges the specified overrides, keyed by dotted key path (for example "peer.gateway.endorsementTimeout"),
+// on top of the specified configuration. Every key path must already exist in the configuration. If an override is a
+// map, and the key path is a section, the map is merged into the section and the other keys in the section are kept.
+// Every key in the map must also already exist in the section.
+func ApplyOverrides(config map[interface{}]interface{}, overrides map[string]interface{}) error {
+	for _, keyPath := range sortedKeys(overrides) {
+		parent, key, err := lookupKeyPath(config, keyPath)
+		if err != nil {
+			return err
+		}
+		value, err := mergeValue(keyPath, parent[key], overrides[keyPath])
+		if err != nil {
+			return err
+		}
+		parent[key] = value
+	}
+	return nil
+}
+
+// mergeValue returns the override merged into the existing value at the specified key path. Maps are merged key by key,
+// and any other value replaces the existing value.
+func mergeValue(keyPath string, existing interface{}, override interface{}) (interface{}, error) {
+	section, ok := existing.(map[interface{}]interface{})
+	if !ok {
+		return override, nil
+	}
+	var entries map[interface{}]interface{}
+	switch value := override.(type) {
+	case map[interface{}]interface{}:
+		entries = value
+	case map[string]interface{}:
+		entries = map[interface{}]interface{}{}
+		for name, entry := range value {
+			entries[name] = entry
+		}
+	default:
+		return override, nil
+	}
+	for name, entry := range entries {
+		nameString := fmt.Sprintf("%v", name)
+		key, ok := findKey(section, nameString)
+		if !ok {
+			return nil, fmt.Errorf("Unknown configuration key path %s.%s", keyPath, nameString)
+		}
+		value, err := mergeValue(fmt.Sprintf("%s.%s", keyPath, nameString), section[key], entry)
+		if err != nil {
+			return nil, err
+		}
+		section[key] = value
+	}
+	return section, nil
+}
+
+// OverridesToEnvironment converts the specified overrides, keyed by dotted key path (for example "General.Keepalive.ServerMinInterval"),
+// into environment variables with the specified prefix (for example "ORDERER"). Every key path must already exist in the
+// configuration, and every value must be a scalar.
+func OverridesToEnvironment(prefix string, config map[interface{}]interface{}, overrides map[string]interface{}) ([]string, error) {
+	result := []string{}
+	for _, keyPath := range sortedKeys(overrides) {
+		_, _, err := lookupKeyPath(config, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		value := overrides[keyPath]
+		switch value.(type) {
+		case string, bool, float64, int:
+		default:
+			return nil, fmt.Errorf("Invalid value for key path %s, must be a string, number or boolean", keyPath)
+		}
+		name := strings.ToUpper(strings.ReplaceAll(keyPath, ".", "_"))
+		result = append(result, fmt.Sprintf("%s_%s=%s", prefix, name, FormatScalar(value)))
+	}
+	return result, nil
+}
+
+// FormatScalar formats a string, number or boolean from a JSON configuration file. Numbers are decoded from JSON as
+// float64, so they are formatted without an exponent, for example 104857600 rather than 1.048576e+08.
+func FormatScalar(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func lookupKeyPath(config map[interface{}]interface{}, keyPath string) (map[interface{}]interface{}, interface{}, error) {
+	parts := strings.Split(keyPath, ".")
+	current := config
+	for i, part := range parts {
+		key, ok := findKey(current, part)
+		if !ok {
+			return nil, nil, fmt.Errorf("Unknown configuration key path %s", keyPath)
+		}
+		if i == len(parts)-1 {
+			return current, key, nil
+		}
+		next, ok := current[key].(map[interface{}]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("Unknown configuration key path %s, %s is not a section", keyPath, strings.Join(parts[:i+1], "."))
+		}
+		current = next
+	}
+	return nil, nil, fmt.Errorf("Invalid configuration key path %s", keyPath)
+}
+
+func findKey(config map[interface{}]interface{}, name string) (interface{}, bool) {
+	if _, ok := config[name]; ok {
+		return name, true
+	}
+	for key := range config {
+		if keyString, ok := key.(string); ok && strings.EqualFold(keyString, name) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func sortedKeys(overrides map[string]interface{}) []string {
+	result := []string{}
+	for key := range overrides {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
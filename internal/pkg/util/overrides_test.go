@@ -0,0 +1,189 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package util_test
+
+import (
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+const testConfig = `
+General:
+  ListenPort: 7050
+  Keepalive:
+    ServerMinInterval: 60s
+peer:
+  id: jdoe
+  gateway:
+    endorsementTimeout: 30s
+  tls:
+    enabled: false
+    cert:
+      file: tls/server.crt
+    key:
+      file: tls/server.key
+`
+
+var _ = Describe("the util package", func() {
+
+	var config map[interface{}]interface{}
+
+	BeforeEach(func() {
+		config = map[interface{}]interface{}{}
+		err := yaml.Unmarshal([]byte(testConfig), config)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("util.ApplyOverrides()", func() {
+
+		When("called with known key paths", func() {
+			It("merges the overrides into the configuration", func() {
+				err := util.ApplyOverrides(config, map[string]interface{}{
+					"peer.gateway.endorsementTimeout": "2m",
+					"peer.tls":                        map[string]interface{}{"enabled": true},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				peer := config["peer"].(map[interface{}]interface{})
+				Expect(peer["gateway"]).To(HaveKeyWithValue("endorsementTimeout", "2m"))
+				Expect(peer["tls"]).To(Equal(map[interface{}]interface{}{
+					"enabled": true,
+					"cert":    map[interface{}]interface{}{"file": "tls/server.crt"},
+					"key":     map[interface{}]interface{}{"file": "tls/server.key"},
+				}))
+				Expect(peer["id"]).To(Equal("jdoe"))
+			})
+		})
+
+		When("called with a map for a nested section", func() {
+			It("merges the map into the existing section, keeping the other keys", func() {
+				err := util.ApplyOverrides(config, map[string]interface{}{
+					"peer": map[string]interface{}{
+						"TLS": map[string]interface{}{"key": map[string]interface{}{"file": "other.key"}},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				peer := config["peer"].(map[interface{}]interface{})
+				Expect(peer["id"]).To(Equal("jdoe"))
+				Expect(peer["gateway"]).To(HaveKeyWithValue("endorsementTimeout", "30s"))
+				Expect(peer["tls"]).To(Equal(map[interface{}]interface{}{
+					"enabled": false,
+					"cert":    map[interface{}]interface{}{"file": "tls/server.crt"},
+					"key":     map[interface{}]interface{}{"file": "other.key"},
+				}))
+			})
+		})
+
+		When("called with a key path that differs only by case", func() {
+			It("merges the override into the existing key", func() {
+				err := util.ApplyOverrides(config, map[string]interface{}{
+					"general.listenport": 8050,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config["General"]).To(HaveKeyWithValue("ListenPort", 8050))
+			})
+		})
+
+		When("called with an unknown key path", func() {
+			It("returns an error", func() {
+				err := util.ApplyOverrides(config, map[string]interface{}{
+					"peer.gateway.unknown": "2m",
+				})
+				Expect(err).To(MatchError("Unknown configuration key path peer.gateway.unknown"))
+			})
+		})
+
+		When("called with a map that contains an unknown key", func() {
+			It("returns an error without changing the configuration", func() {
+				err := util.ApplyOverrides(config, map[string]interface{}{
+					"peer": map[string]interface{}{
+						"tls": map[string]interface{}{"enabeld": true},
+					},
+				})
+				Expect(err).To(MatchError("Unknown configuration key path peer.tls.enabeld"))
+				Expect(config["peer"]).To(HaveKeyWithValue("tls", HaveKeyWithValue("enabled", false)))
+				Expect(config["peer"]).NotTo(HaveKeyWithValue("tls", HaveKey("enabeld")))
+			})
+		})
+
+		When("called with a key path through a value that is not a section", func() {
+			It("returns an error", func() {
+				err := util.ApplyOverrides(config, map[string]interface{}{
+					"peer.id.name": "bob",
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+	})
+
+	Context("util.OverridesToEnvironment()", func() {
+
+		When("called with known key paths", func() {
+			It("returns environment variables", func() {
+				envs, err := util.OverridesToEnvironment("ORDERER", config, map[string]interface{}{
+					"General.Keepalive.ServerMinInterval": "30s",
+					"General.ListenPort":                  float64(7051),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envs).To(Equal([]string{
+					"ORDERER_GENERAL_KEEPALIVE_SERVERMININTERVAL=30s",
+					"ORDERER_GENERAL_LISTENPORT=7051",
+				}))
+			})
+		})
+
+		When("called with a large integral number", func() {
+			It("formats the number without an exponent", func() {
+				envs, err := util.OverridesToEnvironment("ORDERER", config, map[string]interface{}{
+					"General.ListenPort": float64(104857600),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envs).To(Equal([]string{"ORDERER_GENERAL_LISTENPORT=104857600"}))
+			})
+		})
+
+		When("called with an unknown key path", func() {
+			It("returns an error", func() {
+				_, err := util.OverridesToEnvironment("ORDERER", config, map[string]interface{}{
+					"General.Unknown": "30s",
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("called with a value that is not a scalar", func() {
+			It("returns an error", func() {
+				_, err := util.OverridesToEnvironment("ORDERER", config, map[string]interface{}{
+					"General.Keepalive": map[string]interface{}{},
+				})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+	})
+
+	Context("util.FormatScalar()", func() {
+
+		When("called with numbers", func() {
+			It("formats them without an exponent", func() {
+				Expect(util.FormatScalar(float64(1000000))).To(Equal("1000000"))
+				Expect(util.FormatScalar(float64(104857600))).To(Equal("104857600"))
+				Expect(util.FormatScalar(1.5)).To(Equal("1.5"))
+				Expect(util.FormatScalar(42)).To(Equal("42"))
+			})
+		})
+
+		When("called with strings and booleans", func() {
+			It("formats them unchanged", func() {
+				Expect(util.FormatScalar("30s")).To(Equal("30s"))
+				Expect(util.FormatScalar(true)).To(Equal("true"))
+			})
+		})
+
+	})
+
+})
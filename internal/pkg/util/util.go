@@ -5,12 +5,15 @@
 package util
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/certificate"
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity/privatekey"
 )
 
 // GetHomeDirectory returns the Microfab home directory.
@@ -46,54 +49,158 @@ func UnmarshalOrPanic(b []byte, m proto.Message) {
 const config = `NodeOUs:
   Enable: true
   ClientOUIdentifier:
-    Certificate: cacerts/ca.pem
+    Certificate: cacerts/%[1]s
     OrganizationalUnitIdentifier: client
   AdminOUIdentifier:
-    Certificate: cacerts/ca.pem
+    Certificate: cacerts/%[1]s
     OrganizationalUnitIdentifier: admin
   PeerOUIdentifier:
-    Certificate: cacerts/ca.pem
+    Certificate: cacerts/%[1]s
     OrganizationalUnitIdentifier: peer
   OrdererOUIdentifier:
-    Certificate: cacerts/ca.pem
+    Certificate: cacerts/%[1]s
     OrganizationalUnitIdentifier: orderer
 `
 
+type mspDirectory struct {
+	caName          string
+	certificateName string
+	privateKeyName  string
+	tlsCA           *certificate.Certificate
+	tlsCAName       string
+}
+
+// MSPOption is a type representing an option for creating an MSP directory.
+type MSPOption func(*mspDirectory)
+
+// WithFileNames uses the specified file names for the CA certificate, the signing certificate, and the private key.
+func WithFileNames(caName, certificateName, privateKeyName string) MSPOption {
+	return func(m *mspDirectory) {
+		m.caName = caName
+		m.certificateName = certificateName
+		m.privateKeyName = privateKeyName
+	}
+}
+
+// WithTLSCA adds the specified TLS CA certificate to the tlscacerts directory, using the specified file name.
+func WithTLSCA(tlsCA *certificate.Certificate, name string) MSPOption {
+	return func(m *mspDirectory) {
+		m.tlsCA = tlsCA
+		m.tlsCAName = name
+	}
+}
+
 // CreateMSPDirectory creates an MSP directory on disk suitable for the peer or orderer to use.
-func CreateMSPDirectory(directory string, identity *identity.Identity) error {
+func CreateMSPDirectory(directory string, identity *identity.Identity, opts ...MSPOption) error {
+	m, err := createMSPDirectory(directory, identity.CA(), opts...)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(path.Join(directory, "keystore"), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(path.Join(directory, "signcerts"), 0755)
+	if err != nil {
+		return err
+	}
+	privateKey := identity.PrivateKey().Bytes()
+	err = ioutil.WriteFile(path.Join(directory, "keystore", m.privateKeyName), privateKey, 0644)
+	if err != nil {
+		return err
+	}
+	certificate := identity.Certificate().Bytes()
+	return ioutil.WriteFile(path.Join(directory, "signcerts", m.certificateName), certificate, 0644)
+}
+
+// CreateVerifyingMSPDirectory creates an MSP directory on disk that contains the CA certificates for an
+// organization, but no signing identity. This is the layout used for organization MSP definitions.
+func CreateVerifyingMSPDirectory(directory string, ca *certificate.Certificate, opts ...MSPOption) error {
+	_, err := createMSPDirectory(directory, ca, opts...)
+	return err
+}
+
+// LoadMSPDirectory loads the signing identity from an MSP directory on disk, such as one created by
+// CreateMSPDirectory or by cryptogen. The first file in each of the signcerts, keystore and cacerts directories is
+// used, and the cacerts directory is optional.
+func LoadMSPDirectory(directory string) (*identity.Identity, error) {
+	certificateData, err := readFirstFile(path.Join(directory, "signcerts"))
+	if err != nil {
+		return nil, err
+	}
+	cert, err := certificate.FromBytes(certificateData)
+	if err != nil {
+		return nil, err
+	}
+	privateKeyData, err := readFirstFile(path.Join(directory, "keystore"))
+	if err != nil {
+		return nil, err
+	}
+	pk, err := privatekey.FromBytes(privateKeyData)
+	if err != nil {
+		return nil, err
+	}
+	var ca *certificate.Certificate
+	if caData, err := readFirstFile(path.Join(directory, "cacerts")); err == nil {
+		ca, err = certificate.FromBytes(caData)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return identity.FromParts(cert.Certificate().Subject.CommonName, cert, pk, ca)
+}
+
+func readFirstFile(directory string) ([]byte, error) {
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			return ioutil.ReadFile(path.Join(directory, file.Name()))
+		}
+	}
+	return nil, fmt.Errorf("No files found in %s", directory)
+}
+
+func createMSPDirectory(directory string, ca *certificate.Certificate, opts ...MSPOption) (*mspDirectory, error) {
+	m := &mspDirectory{
+		caName:          "ca.pem",
+		certificateName: "cert.pem",
+		privateKeyName:  "key.pem",
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
 	directories := []string{
 		directory,
 		path.Join(directory, "admincerts"),
 		path.Join(directory, "cacerts"),
-		path.Join(directory, "keystore"),
-		path.Join(directory, "signcerts"),
+	}
+	if m.tlsCA != nil {
+		directories = append(directories, path.Join(directory, "tlscacerts"))
 	}
 	for _, directory := range directories {
 		err := os.MkdirAll(directory, 0755)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	err := ioutil.WriteFile(path.Join(directory, "config.yaml"), []byte(config), 0644)
-	if err != nil {
-		return err
-	}
-	privateKey := identity.PrivateKey().Bytes()
-	err = ioutil.WriteFile(path.Join(directory, "keystore", "key.pem"), privateKey, 0644)
+	err := ioutil.WriteFile(path.Join(directory, "config.yaml"), []byte(fmt.Sprintf(config, m.caName)), 0644)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	certificate := identity.Certificate().Bytes()
-	err = ioutil.WriteFile(path.Join(directory, "signcerts", "cert.pem"), certificate, 0644)
-	if err != nil {
-		return err
+	if ca != nil {
+		err = ioutil.WriteFile(path.Join(directory, "cacerts", m.caName), ca.Bytes(), 0644)
+		if err != nil {
+			return nil, err
+		}
 	}
-	if hasCA := identity.CA() != nil; hasCA {
-		ca := identity.CA().Bytes()
-		err = ioutil.WriteFile(path.Join(directory, "cacerts", "ca.pem"), ca, 0644)
+	if m.tlsCA != nil {
+		err = ioutil.WriteFile(path.Join(directory, "tlscacerts", m.tlsCAName), m.tlsCA.Bytes(), 0644)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return m, nil
 }
@@ -0,0 +1,60 @@
+/*
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+package util_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/hyperledger-labs/microfab/internal/pkg/identity"
+	"github.com/hyperledger-labs/microfab/internal/pkg/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the util package", func() {
+
+	var testDirectory string
+
+	BeforeEach(func() {
+		var err error
+		testDirectory, err = ioutil.TempDir("", "ut-util")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(testDirectory)
+	})
+
+	Context("util.LoadMSPDirectory()", func() {
+
+		When("called with an MSP directory", func() {
+			It("loads the signing identity", func() {
+				ca, err := identity.New("Org1 CA", identity.WithIsCA(true))
+				Expect(err).NotTo(HaveOccurred())
+				admin, err := identity.New("Org1 Admin", identity.UsingSigner(ca))
+				Expect(err).NotTo(HaveOccurred())
+				err = util.CreateMSPDirectory(path.Join(testDirectory, "msp"), admin)
+				Expect(err).NotTo(HaveOccurred())
+				loaded, err := util.LoadMSPDirectory(path.Join(testDirectory, "msp"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(loaded.Name()).To(Equal("Org1 Admin"))
+				Expect(loaded.Certificate().Bytes()).To(Equal(admin.Certificate().Bytes()))
+				Expect(loaded.PrivateKey().Bytes()).To(Equal(admin.PrivateKey().Bytes()))
+				Expect(loaded.CA().Bytes()).To(Equal(ca.Certificate().Bytes()))
+			})
+		})
+
+		When("called with a directory without a signing identity", func() {
+			It("returns an error", func() {
+				_, err := util.LoadMSPDirectory(testDirectory)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+	})
+
+})
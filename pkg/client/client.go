@@ -5,10 +5,13 @@
 package client
 
 import (
+	"bytes"
 	"crypto/tls"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -69,6 +72,62 @@ type Identity struct {
 	Wallet      string `json:"wallet"`
 }
 
+// BenchmarkOptions represents the options for a benchmark. The arguments are templates, which can refer to the
+// index of the transaction ({{.Index}}), the worker running it ({{.Worker}}), the organization submitting it
+// ({{.Organization}}), and a random number ({{.Random}}).
+type BenchmarkOptions struct {
+	Channel       string   `json:"channel"`
+	Chaincode     string   `json:"chaincode"`
+	Function      string   `json:"function"`
+	Args          []string `json:"args"`
+	Submit        bool     `json:"submit"`
+	Transactions  int      `json:"transactions"`
+	Concurrency   int      `json:"concurrency"`
+	Organizations []string `json:"organizations,omitempty"`
+}
+
+// BenchmarkLatency represents the latency percentiles of the successful transactions in a benchmark, in milliseconds.
+type BenchmarkLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// BenchmarkResult represents the results of a benchmark, either for a single organization or for all organizations.
+type BenchmarkResult struct {
+	Organization string           `json:"organization,omitempty"`
+	Transactions int              `json:"transactions"`
+	Successes    int              `json:"successes"`
+	Failures     int              `json:"failures"`
+	Throughput   float64          `json:"throughput"`
+	Latency      BenchmarkLatency `json:"latency"`
+	FailureCodes map[string]int   `json:"failure_codes"`
+}
+
+// BenchmarkReport represents the report of a benchmark. The duration is in seconds, and the throughput is the number
+// of successful transactions per second.
+type BenchmarkReport struct {
+	Options       *BenchmarkOptions  `json:"options"`
+	Duration      float64            `json:"duration"`
+	Total         *BenchmarkResult   `json:"total"`
+	Organizations []*BenchmarkResult `json:"organizations"`
+}
+
+type tokenTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
 // New creates a new Microfab client.
 func New(url *url.URL, tlsEnabled bool) (*Client, error) {
 
@@ -95,6 +154,18 @@ func New(url *url.URL, tlsEnabled bool) (*Client, error) {
 
 }
 
+// SetToken sets the bearer token that the client presents to Microfab, for when authentication is enabled for the
+// console.
+func (c *Client) SetToken(token string) {
+	transport := c.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c.httpClient = &http.Client{
+		Transport: &tokenTransport{token, transport},
+	}
+}
+
 // Ping tests the connection to Microfab.
 func (c *Client) Ping() error {
 
@@ -215,6 +286,72 @@ func (c *Client) GetIdentity(organization string) (*Identity, error) {
 	return nil, errors.Errorf("Microfab does not have an admin identity for organization %s", organization)
 }
 
+// GetGateway gets the gateway (connection profile) for the specified organization.
+func (c *Client) GetGateway(organization string) (map[string]interface{}, error) {
+	components, err := c.getComponents()
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range components {
+		if component["type"] == "gateway" && component["wallet"] == organization {
+			return component, nil
+		}
+	}
+	return nil, errors.Errorf("Microfab does not have a gateway for organization %s", organization)
+}
+
+// GetCaliperNetworkConfig gets the Hyperledger Caliper network configuration, in the specified format (json or yaml).
+func (c *Client) GetCaliperNetworkConfig(format string) ([]byte, error) {
+	return c.get("/ak/api/v1/caliper/network", url.Values{"format": {format}})
+}
+
+// GetExplorerConnectionProfile gets the Hyperledger Explorer connection profile for the specified organization, in the
+// specified format (json or yaml). If the organization is empty, the first organization with a peer is used.
+func (c *Client) GetExplorerConnectionProfile(organization string, format string) ([]byte, error) {
+	query := url.Values{"format": {format}}
+	if organization != "" {
+		query.Set("organization", organization)
+	}
+	return c.get("/ak/api/v1/explorer/connection-profile", query)
+}
+
+// RunBenchmark runs a benchmark against a chaincode function, and waits for it to complete.
+func (c *Client) RunBenchmark(options *BenchmarkOptions) (*BenchmarkReport, error) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	target := c.url.ResolveReference(&url.URL{Path: "/ak/api/v1/benchmark"})
+	resp, err := c.httpClient.Post(target.String(), "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.Errorf("Microfab returned HTTP %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	report := &BenchmarkReport{}
+	err = json.NewDecoder(resp.Body).Decode(report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (c *Client) get(path string, query url.Values) ([]byte, error) {
+	target := c.url.ResolveReference(&url.URL{Path: path, RawQuery: query.Encode()})
+	resp, err := c.httpClient.Get(target.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("Microfab returned HTTP %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
 func (c *Client) getComponents() ([]map[string]interface{}, error) {
 	target := c.url.ResolveReference(&url.URL{Path: "/ak/api/v1/components"})
 	resp, err := c.httpClient.Get(target.String())
@@ -0,0 +1,95 @@
+package microfab
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/hyperledger-labs/microfab/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var benchmarkOptions = &client.BenchmarkOptions{}
+var benchmarkJSON bool
+
+var benchmarkCmd = &cobra.Command{
+	Use:     "benchmark [args...]",
+	Short:   "Drives concurrent transactions against a chaincode function and reports the results",
+	GroupID: "mf",
+	Long: `Drives concurrent transactions against a chaincode function and reports the throughput, latency
+percentiles and failure codes for each organization.
+
+Each argument is a Go template, which can refer to the index of the transaction ({{.Index}}), the
+worker running it ({{.Worker}}), the organization submitting it ({{.Organization}}), and a random
+number ({{.Random}}). For example:
+
+  microfab benchmark --chaincode asset --function CreateAsset --submit 'asset{{.Index}}' blue 5`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		benchmarkOptions.Args = args
+		return benchmark()
+	},
+}
+
+func init() {
+	benchmarkCmd.Flags().StringVar(&benchmarkOptions.Channel, "channel", "mychannel", "channel the chaincode is deployed to")
+	benchmarkCmd.Flags().StringVar(&benchmarkOptions.Chaincode, "chaincode", "", "name of the chaincode")
+	benchmarkCmd.Flags().StringVar(&benchmarkOptions.Function, "function", "", "chaincode function to call")
+	benchmarkCmd.Flags().BoolVar(&benchmarkOptions.Submit, "submit", false, "submit the transactions instead of evaluating them")
+	benchmarkCmd.Flags().IntVarP(&benchmarkOptions.Transactions, "transactions", "n", 100, "total number of transactions")
+	benchmarkCmd.Flags().IntVarP(&benchmarkOptions.Concurrency, "concurrency", "c", 10, "number of concurrent transactions")
+	benchmarkCmd.Flags().StringSliceVar(&benchmarkOptions.Organizations, "organization", nil, "organizations to use, defaults to all organizations in the channel")
+	benchmarkCmd.Flags().BoolVar(&benchmarkJSON, "json", false, "print the report as JSON")
+	benchmarkCmd.MarkFlagRequired("chaincode")
+	benchmarkCmd.MarkFlagRequired("function")
+}
+
+func benchmark() error {
+	urlStr := "http://console.127-0-0-1.nip.io:8080"
+	consoleURL, err := url.Parse(urlStr)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to parse URL")
+	}
+	mfc, err := newClient(consoleURL)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to create client to connect to Microfab")
+	}
+	log.Printf("Running %d transactions against '%s' with concurrency %d", benchmarkOptions.Transactions, benchmarkOptions.Function, benchmarkOptions.Concurrency)
+	report, err := mfc.RunBenchmark(benchmarkOptions)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to run benchmark")
+	}
+	if benchmarkJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "ORGANIZATION\tSUCCESS\tFAIL\tTPS\tP50 (ms)\tP90 (ms)\tP99 (ms)\tMAX (ms)\t")
+	for _, result := range append(report.Organizations, report.Total) {
+		name := result.Organization
+		if name == "" {
+			name = "Total"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n", name, result.Successes, result.Failures, result.Throughput, result.Latency.P50, result.Latency.P90, result.Latency.P99, result.Latency.Max)
+	}
+	w.Flush()
+	for _, result := range report.Organizations {
+		codes := []string{}
+		for code := range result.FailureCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Printf("%s: %d x %s\n", result.Organization, result.FailureCodes[code], code)
+		}
+	}
+	return nil
+}
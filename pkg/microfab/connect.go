@@ -8,7 +8,6 @@ import (
 	"os"
 	"path"
 
-	"github.com/hyperledger-labs/microfab/pkg/client"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -59,7 +58,7 @@ func connect() error {
 		os.MkdirAll(rootDir, 0755)
 	}
 
-	mfc, err := client.New(testURL, false)
+	mfc, err := newClient(testURL)
 	if err != nil {
 		return errors.Wrapf(err, "Unable to create client")
 	}
@@ -0,0 +1,155 @@
+package microfab
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hyperledger-labs/microfab/pkg/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var exportDir string
+var exportFormat = "json"
+var exportOrganization string
+
+var exportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Writes out network configuration for use by other tools",
+	GroupID: "mf",
+}
+
+var exportCaliperCmd = &cobra.Command{
+	Use:   "caliper",
+	Short: "Writes out a Hyperledger Caliper network configuration and connection profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportCaliper()
+	},
+}
+
+var exportExplorerCmd = &cobra.Command{
+	Use:   "explorer",
+	Short: "Writes out a Hyperledger Explorer configuration and connection profile",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return exportExplorer()
+	},
+}
+
+func init() {
+	exportCmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "Force overwriting output directory")
+	exportCmd.PersistentFlags().StringVar(&exportDir, "dir", "_mfexport", "output directory")
+	exportCaliperCmd.Flags().StringVar(&exportFormat, "format", "json", "output format, json or yaml")
+	exportExplorerCmd.Flags().StringVar(&exportOrganization, "organization", "", "organization that Explorer connects as")
+	exportCmd.AddCommand(exportCaliperCmd)
+	exportCmd.AddCommand(exportExplorerCmd)
+}
+
+func exportClient() (*client.Client, error) {
+	urlStr := "http://console.127-0-0-1.nip.io:8080"
+	consoleURL, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to parse URL")
+	}
+	if exportFormat != "json" && exportFormat != "yaml" {
+		return nil, errors.Errorf("Invalid format '%s', must be json or yaml", exportFormat)
+	}
+	rootDir := path.Clean(exportDir)
+	log.Printf("Connecting to URL '%s'\n", urlStr)
+	log.Printf("Writing configuration to '%s'\n", rootDir)
+	cfgExists, err := Exists(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfgExists {
+		empty, err := isEmpty(rootDir)
+		if err != nil {
+			return nil, err
+		}
+		if !empty && !force {
+			return nil, errors.Errorf("Output directory '%s' is not empty, use --force to overwrite", rootDir)
+		}
+	} else if err := os.MkdirAll(rootDir, 0755); err != nil {
+		return nil, err
+	}
+	return newClient(consoleURL)
+}
+
+func exportCaliper() error {
+	mfc, err := exportClient()
+	if err != nil {
+		return err
+	}
+	networkConfig, err := mfc.GetCaliperNetworkConfig(exportFormat)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to get Caliper network configuration")
+	}
+	networkFile := path.Join(exportDir, fmt.Sprintf("network.%s", exportFormat))
+	if err := os.WriteFile(networkFile, networkConfig, 0644); err != nil {
+		return err
+	}
+	orgs, err := mfc.GetOrganizations()
+	if err != nil {
+		return errors.Wrapf(err, "Unable to get Organizations")
+	}
+	for _, org := range orgs {
+		// only organizations with a peer have a gateway
+		gateway, err := mfc.GetGateway(org)
+		if err != nil {
+			continue
+		}
+		data, err := json.MarshalIndent(gateway, "", "  ")
+		if err != nil {
+			return err
+		}
+		profile := path.Join(exportDir, fmt.Sprintf("connection-%s.json", strings.ToLower(org)))
+		if err := os.WriteFile(profile, data, 0644); err != nil {
+			return err
+		}
+	}
+	log.Printf("Run Caliper with '--caliper-workspace %s --caliper-networkconfig %s'", exportDir, path.Base(networkFile))
+	return nil
+}
+
+func exportExplorer() error {
+	// Explorer only reads JSON configuration files.
+	exportFormat = "json"
+	mfc, err := exportClient()
+	if err != nil {
+		return err
+	}
+	profile, err := mfc.GetExplorerConnectionProfile(exportOrganization, exportFormat)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to get Explorer connection profile")
+	}
+	profileDir := path.Join(exportDir, "connection-profile")
+	if err := os.MkdirAll(profileDir, 0755); err != nil {
+		return err
+	}
+	profileName := "microfab.json"
+	if err := os.WriteFile(path.Join(profileDir, profileName), profile, 0644); err != nil {
+		return err
+	}
+	config := map[string]interface{}{
+		"network-configs": map[string]interface{}{
+			"microfab": map[string]string{
+				"name":    "Microfab",
+				"profile": fmt.Sprintf("./connection-profile/%s", profileName),
+			},
+		},
+		"license": "Apache-2.0",
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path.Join(exportDir, "config.json"), data, 0644); err != nil {
+		return err
+	}
+	log.Printf("Mount '%s' as the Explorer /opt/explorer/app/platform/fabric directory", exportDir)
+	return nil
+}
@@ -4,7 +4,6 @@ import (
 	"log"
 	"net/url"
 
-	"github.com/hyperledger-labs/microfab/pkg/client"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -25,7 +24,7 @@ func ping() error {
 		return errors.Errorf("Unable to parse URL %s", testURL.String())
 	}
 
-	mfc, err := client.New(testURL, false)
+	mfc, err := newClient(testURL)
 	if err != nil {
 		return errors.Wrapf(err, "Unable to connect create client to connect to Microfab")
 
@@ -2,7 +2,10 @@ package microfab
 
 import (
 	"log"
+	"net/url"
+	"os"
 
+	"github.com/hyperledger-labs/microfab/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -22,6 +25,7 @@ var cfg string
 var mspdir string
 var force bool
 var cfgFile string
+var token string
 
 // Execute the microfab command
 func Execute() {
@@ -33,12 +37,27 @@ func Execute() {
 	}
 }
 
+// newClient creates a client for the console at the specified URL, using the bearer token if one is specified.
+func newClient(consoleURL *url.URL) (*client.Client, error) {
+	mfc, err := client.New(consoleURL, false)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		mfc.SetToken(token)
+	}
+	return mfc, nil
+}
+
 func init() {
 
+	rootCmd.PersistentFlags().StringVar(&token, "token", os.Getenv("MICROFAB_TOKEN"), "bearer token for the console, if authentication is enabled")
 	rootCmd.AddGroup(&cobra.Group{ID: "mf", Title: "microfab"})
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(stopCmd)
 	rootCmd.AddCommand(connectCmd)
 	rootCmd.AddCommand(pingCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(benchmarkCmd)
 
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
@@ -25,6 +26,11 @@ var startCmd = &cobra.Command{
 }
 
 var logs bool
+var profile string
+var profilesFile string
+
+// containerProfilesFile is where the profiles file is mounted inside the container.
+const containerProfilesFile = "/etc/microfab/profiles.json"
 
 func init() {
 	startCmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "Force restart if microfab already running")
@@ -32,6 +38,8 @@ func init() {
 
 	startCmd.PersistentFlags().StringVar(&cfg, "config", defaultCfg, "Microfab config")
 	startCmd.PersistentFlags().StringVar(&cfgFile, "configFile", "", "Microfab config file")
+	startCmd.PersistentFlags().StringVar(&profile, "profile", "", "Microfab config profiles to apply, for example two-org-tls,ci")
+	startCmd.PersistentFlags().StringVar(&profilesFile, "profiles", os.Getenv("MICROFAB_PROFILES"), "File that defines additional Microfab config profiles")
 
 	startCmd.MarkFlagsMutuallyExclusive("config", "configFile")
 
@@ -53,11 +61,30 @@ func start() error {
 
 	cfg, err = GetConfig()
 	if err != nil {
-		return errors.Wrapf(err, "Unable to determine config")
+		if profile == "" {
+			return errors.Wrapf(err, "Unable to determine config")
+		}
+		// the profiles provide the configuration
+		cfg = "{}"
 	}
 
 	env[0] = "FABRIC_LOGGING_SPEC=info"
 	env[1] = fmt.Sprintf("MICROFAB_CONFIG=%s", cfg)
+	if profile != "" {
+		env = append(env, fmt.Sprintf("MICROFAB_PROFILE=%s", profile))
+	}
+	binds := []string{}
+	if profilesFile != "" {
+		absProfilesFile, err := filepath.Abs(profilesFile)
+		if err != nil {
+			return errors.Wrapf(err, "Unable to find profiles file %s", profilesFile)
+		}
+		if _, err := os.Stat(absProfilesFile); err != nil {
+			return errors.Wrapf(err, "Unable to read profiles file %s", profilesFile)
+		}
+		binds = append(binds, fmt.Sprintf("%s:%s:ro", absProfilesFile, containerProfilesFile))
+		env = append(env, fmt.Sprintf("MICROFAB_PROFILES=%s", containerProfilesFile))
+	}
 	microFabImage := "ghcr.io/hyperledger-labs/microfab:latest"
 	containername := "microfab"
 
@@ -93,6 +120,7 @@ func start() error {
 	hostConfig := &container.HostConfig{
 		PortBindings: map[nat.Port][]nat.PortBinding{nat.Port("8080"): {{HostIP: "127.0.0.1", HostPort: "8080"}}},
 		AutoRemove:   true,
+		Binds:        binds,
 	}
 
 	resp, err := cli.ContainerCreate(ctx, config, hostConfig, nil, nil, containername)